@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"git.sr.ht/~sircmpwn/getopt"
 	"github.com/gdamore/tcell/v2"
@@ -18,12 +19,16 @@ import (
 	"git.sr.ht/~rjarry/aerc/commands"
 	"git.sr.ht/~rjarry/aerc/commands/account"
 	"git.sr.ht/~rjarry/aerc/commands/compose"
+	"git.sr.ht/~rjarry/aerc/commands/contacts"
 	"git.sr.ht/~rjarry/aerc/commands/msg"
 	"git.sr.ht/~rjarry/aerc/commands/msgview"
+	"git.sr.ht/~rjarry/aerc/commands/sieve"
 	"git.sr.ht/~rjarry/aerc/commands/terminal"
+	"git.sr.ht/~rjarry/aerc/commands/threadview"
 	"git.sr.ht/~rjarry/aerc/config"
 	"git.sr.ht/~rjarry/aerc/lib/crypto"
 	"git.sr.ht/~rjarry/aerc/lib/ipc"
+	"git.sr.ht/~rjarry/aerc/lib/state"
 	"git.sr.ht/~rjarry/aerc/lib/templates"
 	libui "git.sr.ht/~rjarry/aerc/lib/ui"
 	"git.sr.ht/~rjarry/aerc/log"
@@ -56,18 +61,34 @@ func getCommands(selected libui.Drawable) []*commands.Commands {
 			terminal.TerminalCommands,
 			commands.GlobalCommands,
 		}
+	case *widgets.ContactsView:
+		return []*commands.Commands{
+			contacts.ContactsCommands,
+			commands.GlobalCommands,
+		}
+	case *widgets.SieveView:
+		return []*commands.Commands{
+			sieve.SieveCommands,
+			commands.GlobalCommands,
+		}
+	case *widgets.ThreadViewer:
+		return []*commands.Commands{
+			threadview.ThreadViewCommands,
+			msg.MessageCommands,
+			commands.GlobalCommands,
+		}
 	default:
 		return []*commands.Commands{commands.GlobalCommands}
 	}
 }
 
 func execCommand(
-	aerc *widgets.Aerc, ui *libui.UI, cmd []string,
-	acct *config.AccountConfig, msg *models.MessageInfo,
+	aerc *widgets.Aerc, exit func(), cmd []string,
+	data models.TemplateData,
 ) error {
 	cmds := getCommands(aerc.SelectedTabContent())
 	for i, set := range cmds {
-		err := set.ExecuteCommand(aerc, cmd, acct, msg)
+		err := set.ExecuteCommand(aerc, cmd, data)
 		if err != nil {
 			if errors.As(err, new(commands.NoSuchCommand)) {
 				if i == len(cmds)-1 {
@@ -76,7 +97,7 @@ func execCommand(
 				continue
 			}
 			if errors.As(err, new(commands.ErrorExit)) {
-				ui.Exit()
+				exit()
 				return nil
 			}
 			return err
@@ -115,6 +136,10 @@ func buildInfo() string {
 func usage(msg string) {
 	fmt.Fprintln(os.Stderr, msg)
 	fmt.Fprintln(os.Stderr, "usage: aerc [-v] [-a <account-name[,account-name>] [mailto:...]")
+	fmt.Fprintln(os.Stderr, "       aerc --json <method> [<args>...]")
+	fmt.Fprintln(os.Stderr, "       aerc --batch [-a <account-name[,account-name>] [-t <timeout>] <script>")
+	fmt.Fprintln(os.Stderr, "       aerc --check-config")
+	fmt.Fprintln(os.Stderr, "       aerc --import-mutt [-n <account-name>] [-o <dir>] <muttrc>")
 	os.Exit(1)
 }
 
@@ -141,6 +166,18 @@ func setWindowTitle() {
 
 func main() {
 	defer log.PanicHandler()
+	if len(os.Args) > 1 && os.Args[1] == "--json" {
+		os.Exit(jsonMain(os.Args[2:])) //nolint:gocritic // PanicHandler does not need to run as it's not a panic
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--batch" {
+		os.Exit(batchMain(os.Args[1:])) //nolint:gocritic // PanicHandler does not need to run as it's not a panic
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--check-config" {
+		os.Exit(checkConfigMain()) //nolint:gocritic // PanicHandler does not need to run as it's not a panic
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--import-mutt" {
+		os.Exit(importMuttMain(os.Args[1:])) //nolint:gocritic // PanicHandler does not need to run as it's not a panic
+	}
 	opts, optind, err := getopt.Getopts(os.Args, "va:")
 	if err != nil {
 		usage("error: " + err.Error())
@@ -172,6 +209,7 @@ func main() {
 	err = config.LoadConfigFromFile(nil, accts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Run aerc --check-config to list all the problems.")
 		os.Exit(1) //nolint:gocritic // PanicHandler does not need to run as it's not a panic
 	}
 
@@ -192,10 +230,9 @@ func main() {
 	defer c.Close()
 
 	aerc = widgets.NewAerc(c, func(
-		cmd []string, acct *config.AccountConfig,
-		msg *models.MessageInfo,
+		cmd []string, data models.TemplateData,
 	) error {
-		return execCommand(aerc, ui, cmd, acct, msg)
+		return execCommand(aerc, ui.Exit, cmd, data)
 	}, func(cmd string) []string {
 		return getCompletions(aerc, cmd)
 	}, &commands.CmdHistory, deferLoop)
@@ -241,6 +278,9 @@ func main() {
 		setWindowTitle()
 	}
 
+	aerc.RunTrigger(config.Triggers.AercStartup, new(state.TemplateData))
+	aerc.WatchConfig()
+
 	ui.ChannelEvents()
 	for event := range libui.MsgChannel {
 		switch event := event.(type) {
@@ -256,6 +296,8 @@ func main() {
 		}
 		ui.Render()
 	}
+	aerc.RunTrigger(config.Triggers.AercShutdown, new(state.TemplateData))
+	widgets.WaitTriggers(5 * time.Second)
 	err = aerc.CloseBackends()
 	if err != nil {
 		log.Warnf("failed to close backends: %v", err)
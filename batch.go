@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"git.sr.ht/~sircmpwn/getopt"
+	"github.com/google/shlex"
+
+	"git.sr.ht/~rjarry/aerc/commands"
+	"git.sr.ht/~rjarry/aerc/commands/mode"
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/crypto"
+	"git.sr.ht/~rjarry/aerc/lib/templates"
+	libui "git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/models"
+	"git.sr.ht/~rjarry/aerc/widgets"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+)
+
+const batchUsage = `usage: aerc --batch [-a <account-name[,account-name>] [-t <timeout>] <script>|-`
+
+// batchSettle is how long the backends must stay quiet before a command is
+// considered complete.
+const batchSettle = 500 * time.Millisecond
+
+type batch struct {
+	aerc     *widgets.Aerc
+	accounts []*widgets.AccountView
+	timeout  time.Duration
+	settle   time.Duration
+	errors   int32
+	exit     bool
+}
+
+// batchMain loads the accounts and runs the commands of a script without user
+// interface. It returns the exit status.
+func batchMain(args []string) int {
+	opts, optind, err := getopt.Getopts(args, "a:t:")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error: "+err.Error())
+		fmt.Fprintln(os.Stderr, batchUsage)
+		return 1
+	}
+	b := &batch{timeout: 5 * time.Minute}
+	var accts []string
+	for _, opt := range opts {
+		switch opt.Option {
+		case 'a':
+			accts = strings.Split(opt.Value, ",")
+		case 't':
+			b.timeout, err = time.ParseDuration(opt.Value)
+			if err != nil || b.timeout <= 0 {
+				fmt.Fprintf(os.Stderr, "error: invalid timeout: %s\n", opt.Value)
+				return 1
+			}
+		}
+	}
+	if len(args)-optind != 1 {
+		fmt.Fprintln(os.Stderr, batchUsage)
+		return 1
+	}
+	script, err := readBatchScript(args[optind])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aerc: %v\n", err)
+		return 1
+	}
+
+	log.BuildInfo = buildInfo()
+	config.Batch = true
+	err = config.LoadConfigFromFile(nil, accts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	log.Infof("Starting up version %s in batch mode", log.BuildInfo)
+	templates.SetVersion(Version)
+
+	c := crypto.New()
+	err = c.Init()
+	if err != nil {
+		log.Warnf("failed to initialise crypto interface: %v", err)
+	}
+	defer c.Close()
+
+	b.aerc = widgets.NewAerc(c, func(
+		cmd []string, data models.TemplateData,
+	) error {
+		return execCommand(b.aerc, b.quit, cmd, data)
+	}, func(cmd string) []string {
+		return nil
+	}, &commands.CmdHistory, nil)
+	b.aerc.OnStatus(b.status)
+	defer func() {
+		widgets.WaitTriggers(5 * time.Second)
+		if err := b.aerc.CloseBackends(); err != nil {
+			log.Warnf("failed to close backends: %v", err)
+		}
+	}()
+
+	if err := b.connect(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	for _, line := range script {
+		if err := b.run(line); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", line, err)
+			return 1
+		}
+		if b.failed() {
+			return 1
+		}
+		if b.exit {
+			break
+		}
+	}
+	return 0
+}
+
+// readBatchScript returns the commands of the script file, or of the standard
+// input when the file is "-". Empty lines and comments are skipped.
+func readBatchScript(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimPrefix(line, ":"))
+	}
+	return lines, scanner.Err()
+}
+
+func (b *batch) status(text string, isError bool) {
+	if isError {
+		atomic.AddInt32(&b.errors, 1)
+		fmt.Fprintln(os.Stderr, "error: "+text)
+	} else {
+		fmt.Println(text)
+	}
+}
+
+func (b *batch) quit() {
+	b.exit = true
+}
+
+func (b *batch) failed() bool {
+	return atomic.LoadInt32(&b.errors) > 0
+}
+
+// connect waits until all accounts are connected and their default folder is
+// opened.
+func (b *batch) connect() error {
+	if len(config.Accounts) == 0 {
+		return errors.New("no account configured")
+	}
+	for _, conf := range config.Accounts {
+		acct, err := b.aerc.Account(conf.Name)
+		if err != nil {
+			return fmt.Errorf("%s: failed to load account", conf.Name)
+		}
+		b.accounts = append(b.accounts, acct)
+		// folders are opened after a delay
+		delay := config.Ui.ForAccount(conf.Name).DirListDelay
+		if batchSettle+delay > b.settle {
+			b.settle = batchSettle + delay
+		}
+	}
+	err := b.wait(func() bool {
+		for _, acct := range b.accounts {
+			if !acct.Connected() {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if b.failed() {
+		return errors.New("failed to connect")
+	}
+	return nil
+}
+
+// run executes a command of the script and waits for the backends to
+// complete the actions it started.
+func (b *batch) run(line string) error {
+	log.Debugf("batch: %s", line)
+	cmd, err := shlex.Split(line)
+	if err != nil {
+		return err
+	}
+	if err := execCommand(b.aerc, b.quit, cmd, nil); err != nil {
+		return err
+	}
+	return b.wait(func() bool { return true })
+}
+
+// wait processes the messages of the main loop until the backends are idle
+// and ready returns true, or an error was reported.
+func (b *batch) wait(ready func() bool) error {
+	deadline := time.Now().Add(b.timeout)
+	last := time.Now()
+	ticker := time.NewTicker(batchSettle / 10)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-libui.MsgChannel:
+			// redraw requests are ignored, there is nothing to draw
+			switch msg := msg.(type) {
+			case *libui.AercFuncMsg:
+				msg.Func()
+				last = time.Now()
+			case types.WorkerMessage:
+				b.aerc.HandleMessage(msg)
+				last = time.Now()
+			}
+		case now := <-ticker.C:
+			switch {
+			case b.failed():
+				return nil
+			case now.Sub(last) >= b.settle && b.idle() && ready():
+				return nil
+			case now.After(deadline):
+				return fmt.Errorf("timed out after %s", b.timeout)
+			}
+		}
+	}
+}
+
+func (b *batch) idle() bool {
+	if !mode.QuitAllowed() {
+		return false
+	}
+	for _, acct := range b.accounts {
+		if acct.Worker().Pending() > 0 {
+			return false
+		}
+	}
+	return true
+}
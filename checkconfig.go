@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"git.sr.ht/~rjarry/aerc/commands"
+	"git.sr.ht/~rjarry/aerc/config"
+	libui "git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+// bindsContexts maps the sections of binds.conf to the tabs where their
+// bindings are active.
+var bindsContexts = map[string]libui.Drawable{
+	"messages":          (*widgets.AccountView)(nil),
+	"view":              (*widgets.MessageViewer)(nil),
+	"view::passthrough": (*widgets.MessageViewer)(nil),
+	"compose":           (*widgets.Composer)(nil),
+	"compose::editor":   (*widgets.Composer)(nil),
+	"compose::review":   (*widgets.Composer)(nil),
+	"terminal":          (*widgets.Terminal)(nil),
+	"contacts":          (*widgets.ContactsView)(nil),
+	"sieve":             (*widgets.SieveView)(nil),
+	"view-thread":       (*widgets.ThreadViewer)(nil),
+}
+
+// commandExists reports if a command can be run from a section of
+// binds.conf. The global bindings are active in all tabs.
+func commandExists(section, name string) bool {
+	var contexts []libui.Drawable
+	if section == "default" {
+		for _, context := range bindsContexts {
+			contexts = append(contexts, context)
+		}
+	} else {
+		contexts = append(contexts, bindsContexts[section])
+	}
+	for _, context := range contexts {
+		for _, set := range getCommands(context) {
+			if set.ByName(name) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkConfigMain prints the problems found in the configuration files. It
+// returns the exit status, which is 1 if any error was found.
+func checkConfigMain() int {
+	status := 0
+	for _, problem := range config.Check(nil, commandExists) {
+		fmt.Println(problem)
+		if !problem.Warning {
+			status = 1
+		}
+	}
+	return status
+}
+
+func init() {
+	commands.CommandExists = commandExists
+}
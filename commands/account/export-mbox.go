@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"git.sr.ht/~rjarry/aerc/commands/mode"
 	"git.sr.ht/~rjarry/aerc/log"
 	"git.sr.ht/~rjarry/aerc/widgets"
 	mboxer "git.sr.ht/~rjarry/aerc/worker/mbox"
@@ -52,8 +53,10 @@ func (ExportMbox) Execute(aerc *widgets.Aerc, args []string) error {
 
 	aerc.PushStatus("Exporting to "+filename, 10*time.Second)
 
+	mode.NoQuit()
 	go func() {
 		defer log.PanicHandler()
+		defer mode.NoQuitDone()
 		file, err := os.Create(filename)
 		if err != nil {
 			log.Errorf("failed to create file: %v", err)
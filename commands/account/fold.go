@@ -0,0 +1,64 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+	"git.sr.ht/~sircmpwn/getopt"
+)
+
+type Fold struct{}
+
+func init() {
+	register(Fold{})
+}
+
+func (Fold) Aliases() []string {
+	return []string{"fold", "unfold", "toggle-fold"}
+}
+
+func (Fold) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (Fold) Execute(aerc *widgets.Aerc, args []string) error {
+	opts, optind, err := getopt.Getopts(args, "a")
+	if err != nil {
+		return err
+	}
+	if len(args) != optind {
+		return fmt.Errorf("Usage: %s [-a]", args[0])
+	}
+	all := false
+	for _, opt := range opts {
+		if opt.Option == 'a' {
+			all = true
+		}
+	}
+	acct := aerc.SelectedAccount()
+	if acct == nil {
+		return errors.New("No account selected")
+	}
+	store := acct.Store()
+	if store == nil {
+		return errors.New("Cannot perform action. Messages still loading")
+	}
+	msg := store.Selected()
+	if msg == nil {
+		return nil
+	}
+	var fold bool
+	switch args[0] {
+	case "fold":
+		fold = true
+	case "unfold":
+		fold = false
+	default:
+		fold = !store.IsFolded(msg.Uid)
+	}
+	if all {
+		return store.FoldAll(fold)
+	}
+	return store.Fold(msg.Uid, fold)
+}
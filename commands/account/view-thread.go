@@ -0,0 +1,89 @@
+package account
+
+import (
+	"errors"
+
+	"git.sr.ht/~rjarry/aerc/models"
+	"git.sr.ht/~rjarry/aerc/widgets"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+	"git.sr.ht/~sircmpwn/getopt"
+)
+
+type ViewThread struct{}
+
+func init() {
+	register(ViewThread{})
+}
+
+func (ViewThread) Aliases() []string {
+	return []string{"view-thread"}
+}
+
+func (ViewThread) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (ViewThread) Execute(aerc *widgets.Aerc, args []string) error {
+	peek := false
+	opts, optind, err := getopt.Getopts(args, "p")
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		if opt.Option == 'p' {
+			peek = true
+		}
+	}
+	if len(args) != optind {
+		return errors.New("Usage: view-thread [-p]")
+	}
+	acct := aerc.SelectedAccount()
+	if acct == nil {
+		return errors.New("No account selected")
+	}
+	store := acct.Store()
+	if store == nil {
+		return errors.New("Cannot perform action. Messages still loading")
+	}
+	selected := store.Selected()
+	if selected == nil || selected.Envelope == nil {
+		return nil
+	}
+	uids := []uint32{selected.Uid}
+	if thread := store.SelectedThread(); thread != nil {
+		uids = thread.Root().Uids()
+	}
+	var missing []uint32
+	for _, uid := range uids {
+		if msg, ok := store.Messages[uid]; ok && msg == nil {
+			missing = append(missing, uid)
+		}
+	}
+	markRead := !peek && acct.UiConfig().AutoMarkRead
+	open := func() {
+		var msgs []*models.MessageInfo
+		for _, uid := range uids {
+			msg := store.Messages[uid]
+			if _, deleted := store.Deleted[uid]; deleted || msg == nil ||
+				msg.Envelope == nil {
+				continue
+			}
+			msgs = append(msgs, msg)
+		}
+		viewer := widgets.NewThreadViewer(acct, store, msgs, markRead)
+		aerc.NewTab(viewer, selected.Envelope.Subject)
+	}
+	if len(missing) == 0 {
+		open()
+		return nil
+	}
+	store.FetchHeaders(missing, func(msg types.WorkerMessage) {
+		switch msg := msg.(type) {
+		case *types.Done:
+			open()
+		case *types.Error:
+			aerc.PushError(msg.Error.Error())
+		}
+	})
+	return nil
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+// CommandExists reports if a command can be run from a section of
+// binds.conf. It is set by the main package which knows all the commands.
+var CommandExists func(section, name string) bool
+
+type CheckConfig struct{}
+
+func init() {
+	register(CheckConfig{})
+}
+
+func (CheckConfig) Aliases() []string {
+	return []string{"check-config"}
+}
+
+func (CheckConfig) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (CheckConfig) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: check-config")
+	}
+	problems := config.Check(nil, CommandExists)
+	if len(problems) == 0 {
+		aerc.PushSuccess("No problem found in the configuration")
+		return nil
+	}
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		lines[i] = p.String()
+	}
+	dialog := widgets.NewSelectorDialog(
+		fmt.Sprintf("%d configuration problem(s)", len(problems)),
+		strings.Join(lines, "\n"),
+		[]string{"OK"}, 0, aerc.SelectedAccountUiConfig(),
+		func(string, error) {
+			aerc.CloseDialog()
+		},
+	)
+	aerc.AddDialog(dialog)
+	return nil
+}
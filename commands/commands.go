@@ -70,21 +70,15 @@ type CommandSource interface {
 	Commands() *Commands
 }
 
-func templateData(
-	aerc *widgets.Aerc,
-	cfg *config.AccountConfig,
-	msg *models.MessageInfo,
-) models.TemplateData {
+func templateData(aerc *widgets.Aerc) models.TemplateData {
 	var folder string
+	var cfg *config.AccountConfig
+	var msg *models.MessageInfo
 
 	acct := aerc.SelectedAccount()
 	if acct != nil {
 		folder = acct.SelectedDirectory()
-	}
-	if cfg == nil && acct != nil {
 		cfg = acct.AccountConfig()
-	}
-	if msg == nil && acct != nil {
 		msg, _ = acct.SelectedMessage()
 	}
 
@@ -97,11 +91,12 @@ func templateData(
 	return &data
 }
 
+// ExecuteCommand expands the templates in args and executes the command. When
+// data is nil, the templates refer to the selected account and message.
 func (cmds *Commands) ExecuteCommand(
 	aerc *widgets.Aerc,
 	args []string,
-	account *config.AccountConfig,
-	msg *models.MessageInfo,
+	data models.TemplateData,
 ) error {
 	if len(args) == 0 {
 		return errors.New("Expected a command.")
@@ -109,7 +104,9 @@ func (cmds *Commands) ExecuteCommand(
 	if cmd, ok := cmds.dict()[args[0]]; ok {
 		log.Tracef("executing command %v", args)
 		var buf bytes.Buffer
-		data := templateData(aerc, account, msg)
+		if data == nil {
+			data = templateData(aerc)
+		}
 
 		processedArgs := make([]string, len(args))
 		for i, arg := range args {
@@ -17,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 
 	"git.sr.ht/~rjarry/aerc/commands/mode"
+	"git.sr.ht/~rjarry/aerc/config"
 	"git.sr.ht/~rjarry/aerc/lib"
 	"git.sr.ht/~rjarry/aerc/log"
 	"git.sr.ht/~rjarry/aerc/models"
@@ -62,15 +63,6 @@ func (Send) Execute(aerc *widgets.Aerc, args []string) error {
 	tabName := tab.Name
 	config := composer.Config()
 
-	outgoing, err := config.Outgoing.ConnectionString()
-	if err != nil {
-		return errors.Wrap(err, "ReadCredentials(outgoing)")
-	}
-	if outgoing == "" {
-		return errors.New(
-			"No outgoing mail transport configured for this account")
-	}
-
 	header, err := composer.PrepareHeader()
 	if err != nil {
 		return errors.Wrap(err, "PrepareHeader")
@@ -80,32 +72,10 @@ func (Send) Execute(aerc *widgets.Aerc, args []string) error {
 		return errors.Wrap(err, "listRecipients")
 	}
 
-	uri, err := url.Parse(outgoing)
-	if err != nil {
-		return errors.Wrap(err, "url.Parse(outgoing)")
-	}
-
-	scheme, auth, err := parseScheme(uri)
+	ctx, err := newSendCtx(config, rcpts)
 	if err != nil {
 		return err
 	}
-	var starttls bool
-	if starttls_, ok := config.Params["smtp-starttls"]; ok {
-		starttls = starttls_ == "yes"
-	}
-	var domain string
-	if domain_, ok := config.Params["smtp-domain"]; ok {
-		domain = domain_
-	}
-	ctx := sendCtx{
-		uri:      uri,
-		scheme:   scheme,
-		auth:     auth,
-		starttls: starttls,
-		from:     config.From,
-		rcpts:    rcpts,
-		domain:   domain,
-	}
 
 	log.Debugf("send config uri: %s", ctx.uri)
 	log.Debugf("send config scheme: %s", ctx.scheme)
@@ -166,18 +136,7 @@ func send(aerc *widgets.Aerc, composer *widgets.Composer, ctx sendCtx,
 	go func() {
 		defer log.PanicHandler()
 
-		var sender io.WriteCloser
-		var err error
-		switch ctx.scheme {
-		case "smtp":
-			fallthrough
-		case "smtps":
-			sender, err = newSmtpSender(ctx)
-		case "":
-			sender, err = newSendmailSender(ctx)
-		default:
-			sender, err = nil, fmt.Errorf("unsupported scheme %v", ctx.scheme)
-		}
+		sender, err := newSender(ctx)
 		if err != nil {
 			failCh <- errors.Wrap(err, "send:")
 			return
@@ -206,6 +165,7 @@ func send(aerc *widgets.Aerc, composer *widgets.Composer, ctx sendCtx,
 		err := <-failCh
 		if err != nil {
 			aerc.PushError(strings.ReplaceAll(err.Error(), "\n", " "))
+			composer.SendFailed(header, err)
 			aerc.NewTab(composer, tabName)
 			return
 		}
@@ -220,12 +180,14 @@ func send(aerc *widgets.Aerc, composer *widgets.Composer, ctx sendCtx,
 					config.CopyTo, err.Error())
 				aerc.PushError(errmsg)
 				composer.SetSent(archive)
+				composer.TriggerSent(header)
 				composer.Close()
 				return
 			}
 		}
 		aerc.PushStatus("Message sent.", 10*time.Second)
 		composer.SetSent(archive)
+		composer.TriggerSent(header)
 		composer.Close()
 	}()
 }
@@ -252,6 +214,72 @@ type sendCtx struct {
 	domain   string
 }
 
+// newSendCtx prepares the outgoing transport settings of an account for the
+// given recipients.
+func newSendCtx(acct *config.AccountConfig,
+	rcpts []*mail.Address,
+) (sendCtx, error) {
+	var ctx sendCtx
+	outgoing, err := acct.Outgoing.ConnectionString()
+	if err != nil {
+		return ctx, errors.Wrap(err, "ReadCredentials(outgoing)")
+	}
+	if outgoing == "" {
+		return ctx, errors.New(
+			"No outgoing mail transport configured for this account")
+	}
+	uri, err := url.Parse(outgoing)
+	if err != nil {
+		return ctx, errors.Wrap(err, "url.Parse(outgoing)")
+	}
+	scheme, auth, err := parseScheme(uri)
+	if err != nil {
+		return ctx, err
+	}
+	var starttls bool
+	if starttls_, ok := acct.Params["smtp-starttls"]; ok {
+		starttls = starttls_ == "yes"
+	}
+	var domain string
+	if domain_, ok := acct.Params["smtp-domain"]; ok {
+		domain = domain_
+	}
+	return sendCtx{
+		uri:      uri,
+		scheme:   scheme,
+		auth:     auth,
+		starttls: starttls,
+		from:     acct.From,
+		rcpts:    rcpts,
+		domain:   domain,
+	}, nil
+}
+
+func newSender(ctx sendCtx) (io.WriteCloser, error) {
+	switch ctx.scheme {
+	case "smtp", "smtps":
+		return newSmtpSender(ctx)
+	case "":
+		return newSendmailSender(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported scheme %v", ctx.scheme)
+	}
+}
+
+// NewSender opens the outgoing mail transport of an account (SMTP or
+// sendmail) to submit a message to the given recipients. The whole message
+// must be written to the returned WriteCloser which must then be closed to
+// complete the submission.
+func NewSender(acct *config.AccountConfig,
+	rcpts []*mail.Address,
+) (io.WriteCloser, error) {
+	ctx, err := newSendCtx(acct, rcpts)
+	if err != nil {
+		return nil, err
+	}
+	return newSender(ctx)
+}
+
 func newSendmailSender(ctx sendCtx) (io.WriteCloser, error) {
 	args, err := shlex.Split(ctx.uri.Path)
 	if err != nil {
@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"errors"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type Contacts struct{}
+
+func init() {
+	register(Contacts{})
+}
+
+func (Contacts) Aliases() []string {
+	return []string{"contacts"}
+}
+
+func (Contacts) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (Contacts) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: contacts")
+	}
+	acct := aerc.SelectedAccount()
+	if acct == nil {
+		return errors.New("No account selected")
+	}
+	view := widgets.NewContactsView(aerc, acct)
+	aerc.NewTab(view, "contacts")
+	return nil
+}
@@ -0,0 +1,43 @@
+package contacts
+
+import (
+	"errors"
+	"net/url"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type ComposeContact struct{}
+
+func init() {
+	register(ComposeContact{})
+}
+
+func (ComposeContact) Aliases() []string {
+	return []string{"compose-contact"}
+}
+
+func (ComposeContact) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (ComposeContact) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: compose-contact")
+	}
+	view, err := contactsView(aerc)
+	if err != nil {
+		return err
+	}
+	contact := view.Selected()
+	if contact == nil {
+		return errors.New("No contact selected")
+	}
+	query := url.Values{}
+	query.Set("account", view.SelectedAccount().Name())
+	return aerc.Mailto(&url.URL{
+		Scheme:   "mailto",
+		Opaque:   contact.String(),
+		RawQuery: query.Encode(),
+	})
+}
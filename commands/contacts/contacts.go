@@ -0,0 +1,38 @@
+package contacts
+
+import (
+	"errors"
+
+	"git.sr.ht/~rjarry/aerc/commands"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+var ContactsCommands *commands.Commands
+
+func register(cmd commands.Command) {
+	if ContactsCommands == nil {
+		ContactsCommands = commands.NewCommands()
+	}
+	ContactsCommands.Register(cmd)
+}
+
+func contactsView(aerc *widgets.Aerc) (*widgets.ContactsView, error) {
+	view, ok := aerc.SelectedTabContent().(*widgets.ContactsView)
+	if !ok {
+		return nil, errors.New("not in the contacts tab")
+	}
+	return view, nil
+}
+
+// contactAddresses returns all known contact addresses.
+func contactAddresses(aerc *widgets.Aerc) []string {
+	view, err := contactsView(aerc)
+	if err != nil {
+		return nil
+	}
+	var addrs []string
+	for _, c := range view.Book().Contacts() {
+		addrs = append(addrs, c.Addresses...)
+	}
+	return addrs
+}
@@ -0,0 +1,54 @@
+package contacts
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"git.sr.ht/~sircmpwn/getopt"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type EditContact struct{}
+
+func init() {
+	register(EditContact{})
+}
+
+func (EditContact) Aliases() []string {
+	return []string{"edit-contact"}
+}
+
+func (EditContact) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (EditContact) Execute(aerc *widgets.Aerc, args []string) error {
+	opts, optind, err := getopt.Getopts(args, "a:d:")
+	if err != nil {
+		return err
+	}
+	var add, remove []string
+	for _, opt := range opts {
+		switch opt.Option {
+		case 'a':
+			addr, err := mail.ParseAddress(opt.Value)
+			if err != nil {
+				return err
+			}
+			add = append(add, addr.Address)
+		case 'd':
+			remove = append(remove, opt.Value)
+		}
+	}
+	name := strings.Join(args[optind:], " ")
+	if name == "" && len(add) == 0 && len(remove) == 0 {
+		return errors.New("Usage: edit-contact [-a <address>] [-d <address>] [<name>]")
+	}
+	view, err := contactsView(aerc)
+	if err != nil {
+		return err
+	}
+	return view.Edit(name, add, remove)
+}
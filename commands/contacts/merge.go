@@ -0,0 +1,38 @@
+package contacts
+
+import (
+	"errors"
+	"fmt"
+
+	"git.sr.ht/~rjarry/aerc/commands"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type MergeContact struct{}
+
+func init() {
+	register(MergeContact{})
+}
+
+func (MergeContact) Aliases() []string {
+	return []string{"merge-contact"}
+}
+
+func (MergeContact) Complete(aerc *widgets.Aerc, args []string) []string {
+	return commands.CompletionFromList(aerc, contactAddresses(aerc), args)
+}
+
+func (MergeContact) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 2 {
+		return errors.New("Usage: merge-contact <address>")
+	}
+	view, err := contactsView(aerc)
+	if err != nil {
+		return err
+	}
+	src := view.Book().Lookup(args[1])
+	if src == nil {
+		return fmt.Errorf("No contact with address %s", args[1])
+	}
+	return view.Merge(src)
+}
@@ -0,0 +1,51 @@
+package msg
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type ApplyRules struct{}
+
+func init() {
+	register(ApplyRules{})
+}
+
+func (ApplyRules) Aliases() []string {
+	return []string{"apply-rules"}
+}
+
+func (ApplyRules) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (ApplyRules) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: apply-rules")
+	}
+	if len(config.Rules) == 0 {
+		return errors.New("No rules defined in aerc.conf")
+	}
+	h := newHelper(aerc)
+	acct, err := h.account()
+	if err != nil {
+		return err
+	}
+	store, err := h.store()
+	if err != nil {
+		return err
+	}
+	uids, err := h.markedOrSelectedUids()
+	if err != nil {
+		return err
+	}
+	store.Marker().ClearVisualMark()
+	n := acct.ApplyRules(store, uids)
+	aerc.PushStatus(fmt.Sprintf("Rules matched %d of %d messages",
+		n, len(uids)), 10*time.Second)
+	return nil
+}
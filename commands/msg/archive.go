@@ -111,7 +111,7 @@ func archive(aerc *widgets.Aerc, msgs []*models.MessageInfo, archiveType string)
 
 		wg.Wait()
 		if success {
-			handleDone(aerc, acct, next, "Messages archived.", store)
+			handleDone(aerc, acct, uids, next, "Messages archived.", store)
 		}
 	}()
 	return nil
@@ -0,0 +1,139 @@
+package msg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-message/textproto"
+
+	"git.sr.ht/~rjarry/aerc/commands/compose"
+	"git.sr.ht/~rjarry/aerc/commands/mode"
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/widgets"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+)
+
+type Bounce struct{}
+
+func init() {
+	register(Bounce{})
+}
+
+func (Bounce) Aliases() []string {
+	return []string{"bounce", "resend"}
+}
+
+func (Bounce) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (Bounce) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) < 2 {
+		return errors.New("Usage: bounce <address>...")
+	}
+	rcpts, err := mail.ParseAddressList(strings.Join(args[1:], ", "))
+	if err != nil {
+		return fmt.Errorf("invalid address(es): %w", err)
+	}
+
+	h := newHelper(aerc)
+	acct, err := h.account()
+	if err != nil {
+		return err
+	}
+	store, err := h.store()
+	if err != nil {
+		return err
+	}
+	uids, err := h.markedOrSelectedUids()
+	if err != nil {
+		return err
+	}
+	config := acct.AccountConfig()
+	if config.From == nil {
+		return errors.New("No from address configured for this account")
+	}
+
+	store.FetchFull(uids, func(fm *types.FullMessage) {
+		raw, err := io.ReadAll(fm.Content.Reader)
+		if err != nil {
+			aerc.PushError(err.Error())
+			return
+		}
+		header, err := resentHeader(config.From, rcpts, time.Now())
+		if err != nil {
+			aerc.PushError(err.Error())
+			return
+		}
+		aerc.PushStatus("Bouncing...", 10*time.Second)
+		mode.NoQuit()
+		go func() {
+			defer log.PanicHandler()
+			defer mode.NoQuitDone()
+
+			err := bounce(config, rcpts, header, raw)
+			if err != nil {
+				aerc.PushError(strings.ReplaceAll(err.Error(), "\n", " "))
+				return
+			}
+			aerc.PushStatus(fmt.Sprintf("Message bounced to %s",
+				strings.Join(args[1:], ", ")), 10*time.Second)
+		}()
+	})
+	return nil
+}
+
+// resentHeader returns the Resent-* fields (RFC 5322, section 3.6.6) to
+// prepend to a bounced message.
+func resentHeader(from *mail.Address, rcpts []*mail.Address,
+	date time.Time,
+) (*mail.Header, error) {
+	var h mail.Header
+	if err := h.GenerateMessageID(); err != nil {
+		return nil, err
+	}
+	msgID := h.Get("Message-Id")
+	h.Del("Message-Id")
+	// fields are written in reverse order of insertion
+	h.Set("Resent-Message-Id", msgID)
+	h.Set("Resent-Date", date.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	h.SetAddressList("Resent-To", rcpts)
+	h.SetAddressList("Resent-From", []*mail.Address{from})
+	return &h, nil
+}
+
+// bounce submits the original message unchanged except for the given
+// header fields which are prepended to it.
+func bounce(config *config.AccountConfig, rcpts []*mail.Address,
+	header *mail.Header, raw []byte,
+) error {
+	var buf bytes.Buffer
+	if err := textproto.WriteHeader(&buf, header.Header.Header); err != nil {
+		return err
+	}
+	// strip the blank line separating the header from the body
+	resent := bytes.TrimSuffix(buf.Bytes(), []byte("\r\n"))
+	if !bytes.Contains(raw, []byte("\r\n")) {
+		resent = bytes.ReplaceAll(resent, []byte("\r\n"), []byte("\n"))
+	}
+
+	sender, err := compose.NewSender(config, rcpts)
+	if err != nil {
+		return err
+	}
+	if _, err := sender.Write(resent); err != nil {
+		sender.Close()
+		return err
+	}
+	if _, err := sender.Write(raw); err != nil {
+		sender.Close()
+		return err
+	}
+	return sender.Close()
+}
@@ -0,0 +1,45 @@
+package msg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-message/textproto"
+)
+
+func TestResentHeader(t *testing.T) {
+	from := &mail.Address{Name: "Jane", Address: "jane@example.com"}
+	rcpts := []*mail.Address{
+		{Address: "bob@example.com"},
+		{Address: "alice@example.com"},
+	}
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	h, err := resentHeader(from, rcpts, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := textproto.WriteHeader(&buf, h.Header.Header); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\r\n")
+	expected := []string{
+		"Resent-From: \"Jane\" <jane@example.com>",
+		"Resent-To: <bob@example.com>, <alice@example.com>",
+		"Resent-Date: Mon, 02 Jan 2023 03:04:05 +0000",
+	}
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 header lines, got %q", lines)
+	}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Errorf("expected %q, got %q", line, lines[i])
+		}
+	}
+	if !strings.HasPrefix(lines[3], "Resent-Message-Id: <") {
+		t.Errorf("unexpected message id: %q", lines[3])
+	}
+}
@@ -77,6 +77,10 @@ func (Delete) Execute(aerc *widgets.Aerc, args []string) error {
 						})
 				}
 			} else {
+				if tv, ok := h.msgProvider.(*widgets.ThreadViewer); ok &&
+					tv.Remove(uids) == 0 {
+					aerc.RemoveTab(tv)
+				}
 				if next == nil {
 					// We deleted the last message, select the new last message
 					// instead of the first message
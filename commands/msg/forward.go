@@ -9,9 +9,12 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"git.sr.ht/~rjarry/aerc/commands"
 	"git.sr.ht/~rjarry/aerc/config"
 	"git.sr.ht/~rjarry/aerc/lib"
 	"git.sr.ht/~rjarry/aerc/lib/format"
@@ -70,16 +73,8 @@ func (forward) Execute(aerc *widgets.Aerc, args []string) error {
 	if store == nil {
 		return errors.New("Cannot perform action. Messages still loading")
 	}
-	msg, err := widget.SelectedMessage()
-	if err != nil {
-		return err
-	}
-	log.Debugf("Forwarding email <%s>", msg.Envelope.MessageId)
 
 	h := &mail.Header{}
-	subject := "Fwd: " + msg.Envelope.Subject
-	h.SetSubject(subject)
-
 	var tolist []*mail.Address
 	to := strings.Join(args[optind:], ", ")
 	if strings.Contains(to, "@") {
@@ -92,6 +87,37 @@ func (forward) Execute(aerc *widgets.Aerc, args []string) error {
 		h.SetAddressList("to", tolist)
 	}
 
+	// with -A, forward the marked messages rather than the selected one
+	var msg *models.MessageInfo
+	if pm, ok := widget.(widgets.ProvidesMessages); ok && attachAll {
+		marked, err := pm.MarkedMessages()
+		if err != nil {
+			return err
+		}
+		if len(marked) > 1 {
+			return forwardDigest(aerc, acct, store, marked, template, h)
+		}
+		if len(marked) == 1 {
+			msgs, err := commands.MsgInfoFromUids(store, marked, func(s string) {
+				aerc.PushStatus(s, 10*time.Second)
+			})
+			if err != nil {
+				return err
+			}
+			msg = msgs[0]
+		}
+	}
+	if msg == nil {
+		msg, err = widget.SelectedMessage()
+		if err != nil {
+			return err
+		}
+	}
+	log.Debugf("Forwarding email <%s>", msg.Envelope.MessageId)
+
+	subject := "Fwd: " + msg.Envelope.Subject
+	h.SetSubject(subject)
+
 	original := models.OriginalMail{
 		From:          format.FormatAddresses(msg.Envelope.From),
 		Date:          msg.Envelope.Date,
@@ -210,3 +236,83 @@ func (forward) Execute(aerc *widgets.Aerc, args []string) error {
 	}
 	return nil
 }
+
+// forwardDigest opens a composer with all given messages attached as a
+// single multipart/digest part and a summary of them in the body.
+func forwardDigest(aerc *widgets.Aerc, acct *widgets.AccountView,
+	store *lib.MessageStore, uids []uint32, template string, h *mail.Header,
+) error {
+	msgs, err := commands.MsgInfoFromUids(store, uids, func(s string) {
+		aerc.PushStatus(s, 10*time.Second)
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].Envelope.Date.Before(msgs[j].Envelope.Date)
+	})
+
+	if template == "" {
+		template = config.Templates.NewMessage
+	}
+
+	contents := make(map[uint32][]byte)
+	store.FetchFullDone(commands.UidsFromMessageInfos(msgs),
+		func(fm *types.FullMessage) {
+			buf, err := io.ReadAll(fm.Content.Reader)
+			if err != nil {
+				log.Errorf("failed to read message %d: %v", fm.Content.Uid, err)
+				return
+			}
+			contents[fm.Content.Uid] = buf
+		},
+		func(_ []uint32) {
+			var fetched []*models.MessageInfo
+			digest := make([][]byte, 0, len(msgs))
+			for _, msg := range msgs {
+				if buf, ok := contents[msg.Uid]; ok {
+					fetched = append(fetched, msg)
+					digest = append(digest, buf)
+				}
+			}
+			if len(fetched) == 0 {
+				aerc.PushError("Failed to fetch the messages to forward")
+				return
+			}
+			if len(fetched) < len(msgs) {
+				aerc.PushWarning(fmt.Sprintf(
+					"Failed to fetch %d of %d messages, forwarding the others",
+					len(msgs)-len(fetched), len(msgs)))
+			}
+
+			subject := fmt.Sprintf("Fwd: %s (%d messages)",
+				fetched[0].Envelope.Subject, len(fetched))
+			h.SetSubject(subject)
+
+			var summary strings.Builder
+			fmt.Fprintf(&summary, "Forwarded %d messages:\n\n", len(fetched))
+			for i, msg := range fetched {
+				fmt.Fprintf(&summary, "%3d. %s  %s\n     %s\n", i+1,
+					msg.Envelope.Date.Format("Mon Jan 2, 2006 at 3:04 PM"),
+					format.FormatAddresses(msg.Envelope.From),
+					msg.Envelope.Subject)
+			}
+			summary.WriteString("\n")
+
+			composer, err := widgets.NewComposer(aerc, acct,
+				acct.AccountConfig(), acct.Worker(), template, h, nil)
+			if err != nil {
+				aerc.PushError("Error: " + err.Error())
+				return
+			}
+			composer.PrependContents(strings.NewReader(summary.String()))
+			composer.AddDigestAttachment("forwarded-messages", digest)
+			composer.Tab = aerc.NewTab(composer, subject)
+			if !h.Has("to") {
+				composer.FocusEditor("to")
+			} else {
+				composer.FocusTerminal()
+			}
+		})
+	return nil
+}
@@ -0,0 +1,143 @@
+package msg
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message/mail"
+
+	"git.sr.ht/~rjarry/aerc/lib"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+// ListAction opens one of the URLs found in the List-* headers of a
+// message. See RFC 2369.
+type ListAction struct{}
+
+func init() {
+	register(ListAction{})
+}
+
+func (ListAction) Aliases() []string {
+	return []string{"list-help", "list-archive", "list-subscribe"}
+}
+
+func (ListAction) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (ListAction) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: %s", args[0])
+	}
+	widget := aerc.SelectedTabContent().(widgets.ProvidesMessage)
+	msg, err := widget.SelectedMessage()
+	if err != nil {
+		return err
+	}
+	key := strings.TrimPrefix(args[0], "list-")
+	header := "List-" + strings.ToUpper(key[:1]) + key[1:]
+	methods, err := listHeaderURLs(msg.RFC822Headers, header)
+	if err != nil {
+		return err
+	}
+
+	open := func(u *url.URL) {
+		var err error
+		switch strings.ToLower(u.Scheme) {
+		case "mailto":
+			err = composeMailto(aerc, u, args[0])
+		default:
+			err = openListURL(aerc, u)
+		}
+		if err != nil {
+			aerc.PushError(err.Error())
+		}
+	}
+	if len(methods) == 1 {
+		open(methods[0])
+		return nil
+	}
+
+	options := make([]string, len(methods))
+	for i, method := range methods {
+		options[i] = method.String()
+	}
+	dialog := widgets.NewSelectorDialog(
+		fmt.Sprintf("Select %s method", args[0]),
+		"Press <Enter> to confirm or <ESC> to cancel",
+		options, 0, aerc.SelectedAccountUiConfig(),
+		func(option string, err error) {
+			aerc.CloseDialog()
+			if err != nil {
+				if errors.Is(err, widgets.ErrNoOptionSelected) {
+					aerc.PushStatus(args[0]+": "+err.Error(),
+						5*time.Second)
+				} else {
+					aerc.PushError(args[0] + ": " + err.Error())
+				}
+				return
+			}
+			for _, m := range methods {
+				if m.String() == option {
+					open(m)
+					return
+				}
+			}
+		},
+	)
+	aerc.AddDialog(dialog)
+	return nil
+}
+
+// listHeaderURLs returns the URLs found in a List-* header.
+func listHeaderURLs(headers *mail.Header, key string) ([]*url.URL, error) {
+	if headers == nil || !headers.Has(key) {
+		return nil, fmt.Errorf("No %s header found", key)
+	}
+	text, err := headers.Text(key)
+	if err != nil {
+		return nil, err
+	}
+	methods := parseUnsubscribeMethods(text)
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("No URL found in %s header", key)
+	}
+	return methods, nil
+}
+
+// listPostAddresses returns the addresses of the List-Post header. It
+// returns nil if the header is missing or if posting is not allowed.
+func listPostAddresses(headers *mail.Header) []*mail.Address {
+	methods, err := listHeaderURLs(headers, "List-Post")
+	if err != nil {
+		return nil
+	}
+	var addrs []*mail.Address
+	for _, u := range methods {
+		if strings.ToLower(u.Scheme) != "mailto" {
+			continue
+		}
+		list, err := mail.ParseAddressList(u.Opaque)
+		if err != nil {
+			log.Warnf("invalid List-Post address %q: %v", u.Opaque, err)
+			continue
+		}
+		addrs = append(addrs, list...)
+	}
+	return addrs
+}
+
+func openListURL(aerc *widgets.Aerc, u *url.URL) error {
+	go func() {
+		defer log.PanicHandler()
+		if err := lib.XDGOpen(u.String()); err != nil {
+			aerc.PushError(err.Error())
+		}
+	}()
+	return nil
+}
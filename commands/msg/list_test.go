@@ -0,0 +1,51 @@
+package msg
+
+import (
+	"testing"
+
+	"github.com/emersion/go-message/mail"
+)
+
+func TestListPostAddresses(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected []string
+	}{
+		{"<mailto:list@example.com>", []string{"list@example.com"}},
+		{"<mailto:list@example.com?subject=hi>, <https://example.com/post>",
+			[]string{"list@example.com"}},
+		{"NO (posting not allowed)", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		var h mail.Header
+		if test.header != "" {
+			h.Set("List-Post", test.header)
+		}
+		addrs := listPostAddresses(&h)
+		if len(addrs) != len(test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.header, test.expected, addrs)
+			continue
+		}
+		for i, addr := range addrs {
+			if addr.Address != test.expected[i] {
+				t.Errorf("%q: expected %s, got %s", test.header,
+					test.expected[i], addr.Address)
+			}
+		}
+	}
+}
+
+func TestIsSubset(t *testing.T) {
+	list := []*mail.Address{{Address: "list@example.com"}}
+	author := []*mail.Address{{Address: "author@example.com"}}
+	if !isSubset(list, list) {
+		t.Error("list should be a subset of itself")
+	}
+	if isSubset(author, list) {
+		t.Error("author should not be a subset of list")
+	}
+	if isSubset(nil, list) {
+		t.Error("empty set should not be a subset")
+	}
+}
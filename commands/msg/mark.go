@@ -23,17 +23,6 @@ func (Mark) Complete(aerc *widgets.Aerc, args []string) []string {
 
 func (Mark) Execute(aerc *widgets.Aerc, args []string) error {
 	h := newHelper(aerc)
-	OnSelectedMessage := func(fn func(uint32)) error {
-		if fn == nil {
-			return fmt.Errorf("no operation selected")
-		}
-		selected, err := h.msgProvider.SelectedMessage()
-		if err != nil {
-			return err
-		}
-		fn(selected.Uid)
-		return nil
-	}
 	store, err := h.store()
 	if err != nil {
 		return err
@@ -48,6 +37,27 @@ func (Mark) Execute(aerc *widgets.Aerc, args []string) error {
 	var visual bool
 	var clearVisual bool
 	var thread bool
+	OnSelectedMessage := func(fn func(uint32)) error {
+		if fn == nil {
+			return fmt.Errorf("no operation selected")
+		}
+		selected, err := h.msgProvider.SelectedMessage()
+		if err != nil {
+			return err
+		}
+		uids := store.ExpandFolded([]uint32{selected.Uid})
+		if len(uids) > 1 && toggle && args[0] == "mark" {
+			// a folded thread is marked or unmarked as a whole
+			fn = marker.Mark
+			if marker.IsMarked(selected.Uid) {
+				fn = marker.Unmark
+			}
+		}
+		for _, uid := range uids {
+			fn(uid)
+		}
+		return nil
+	}
 	for _, opt := range opts {
 		switch opt.Option {
 		case 'a':
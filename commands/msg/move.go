@@ -71,7 +71,8 @@ func (Move) Execute(aerc *widgets.Aerc, args []string) error {
 	) {
 		switch msg := msg.(type) {
 		case *types.Done:
-			handleDone(aerc, acct, next, "Messages moved to "+joinedArgs, store)
+			handleDone(aerc, acct, uids, next,
+				"Messages moved to "+joinedArgs, store)
 		case *types.Error:
 			aerc.PushError(msg.Error.Error())
 			marker.Remark()
@@ -84,6 +85,7 @@ func (Move) Execute(aerc *widgets.Aerc, args []string) error {
 func handleDone(
 	aerc *widgets.Aerc,
 	acct *widgets.AccountView,
+	uids []uint32,
 	next *models.MessageInfo,
 	message string,
 	store *lib.MessageStore,
@@ -112,6 +114,10 @@ func handleDone(
 				aerc.ReplaceTab(mv, nextMv, next.Envelope.Subject)
 			})
 	default:
+		if tv, ok := h.msgProvider.(*widgets.ThreadViewer); ok &&
+			tv.Remove(uids) == 0 {
+			aerc.RemoveTab(tv)
+		}
 		if next == nil {
 			// We moved the last message, select the new last message
 			// instead of the first message
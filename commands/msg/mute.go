@@ -0,0 +1,61 @@
+package msg
+
+import (
+	"fmt"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type Mute struct{}
+
+func init() {
+	register(Mute{})
+}
+
+func (Mute) Aliases() []string {
+	return []string{"mute-thread", "unmute-thread"}
+}
+
+func (Mute) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (Mute) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: %s", args[0])
+	}
+	h := newHelper(aerc)
+	acct, err := h.account()
+	if err != nil {
+		return err
+	}
+	store, err := h.store()
+	if err != nil {
+		return err
+	}
+	msg, err := h.msgProvider.SelectedMessage()
+	if err != nil {
+		return err
+	}
+	uids := []uint32{msg.Uid}
+	if thread := store.SelectedThread(); thread != nil &&
+		store.SelectedUid() == msg.Uid {
+		uids = thread.Root().Uids()
+	}
+	if args[0] == "mute-thread" {
+		err = store.Mute(uids)
+	} else {
+		err = store.Unmute(uids)
+	}
+	if err != nil {
+		return err
+	}
+	acct.SaveMuted()
+	if args[0] == "mute-thread" {
+		aerc.PushStatus("Thread muted", 10*time.Second)
+	} else {
+		aerc.PushStatus("Thread unmuted", 10*time.Second)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package msg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/widgets"
+	"github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+)
+
+// patchSend runs git format-patch in the current directory and opens one
+// composer per patch. The cover letter (or the first patch if there is none)
+// comes first and all other patches reply to it.
+func patchSend(aerc *widgets.Aerc, args []string) error {
+	if len(args) < 2 {
+		return errors.New(
+			"Usage: patch send <revision-range> [<format-patch options>...]")
+	}
+	acct, err := newHelper(aerc).account()
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "aerc-patch-send")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files, err := formatPatch(tmpDir, args[1:])
+	if err != nil {
+		return err
+	}
+	patches := make([]*patchMessage, 0, len(files))
+	for _, f := range files {
+		p, err := readPatchFile(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		patches = append(patches, p)
+	}
+	// a cover letter is pointless for a single patch
+	if len(patches) == 2 && patches[0].Cover {
+		patches = patches[1:]
+	}
+	if err := threadPatches(patches, acct.AccountConfig().From); err != nil {
+		return err
+	}
+
+	for _, p := range patches {
+		composer, err := widgets.NewComposer(aerc, acct,
+			acct.AccountConfig(), acct.Worker(), "", p.Header, nil)
+		if err != nil {
+			return err
+		}
+		composer.SetContents(bytes.NewReader(p.Body))
+		subject, _ := p.Header.Subject()
+		composer.Tab = aerc.NewTab(composer, subject)
+		if !p.Header.Has("to") {
+			composer.FocusEditor("to")
+		} else {
+			composer.FocusTerminal()
+		}
+	}
+	// bring the cover letter to the front
+	aerc.SelectTabIndex(len(aerc.TabNames()) - len(patches))
+	return nil
+}
+
+// formatPatch runs git format-patch with a cover letter in the current
+// directory and returns the generated files, in order.
+func formatPatch(dir string, args []string) ([]string, error) {
+	gitArgs := []string{"format-patch", "--cover-letter", "--no-thread",
+		"-o", dir}
+	cmd := exec.Command("git", append(gitArgs, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git format-patch: %s", msg)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.patch"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("git format-patch: no patches generated")
+	}
+	// files are prefixed with their index in the series
+	sort.Strings(files)
+	return files, nil
+}
+
+type patchMessage struct {
+	Header *mail.Header
+	Body   []byte
+	Cover  bool
+}
+
+func readPatchFile(path string) (*patchMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readPatch(f)
+}
+
+// readPatch parses a single message generated by git format-patch, skipping
+// the mbox "From <sha1>" separator line.
+func readPatch(r io.Reader) (*patchMessage, error) {
+	br := bufio.NewReader(r)
+	if line, err := br.Peek(5); err == nil && string(line) == "From " {
+		if _, err := br.ReadString('\n'); err != nil {
+			return nil, err
+		}
+	}
+	e, err := message.Read(br)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(e.Body)
+	if err != nil {
+		return nil, err
+	}
+	h := &mail.Header{Header: e.Header}
+	subject, err := h.Subject()
+	if err != nil {
+		return nil, err
+	}
+	info, _ := parsePatchSubject(subject)
+	return &patchMessage{
+		Header: h,
+		Body:   body,
+		Cover:  info.Index == 0,
+	}, nil
+}
+
+// threadPatches keeps only the headers relevant to sending, assigns Message-Ids
+// and makes every patch reply to the first message. When a patch author is
+// not the sender, a From: line is prepended to the body so that git am
+// preserves the authorship.
+func threadPatches(patches []*patchMessage, sender *mail.Address) error {
+	var root string
+	for i, p := range patches {
+		h := &mail.Header{}
+		if subject, err := p.Header.Subject(); err == nil {
+			h.SetSubject(subject)
+		}
+		for _, key := range []string{"to", "cc"} {
+			if addrs, err := p.Header.AddressList(key); err == nil && len(addrs) > 0 {
+				h.SetAddressList(key, addrs)
+			}
+		}
+		if !p.Cover {
+			author, err := p.Header.AddressList("from")
+			if err == nil && len(author) == 1 && sender != nil &&
+				!strings.EqualFold(author[0].Address, sender.Address) {
+				p.Body = append([]byte(fmt.Sprintf("From: %s\n\n",
+					author[0].String())), p.Body...)
+			}
+		}
+		if err := h.GenerateMessageID(); err != nil {
+			return err
+		}
+		if i == 0 {
+			id, err := h.MessageID()
+			if err != nil {
+				return err
+			}
+			root = id
+		} else {
+			h.SetMsgIDList("in-reply-to", []string{root})
+			h.SetMsgIDList("references", []string{root})
+		}
+		log.Tracef("patch %d: %s", i, h.Get("message-id"))
+		p.Header = h
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package msg
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/commands"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/models"
+	"git.sr.ht/~rjarry/aerc/widgets"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+	"github.com/mitchellh/go-homedir"
+
+	"git.sr.ht/~sircmpwn/getopt"
+)
+
+type Patch struct{}
+
+func init() {
+	register(Patch{})
+}
+
+func (Patch) Aliases() []string {
+	return []string{"patch"}
+}
+
+func (Patch) Complete(aerc *widgets.Aerc, args []string) []string {
+	if len(args) <= 1 {
+		return commands.CompletionFromList(aerc,
+			[]string{"apply", "send"}, args)
+	}
+	switch args[0] {
+	case "apply":
+		var dirs []string
+		for _, c := range commands.CompletePath(strings.Join(args[1:], " ")) {
+			if strings.HasSuffix(c, "/") {
+				dirs = append(dirs, "apply "+c)
+			}
+		}
+		return dirs
+	}
+	return nil
+}
+
+func (Patch) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) < 2 {
+		return errors.New("Usage: patch apply|send [args...]")
+	}
+	switch args[1] {
+	case "apply":
+		return patchApply(aerc, args[1:])
+	case "send":
+		return patchSend(aerc, args[1:])
+	default:
+		return fmt.Errorf("unknown patch sub-command: %s", args[1])
+	}
+}
+
+func patchApply(aerc *widgets.Aerc, args []string) error {
+	opts, optind, err := getopt.Getopts(args, "v:")
+	if err != nil {
+		return err
+	}
+	version := 0
+	for _, opt := range opts {
+		if opt.Option == 'v' {
+			version, err = strconv.Atoi(strings.TrimPrefix(opt.Value, "v"))
+			if err != nil || version < 1 {
+				return fmt.Errorf("invalid version: %s", opt.Value)
+			}
+		}
+	}
+	if len(args[optind:]) != 1 {
+		return errors.New("Usage: patch apply [-v <version>] <repo>")
+	}
+	repo, err := homedir.Expand(args[optind])
+	if err != nil {
+		return err
+	}
+
+	h := newHelper(aerc)
+	store, err := h.store()
+	if err != nil {
+		return err
+	}
+	var uids []uint32
+	if thread := store.SelectedThread(); thread != nil {
+		uids = thread.Root().Uids()
+	} else {
+		// without threading, rely on the marked messages
+		uids, err = h.markedOrSelectedUids()
+		if err != nil {
+			return err
+		}
+	}
+	infos, err := commands.MsgInfoFromUids(store, uids, h.statusInfo)
+	if err != nil {
+		return err
+	}
+
+	series, err := findPatchSeries(infos, version)
+	if err != nil {
+		return err
+	}
+	if len(series.Superseded) > 0 {
+		aerc.PushWarning(fmt.Sprintf(
+			"Applying v%d, ignoring superseded %s", series.Version,
+			formatVersions(series.Superseded)))
+	}
+
+	title := fmt.Sprintf("git am <%s", repo)
+	var messages []*types.FullMessage
+	store.FetchFullDone(series.Uids, func(fm *types.FullMessage) {
+		messages = append(messages, fm)
+	}, func(missing []uint32) {
+		if len(missing) > 0 {
+			failed := make(map[uint32]bool, len(missing))
+			for _, uid := range missing {
+				failed[uid] = true
+			}
+			var names []string
+			for i, uid := range series.Uids {
+				if failed[uid] {
+					names = append(names, fmt.Sprintf("%d/%d",
+						i+1, len(series.Uids)))
+				}
+			}
+			aerc.PushError("Failed to fetch patches " +
+				strings.Join(names, ", "))
+			return
+		}
+		order := make(map[uint32]int, len(series.Uids))
+		for i, uid := range series.Uids {
+			order[uid] = i
+		}
+		sort.Slice(messages, func(i, j int) bool {
+			return order[messages[i].Content.Uid] <
+				order[messages[j].Content.Uid]
+		})
+		log.Debugf("applying %d patches to %s", len(messages), repo)
+		reader := newMessagesReader(messages, true)
+		term, err := commands.QuickTerm(aerc,
+			[]string{"git", "-C", repo, "am", "-3"}, reader)
+		if err != nil {
+			aerc.PushError(err.Error())
+			return
+		}
+		aerc.NewTab(term, title)
+	})
+	aerc.PushStatus(fmt.Sprintf("Fetching %d patches", len(series.Uids)),
+		10*time.Second)
+	return nil
+}
+
+var patchSubjectRe = regexp.MustCompile(`\[([^\]]*\bPATCH\b[^\]]*)\]`)
+
+// patchInfo describes the position of a message in a patch series, as
+// encoded by git format-patch in its subject prefix.
+type patchInfo struct {
+	Version int
+	Index   int
+	Total   int
+}
+
+// parsePatchSubject extracts the version and index of a patch from a subject
+// like "Re: [PATCH project v2 3/5] foo". A single patch without index is
+// reported as 1/1.
+func parsePatchSubject(subject string) (patchInfo, bool) {
+	m := patchSubjectRe.FindStringSubmatch(subject)
+	if m == nil {
+		return patchInfo{}, false
+	}
+	p := patchInfo{Version: 1, Index: 1, Total: 1}
+	for _, field := range strings.Fields(m[1]) {
+		switch {
+		case len(field) > 1 && (field[0] == 'v' || field[0] == 'V'):
+			if v, err := strconv.Atoi(field[1:]); err == nil {
+				p.Version = v
+			}
+		case strings.Contains(field, "/"):
+			parts := strings.SplitN(field, "/", 2)
+			i, err1 := strconv.Atoi(parts[0])
+			n, err2 := strconv.Atoi(parts[1])
+			if err1 == nil && err2 == nil && n > 0 && i <= n {
+				p.Index, p.Total = i, n
+			}
+		}
+	}
+	return p, true
+}
+
+// patchSeries is a complete and ordered set of patches.
+type patchSeries struct {
+	Version    int
+	Uids       []uint32
+	Superseded []int
+}
+
+// findPatchSeries selects the patches of the given version (or the latest one
+// when version is 0) among the messages of a thread. Replies and cover
+// letters are skipped. When several messages carry the same index, the
+// earliest one wins. An error is returned if any patch of the series is
+// missing.
+func findPatchSeries(infos []*models.MessageInfo, version int) (*patchSeries, error) {
+	type patch struct {
+		patchInfo
+		info *models.MessageInfo
+	}
+	versions := make(map[int][]patch)
+	for _, info := range infos {
+		if info == nil || info.Envelope == nil {
+			continue
+		}
+		subject := strings.TrimSpace(info.Envelope.Subject)
+		if strings.HasPrefix(strings.ToLower(subject), "re:") {
+			continue
+		}
+		p, ok := parsePatchSubject(subject)
+		if !ok || p.Index == 0 {
+			continue
+		}
+		versions[p.Version] = append(versions[p.Version], patch{p, info})
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("no patches found in thread")
+	}
+
+	var all []int
+	for v := range versions {
+		all = append(all, v)
+	}
+	sort.Ints(all)
+	if version == 0 {
+		version = all[len(all)-1]
+	}
+	patches, ok := versions[version]
+	if !ok {
+		return nil, fmt.Errorf("no patches found for v%d, available %s",
+			version, formatVersions(all))
+	}
+
+	sort.SliceStable(patches, func(i, j int) bool {
+		if patches[i].Index != patches[j].Index {
+			return patches[i].Index < patches[j].Index
+		}
+		return patches[i].info.Envelope.Date.Before(
+			patches[j].info.Envelope.Date)
+	})
+	total := 0
+	for _, p := range patches {
+		if p.Total > total {
+			total = p.Total
+		}
+	}
+	series := &patchSeries{Version: version}
+	found := make(map[int]bool)
+	for _, p := range patches {
+		if found[p.Index] {
+			continue
+		}
+		found[p.Index] = true
+		series.Uids = append(series.Uids, p.info.Uid)
+	}
+	var missing []string
+	for i := 1; i <= total; i++ {
+		if !found[i] {
+			missing = append(missing, fmt.Sprintf("%d/%d", i, total))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("v%d is incomplete, missing %s",
+			version, strings.Join(missing, ", "))
+	}
+	for _, v := range all {
+		if v < version {
+			series.Superseded = append(series.Superseded, v)
+		}
+	}
+	return series, nil
+}
+
+func formatVersions(versions []int) string {
+	s := make([]string, len(versions))
+	for i, v := range versions {
+		s[i] = fmt.Sprintf("v%d", v)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,136 @@
+package msg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/models"
+	"github.com/emersion/go-message/mail"
+)
+
+func TestParsePatchSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		ok      bool
+		info    patchInfo
+	}{
+		{"[PATCH] fix typo", true, patchInfo{1, 1, 1}},
+		{"[PATCH 2/3] foo", true, patchInfo{1, 2, 3}},
+		{"[PATCH aerc v3 0/4] cover", true, patchInfo{3, 0, 4}},
+		{"[RFC PATCH v2 1/2] bar", true, patchInfo{2, 1, 2}},
+		{"Re: [PATCH v2] baz", true, patchInfo{2, 1, 1}},
+		{"[aerc-devel] no patch here", false, patchInfo{}},
+	}
+	for _, test := range tests {
+		info, ok := parsePatchSubject(test.subject)
+		if ok != test.ok || info != test.info {
+			t.Errorf("%q: expected %v %v, got %v %v", test.subject,
+				test.info, test.ok, info, ok)
+		}
+	}
+}
+
+func TestFindPatchSeries(t *testing.T) {
+	now := time.Now()
+	msg := func(uid uint32, subject string) *models.MessageInfo {
+		return &models.MessageInfo{
+			Uid: uid,
+			Envelope: &models.Envelope{
+				Subject: subject,
+				Date:    now.Add(time.Duration(uid) * time.Minute),
+			},
+		}
+	}
+	infos := []*models.MessageInfo{
+		msg(1, "[PATCH 0/2] cover"),
+		msg(2, "[PATCH 2/2] second"),
+		msg(3, "[PATCH 1/2] first"),
+		msg(4, "Re: [PATCH 1/2] first"),
+		msg(5, "[PATCH v2 2/3] second"),
+		msg(6, "[PATCH v2 1/3] first"),
+	}
+
+	series, err := findPatchSeries(infos, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.Uids) != 2 || series.Uids[0] != 3 || series.Uids[1] != 2 {
+		t.Errorf("unexpected order: %v", series.Uids)
+	}
+
+	if _, err := findPatchSeries(infos, 0); err == nil {
+		t.Errorf("incomplete v2 should fail")
+	}
+
+	infos = append(infos, msg(7, "[PATCH v2 3/3] third"))
+	series, err = findPatchSeries(infos, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.Version != 2 || len(series.Uids) != 3 ||
+		series.Uids[0] != 6 || series.Uids[2] != 7 {
+		t.Errorf("unexpected series: %#v", series)
+	}
+	if len(series.Superseded) != 1 || series.Superseded[0] != 1 {
+		t.Errorf("unexpected superseded versions: %v", series.Superseded)
+	}
+
+	if _, err := findPatchSeries(infos, 5); err == nil {
+		t.Errorf("unknown version should fail")
+	}
+}
+
+func TestThreadPatches(t *testing.T) {
+	raw := []string{
+		"From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001\n" +
+			"From: Alice <alice@example.com>\n" +
+			"Date: Mon, 2 Jan 2023 10:00:00 +0100\n" +
+			"Subject: [PATCH 0/1] *** SUBJECT HERE ***\n" +
+			"To: list@example.com\n\n*** BLURB HERE ***\n",
+		"From 1234567890abcdef1234567890abcdef12345678 Mon Sep 17 00:00:00 2001\n" +
+			"From: Bob <bob@example.com>\n" +
+			"Date: Mon, 2 Jan 2023 09:00:00 +0100\n" +
+			"Subject: [PATCH 1/1] fix things\n" +
+			"To: list@example.com\n\n---\n foo | 1 +\n",
+	}
+	var patches []*patchMessage
+	for _, r := range raw {
+		p, err := readPatch(strings.NewReader(r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		patches = append(patches, p)
+	}
+	if !patches[0].Cover || patches[1].Cover {
+		t.Fatalf("cover letter not detected")
+	}
+	err := threadPatches(patches,
+		&mail.Address{Name: "Alice", Address: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := patches[0].Header.MessageID()
+	if err != nil || root == "" {
+		t.Fatalf("no message-id on cover letter: %v", err)
+	}
+	if patches[0].Header.Has("in-reply-to") {
+		t.Errorf("cover letter should not reply to anything")
+	}
+	for _, key := range []string{"in-reply-to", "references"} {
+		ids, err := patches[1].Header.MsgIDList(key)
+		if err != nil || len(ids) != 1 || ids[0] != root {
+			t.Errorf("%s: expected %s, got %v", key, root, ids)
+		}
+	}
+	if patches[1].Header.Has("date") || patches[1].Header.Has("from") {
+		t.Errorf("date and from should be left to the composer")
+	}
+	if !strings.HasPrefix(string(patches[1].Body),
+		"From: \"Bob\" <bob@example.com>\n\n---") {
+		t.Errorf("author not preserved in body: %q", patches[1].Body)
+	}
+	if to, _ := patches[1].Header.AddressList("to"); len(to) != 1 {
+		t.Errorf("recipients not kept: %v", to)
+	}
+}
@@ -37,16 +37,17 @@ func (reply) Complete(aerc *widgets.Aerc, args []string) []string {
 }
 
 func (reply) Execute(aerc *widgets.Aerc, args []string) error {
-	opts, optind, err := getopt.Getopts(args, "acqT:")
+	opts, optind, err := getopt.Getopts(args, "aclqT:")
 	if err != nil {
 		return err
 	}
 	if optind != len(args) {
-		return errors.New("Usage: reply [-acq -T <template>]")
+		return errors.New("Usage: reply [-aclq -T <template>]")
 	}
 	var (
 		quote        bool
 		replyAll     bool
+		replyList    bool
 		closeOnReply bool
 		template     string
 	)
@@ -56,6 +57,8 @@ func (reply) Execute(aerc *widgets.Aerc, args []string) error {
 			replyAll = true
 		case 'c':
 			closeOnReply = true
+		case 'l':
+			replyList = true
 		case 'q':
 			quote = true
 		case 'T':
@@ -106,9 +109,22 @@ func (reply) Execute(aerc *widgets.Aerc, args []string) error {
 
 	recSet := newAddrSet() // used for de-duping
 
-	if len(msg.Envelope.ReplyTo) != 0 {
+	// List-Post addresses, if this message comes from a mailing list
+	listPost := listPostAddresses(msg.RFC822Headers)
+	if replyList && len(listPost) == 0 {
+		return errors.New("No List-Post address found")
+	}
+
+	switch {
+	case replyList:
+		to = listPost
+	case replyAll && len(listPost) > 0 && isSubset(msg.Envelope.ReplyTo, listPost):
+		// the list munged Reply-To, reply to the author and keep the
+		// list in copy
+		to = msg.Envelope.From
+	case len(msg.Envelope.ReplyTo) != 0:
 		to = msg.Envelope.ReplyTo
-	} else {
+	default:
 		to = msg.Envelope.From
 	}
 
@@ -126,7 +142,7 @@ func (reply) Execute(aerc *widgets.Aerc, args []string) error {
 
 	recSet.AddList(to)
 
-	if replyAll {
+	if replyAll && !replyList {
 		// order matters, due to the deduping
 		// in order of importance, first parse the To, then the Cc header
 
@@ -151,6 +167,15 @@ func (reply) Execute(aerc *widgets.Aerc, args []string) error {
 			cc = append(cc, addr)
 		}
 		recSet.AddList(cc)
+
+		// make sure the list is in copy exactly once
+		for _, addr := range listPost {
+			if recSet.Contains(addr) {
+				continue
+			}
+			cc = append(cc, addr)
+			recSet.Add(addr)
+		}
 	}
 
 	subject := "Re: " + trimLocalizedRe(msg.Envelope.Subject, conf.LocalizedRe)
@@ -296,6 +321,22 @@ func (s addrSet) Contains(a *mail.Address) bool {
 	return ok
 }
 
+// isSubset returns true if all addresses of sub are in set. An empty sub is
+// never a subset.
+func isSubset(sub []*mail.Address, set []*mail.Address) bool {
+	if len(sub) == 0 {
+		return false
+	}
+	s := newAddrSet()
+	s.AddList(set)
+	for _, a := range sub {
+		if !s.Contains(a) {
+			return false
+		}
+	}
+	return true
+}
+
 // setReferencesHeader adds the references header to target based on parent
 // according to RFC2822
 func setReferencesHeader(target, parent *mail.Header) error {
@@ -0,0 +1,40 @@
+package msg
+
+import (
+	"errors"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type ToggleMuted struct{}
+
+func init() {
+	register(ToggleMuted{})
+}
+
+func (ToggleMuted) Aliases() []string {
+	return []string{"toggle-muted"}
+}
+
+func (ToggleMuted) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (ToggleMuted) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: toggle-muted")
+	}
+	h := newHelper(aerc)
+	store, err := h.store()
+	if err != nil {
+		return err
+	}
+	store.SetShowMuted(!store.ShowMuted())
+	if store.ShowMuted() {
+		aerc.PushStatus("Showing muted threads", 10*time.Second)
+	} else {
+		aerc.PushStatus("Hiding muted threads", 10*time.Second)
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -56,13 +58,24 @@ func (Unsubscribe) Execute(aerc *widgets.Aerc, args []string) error {
 	}
 	log.Debugf("unsubscribe: found %d methods", len(methods))
 
+	// RFC 8058 one-click unsubscription is only allowed over HTTPS
+	oneClick := strings.EqualFold(
+		strings.TrimSpace(headers.Get("list-unsubscribe-post")),
+		"List-Unsubscribe=One-Click")
+
 	unsubscribe := func(method *url.URL) {
 		log.Debugf("unsubscribe: trying to unsubscribe using %s", method.Scheme)
 		var err error
 		switch strings.ToLower(method.Scheme) {
 		case "mailto":
 			err = unsubscribeMailto(aerc, method)
-		case "http", "https":
+		case "https":
+			if oneClick {
+				err = unsubscribeOneClick(aerc, method)
+			} else {
+				err = unsubscribeHTTP(aerc, method)
+			}
+		case "http":
 			err = unsubscribeHTTP(aerc, method)
 		default:
 			err = fmt.Errorf("unsubscribe: skipping unrecognized scheme: %s", method.Scheme)
@@ -134,6 +147,12 @@ func parseUnsubscribeMethods(header string) (methods []*url.URL) {
 }
 
 func unsubscribeMailto(aerc *widgets.Aerc, u *url.URL) error {
+	return composeMailto(aerc, u, "unsubscribe")
+}
+
+// composeMailto opens a composer with the recipients, subject and body of a
+// mailto: URL.
+func composeMailto(aerc *widgets.Aerc, u *url.URL, title string) error {
 	widget := aerc.SelectedTabContent().(widgets.ProvidesMessage)
 	acct := widget.SelectedAccount()
 	if acct == nil {
@@ -159,7 +178,7 @@ func unsubscribeMailto(aerc *widgets.Aerc, u *url.URL) error {
 		return err
 	}
 	composer.SetContents(strings.NewReader(u.Query().Get("body")))
-	composer.Tab = aerc.NewTab(composer, "unsubscribe")
+	composer.Tab = aerc.NewTab(composer, title)
 	composer.FocusTerminal()
 	return nil
 }
@@ -187,3 +206,49 @@ func unsubscribeHTTP(aerc *widgets.Aerc, u *url.URL) error {
 	aerc.AddDialog(confirm)
 	return nil
 }
+
+func unsubscribeOneClick(aerc *widgets.Aerc, u *url.URL) error {
+	confirm := widgets.NewSelectorDialog(
+		"Do you want to unsubscribe with a one-click request?",
+		u.String(),
+		[]string{"No", "Yes"}, 0, aerc.SelectedAccountUiConfig(),
+		func(option string, _ error) {
+			aerc.CloseDialog()
+			switch option {
+			case "Yes":
+				go func() {
+					defer log.PanicHandler()
+					client := &http.Client{Timeout: 30 * time.Second}
+					status, err := postOneClick(client, u)
+					if err != nil {
+						aerc.PushError("Unsubscribe: " + err.Error())
+						return
+					}
+					aerc.PushSuccess("Unsubscribe: " + status)
+				}()
+			default:
+				aerc.PushError("Unsubscribe: request will not be sent")
+			}
+		},
+	)
+	aerc.AddDialog(confirm)
+	return nil
+}
+
+// postOneClick sends a one-click unsubscription request as defined in RFC
+// 8058 and returns the HTTP status of the response.
+func postOneClick(client *http.Client, u *url.URL) (string, error) {
+	resp, err := client.Post(u.String(),
+		"application/x-www-form-urlencoded",
+		strings.NewReader("List-Unsubscribe=One-Click"))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	//nolint:errcheck // the body is irrelevant
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("request failed: %s", resp.Status)
+	}
+	return resp.Status, nil
+}
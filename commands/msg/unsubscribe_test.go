@@ -1,6 +1,10 @@
 package msg
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
@@ -41,3 +45,43 @@ func TestParseUnsubscribe(t *testing.T) {
 		}
 	}
 }
+
+func TestPostOneClick(t *testing.T) {
+	var body, contentType string
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			body = string(b)
+			contentType = r.Header.Get("Content-Type")
+			if r.URL.Query().Get("id") == "unknown" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/unsubscribe?id=42")
+	status, err := postOneClick(srv.Client(), u)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != "202 Accepted" {
+		t.Errorf("unexpected status: %s", status)
+	}
+	if body != "List-Unsubscribe=One-Click" {
+		t.Errorf("unexpected body: %q", body)
+	}
+	if contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("unexpected content type: %q", contentType)
+	}
+
+	u, _ = url.Parse(srv.URL + "/unsubscribe?id=unknown")
+	if _, err := postOneClick(srv.Client(), u); err == nil {
+		t.Error("expected an error for a 404 response")
+	}
+}
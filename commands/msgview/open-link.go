@@ -2,9 +2,13 @@ package msgview
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"git.sr.ht/~rjarry/aerc/commands"
 	"git.sr.ht/~rjarry/aerc/lib"
+	"git.sr.ht/~rjarry/aerc/lib/parse"
 	"git.sr.ht/~rjarry/aerc/log"
 	"git.sr.ht/~rjarry/aerc/widgets"
 )
@@ -23,21 +27,87 @@ func (OpenLink) Complete(aerc *widgets.Aerc, args []string) []string {
 	mv := aerc.SelectedTabContent().(*widgets.MessageViewer)
 	if mv != nil {
 		if p := mv.SelectedMessagePart(); p != nil {
-			return commands.CompletionFromList(aerc, p.Links, args)
+			// offer the links displayed on screen first
+			links := append([]string{}, p.VisibleLinks...)
+			for _, link := range p.Links {
+				if !contains(p.VisibleLinks, link) {
+					links = append(links, link)
+				}
+			}
+			return commands.CompletionFromList(aerc, links, args)
 		}
 	}
 	return nil
 }
 
 func (OpenLink) Execute(aerc *widgets.Aerc, args []string) error {
-	if len(args) != 2 {
-		return errors.New("Usage: open-link <url>")
+	if len(args) > 2 {
+		return errors.New("Usage: open-link [<url>|<number>]")
 	}
-	go func() {
-		defer log.PanicHandler()
-		if err := lib.XDGOpen(args[1]); err != nil {
-			aerc.PushError("open-link: " + err.Error())
+	var link string
+	if len(args) == 2 {
+		link = args[1]
+	}
+	var texts []string
+	if mv, ok := aerc.SelectedTabContent().(*widgets.MessageViewer); ok {
+		part := mv.SelectedMessagePart()
+		if link == "" {
+			if len(part.VisibleLinks) == 0 {
+				return errors.New("open-link: no link on screen")
+			}
+			link = part.VisibleLinks[0]
+		} else if n, err := strconv.Atoi(link); err == nil {
+			if n < 1 || n > len(part.Links) {
+				return fmt.Errorf("open-link: no link [%d]", n)
+			}
+			link = part.Links[n-1]
 		}
-	}()
+		texts = part.LinkTexts[link]
+	}
+	if link == "" {
+		return errors.New("Usage: open-link [<url>|<number>]")
+	}
+
+	open := func() {
+		go func() {
+			defer log.PanicHandler()
+			if err := lib.XDGOpen(link); err != nil {
+				aerc.PushError("open-link: " + err.Error())
+			}
+		}()
+	}
+
+	reasons := parse.CheckLink(link, texts...)
+	if len(reasons) == 0 {
+		open()
+		return nil
+	}
+	prompt := "Destination: " + link + "\n"
+	for _, reason := range reasons {
+		prompt += "\nWarning: " + reason
+	}
+	confirm := widgets.NewSelectorDialog(
+		"This link may be deceptive. Open it anyway?",
+		prompt, []string{"No", "Yes"}, 0, aerc.SelectedAccountUiConfig(),
+		func(option string, _ error) {
+			aerc.CloseDialog()
+			if option == "Yes" {
+				open()
+			} else {
+				aerc.PushStatus("open-link: link will not be opened",
+					10*time.Second)
+			}
+		},
+	)
+	aerc.AddDialog(confirm)
 	return nil
 }
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"errors"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type Reload struct{}
+
+func init() {
+	register(Reload{})
+}
+
+func (Reload) Aliases() []string {
+	return []string{"reload"}
+}
+
+func (Reload) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (Reload) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: reload")
+	}
+	if err := aerc.Reload(); err != nil {
+		return err
+	}
+	aerc.PushSuccess("Configuration reloaded")
+	return nil
+}
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+
+	"git.sr.ht/~rjarry/aerc/lib/sieve"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type Sieve struct{}
+
+func init() {
+	register(Sieve{})
+}
+
+func (Sieve) Aliases() []string {
+	return []string{"sieve"}
+}
+
+var sieveCommands = []string{
+	"list", "edit", "activate", "deactivate", "delete", "check",
+	"download", "upload",
+}
+
+func (Sieve) Complete(aerc *widgets.Aerc, args []string) []string {
+	if len(args) <= 1 {
+		return CompletionFromList(aerc, sieveCommands, args)
+	}
+	switch args[0] {
+	case "upload":
+		var files []string
+		for _, f := range CompletePath(strings.Join(args[1:], " ")) {
+			files = append(files, "upload "+f)
+		}
+		return files
+	case "edit", "activate", "delete", "check", "download":
+		view, ok := aerc.SelectedTabContent().(*widgets.SieveView)
+		if !ok {
+			return nil
+		}
+		var names []string
+		for _, name := range CompletionFromList(aerc, view.Scripts(), args[1:]) {
+			names = append(names, args[0]+" "+name)
+		}
+		return names
+	}
+	return nil
+}
+
+const sieveUsage = "Usage: sieve [list|edit|activate|delete|check|download] " +
+	"[<script>] | sieve deactivate | sieve upload <file> [<script>]"
+
+func (Sieve) Execute(aerc *widgets.Aerc, args []string) error {
+	acct := aerc.SelectedAccount()
+	if acct == nil {
+		return errors.New("No account selected")
+	}
+	cmd := "list"
+	if len(args) > 1 {
+		cmd = args[1]
+		args = args[2:]
+	} else {
+		args = nil
+	}
+	view, _ := aerc.SelectedTabContent().(*widgets.SieveView)
+	// the script defaults to the one selected in the sieve tab
+	script := func() (string, error) {
+		if len(args) > 0 {
+			return args[0], nil
+		}
+		if view != nil && view.Selected() != "" {
+			return view.Selected(), nil
+		}
+		return "", errors.New(sieveUsage)
+	}
+	done := func(status string) func() {
+		return func() {
+			aerc.PushStatus(status, 10*time.Second)
+			if view != nil {
+				view.Refresh()
+			}
+		}
+	}
+
+	switch cmd {
+	case "list":
+		if view != nil {
+			view.Refresh()
+			return nil
+		}
+		aerc.NewTab(widgets.NewSieveView(aerc, acct), "sieve: "+acct.Name())
+	case "edit":
+		name, err := script()
+		if err != nil {
+			return err
+		}
+		widgets.EditSieveScript(aerc, acct, name, func() {
+			if view != nil {
+				view.Refresh()
+			}
+		})
+	case "activate":
+		name, err := script()
+		if err != nil {
+			return err
+		}
+		widgets.RunSieve(acct, func(c *sieve.Client) error {
+			return c.SetActive(name)
+		}, done(name+" activated"))
+	case "deactivate":
+		widgets.RunSieve(acct, func(c *sieve.Client) error {
+			return c.SetActive("")
+		}, done("Sieve scripts deactivated"))
+	case "delete":
+		name, err := script()
+		if err != nil {
+			return err
+		}
+		widgets.RunSieve(acct, func(c *sieve.Client) error {
+			return c.DeleteScript(name)
+		}, done(name+" deleted"))
+	case "check":
+		name, err := script()
+		if err != nil {
+			return err
+		}
+		var warnings string
+		unsupported := false
+		widgets.RunSieve(acct, func(c *sieve.Client) error {
+			content, err := c.GetScript(name)
+			if err != nil {
+				return err
+			}
+			warnings, err = c.CheckScript(content)
+			if errors.Is(err, sieve.ErrCheckUnsupported) {
+				unsupported = true
+				return nil
+			}
+			return err
+		}, func() {
+			if unsupported {
+				aerc.PushWarning(name + ": validation is unavailable, " +
+					"the server does not support CHECKSCRIPT")
+			} else if warnings != "" {
+				aerc.PushWarning(fmt.Sprintf("%s: %s", name, warnings))
+			} else {
+				aerc.PushSuccess(name + " is valid")
+			}
+		})
+	case "download":
+		name, err := script()
+		if err != nil {
+			return err
+		}
+		path := name + ".sieve"
+		if len(args) > 1 {
+			path, err = homedir.Expand(args[1])
+			if err != nil {
+				return err
+			}
+		}
+		widgets.RunSieve(acct, func(c *sieve.Client) error {
+			content, err := c.GetScript(name)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, []byte(content), 0o600)
+		}, done(fmt.Sprintf("%s saved to %s", name, path)))
+	case "upload":
+		if len(args) == 0 || len(args) > 2 {
+			return errors.New("Usage: sieve upload <file> [<script>]")
+		}
+		path, err := homedir.Expand(args[0])
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".sieve")
+		if len(args) > 1 {
+			name = args[1]
+		}
+		widgets.RunSieve(acct, func(c *sieve.Client) error {
+			// PUTSCRIPT validates the script anyway
+			_, err := c.CheckScript(string(content))
+			if err != nil && !errors.Is(err, sieve.ErrCheckUnsupported) {
+				return err
+			}
+			return c.PutScript(name, string(content))
+		}, done(name+" uploaded"))
+	default:
+		return errors.New(sieveUsage)
+	}
+	return nil
+}
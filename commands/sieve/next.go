@@ -0,0 +1,45 @@
+package sieve
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+type NextPrevScript struct{}
+
+func init() {
+	register(NextPrevScript{})
+}
+
+func (NextPrevScript) Aliases() []string {
+	return []string{"next", "prev"}
+}
+
+func (NextPrevScript) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (NextPrevScript) Execute(aerc *widgets.Aerc, args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("Usage: %s [n]", args[0])
+	}
+	n := 1
+	if len(args) > 1 {
+		var err error
+		n, err = strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("Usage: %s [n]", args[0])
+		}
+	}
+	view, err := sieveView(aerc)
+	if err != nil {
+		return err
+	}
+	if args[0] == "prev" {
+		n = -n
+	}
+	view.Next(n)
+	return nil
+}
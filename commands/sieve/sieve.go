@@ -0,0 +1,25 @@
+package sieve
+
+import (
+	"errors"
+
+	"git.sr.ht/~rjarry/aerc/commands"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+var SieveCommands *commands.Commands
+
+func register(cmd commands.Command) {
+	if SieveCommands == nil {
+		SieveCommands = commands.NewCommands()
+	}
+	SieveCommands.Register(cmd)
+}
+
+func sieveView(aerc *widgets.Aerc) (*widgets.SieveView, error) {
+	view, ok := aerc.SelectedTabContent().(*widgets.SieveView)
+	if !ok {
+		return nil, errors.New("not in a sieve tab")
+	}
+	return view, nil
+}
@@ -0,0 +1,25 @@
+package threadview
+
+import (
+	"errors"
+
+	"git.sr.ht/~rjarry/aerc/commands"
+	"git.sr.ht/~rjarry/aerc/widgets"
+)
+
+var ThreadViewCommands *commands.Commands
+
+func register(cmd commands.Command) {
+	if ThreadViewCommands == nil {
+		ThreadViewCommands = commands.NewCommands()
+	}
+	ThreadViewCommands.Register(cmd)
+}
+
+func threadViewer(aerc *widgets.Aerc) (*widgets.ThreadViewer, error) {
+	view, ok := aerc.SelectedTabContent().(*widgets.ThreadViewer)
+	if !ok {
+		return nil, errors.New("not in a thread view")
+	}
+	return view, nil
+}
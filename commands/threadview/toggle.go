@@ -0,0 +1,48 @@
+package threadview
+
+import (
+	"fmt"
+
+	"git.sr.ht/~rjarry/aerc/widgets"
+	"git.sr.ht/~sircmpwn/getopt"
+)
+
+type Toggle struct{}
+
+func init() {
+	register(Toggle{})
+}
+
+func (Toggle) Aliases() []string {
+	return []string{"toggle-fold", "toggle-quotes"}
+}
+
+func (Toggle) Complete(aerc *widgets.Aerc, args []string) []string {
+	return nil
+}
+
+func (Toggle) Execute(aerc *widgets.Aerc, args []string) error {
+	opts, optind, err := getopt.Getopts(args, "a")
+	if err != nil {
+		return err
+	}
+	if len(args) != optind {
+		return fmt.Errorf("Usage: %s [-a]", args[0])
+	}
+	all := false
+	for _, opt := range opts {
+		if opt.Option == 'a' {
+			all = true
+		}
+	}
+	view, err := threadViewer(aerc)
+	if err != nil {
+		return err
+	}
+	if args[0] == "toggle-fold" {
+		view.ToggleFold(all)
+	} else {
+		view.ToggleQuotes(all)
+	}
+	return nil
+}
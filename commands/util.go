@@ -185,6 +185,10 @@ func MarkedOrSelected(pm widgets.ProvidesMessages) ([]uint32, error) {
 	if err != nil {
 		return nil, err
 	}
+	// a folded thread stands for all its messages
+	if store := pm.Store(); store != nil {
+		return store.ExpandFolded([]uint32{msg.Uid}), nil
+	}
 	return []uint32{msg.Uid}, nil
 }
 
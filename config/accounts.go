@@ -74,6 +74,7 @@ type AccountConfig struct {
 	Params map[string]string
 
 	Archive           string          `ini:"archive" default:"Archive"`
+	ArchiveMuted      bool            `ini:"archive-muted"`
 	CopyTo            string          `ini:"copy-to"`
 	Default           string          `ini:"default" default:"INBOX"`
 	Postpone          string          `ini:"postpone" default:"Drafts"`
@@ -83,6 +84,7 @@ type AccountConfig struct {
 	Folders           []string        `ini:"folders" delim:","`
 	FoldersExclude    []string        `ini:"folders-exclude" delim:","`
 	Outgoing          RemoteConfig    `ini:"outgoing" parse:"ParseOutgoing"`
+	Sieve             RemoteConfig    `ini:"sieve" parse:"ParseSieve"`
 	SignatureFile     string          `ini:"signature-file"`
 	SignatureCmd      string          `ini:"signature-cmd"`
 	EnableFoldersSort bool            `ini:"enable-folders-sort" default:"true"`
@@ -106,6 +108,10 @@ type AccountConfig struct {
 
 	// AuthRes
 	TrustedAuthRes []string `ini:"trusted-authres" delim:","`
+
+	// source and source-cred-cmd as written in accounts.conf
+	sourceValue   string
+	sourceCredCmd string
 }
 
 const (
@@ -191,6 +197,14 @@ func (a *AccountConfig) ParseSource(sec *ini.Section, key *ini.Key) (string, err
 	if k, err := sec.GetKey("source-cred-cmd"); err == nil {
 		remote.PasswordCmd = k.String()
 	}
+	a.sourceValue, a.sourceCredCmd = remote.Value, remote.PasswordCmd
+	// do not run source-cred-cmd again when reloading an unchanged source
+	for _, prev := range previousAccounts {
+		if prev.Name == a.Name && prev.sourceValue == a.sourceValue &&
+			prev.sourceCredCmd == a.sourceCredCmd {
+			return prev.Source, nil
+		}
+	}
 	return remote.ConnectionString()
 }
 
@@ -211,6 +225,89 @@ func (a *AccountConfig) ParseOutgoing(sec *ini.Section, key *ini.Key) (RemoteCon
 	return remote, err
 }
 
+func (a *AccountConfig) ParseSieve(sec *ini.Section, key *ini.Key) (RemoteConfig, error) {
+	var remote RemoteConfig
+	remote.Value = key.String()
+	if k, err := sec.GetKey("sieve-cred-cmd"); err == nil {
+		remote.PasswordCmd = k.String()
+		remote.CacheCmd = true
+	}
+	_, err := remote.parseValue()
+	return remote, err
+}
+
+// SieveURL returns the ManageSieve server URL of the account. Without a
+// sieve setting, it is derived from an imap source on the default port. A
+// sieve URL without user name borrows the credentials of the source, along
+// with its +oauthbearer or +xoauth2 authentication mechanism.
+func (a *AccountConfig) SieveURL() (*url.URL, error) {
+	value, err := a.Sieve.ConnectionString()
+	if err != nil {
+		return nil, err
+	}
+	source, err := url.Parse(a.Source)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(source.Scheme, "+")
+	imap := parts[0] == "imap" || parts[0] == "imaps"
+	insecure := false
+	mech := ""
+	for _, p := range parts[1:] {
+		switch p {
+		case "insecure":
+			insecure = true
+		case "oauthbearer", "xoauth2":
+			mech = p
+		default:
+			imap = false
+		}
+	}
+	if value == "" {
+		if !imap {
+			return nil, fmt.Errorf("%s: no sieve server configured", a.Name)
+		}
+		u := &url.URL{
+			Scheme: "sieve",
+			Host:   source.Hostname(),
+			User:   source.User,
+		}
+		if insecure {
+			u.Scheme += "+insecure"
+		}
+		if mech != "" {
+			u.Scheme += "+" + mech
+			u.RawQuery = source.RawQuery
+		}
+		return u, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil && imap {
+		u.User = source.User
+		if mech != "" && !sieveMech(u.Scheme) {
+			u.Scheme += "+" + mech
+			if u.RawQuery == "" {
+				u.RawQuery = source.RawQuery
+			}
+		}
+	}
+	return u, nil
+}
+
+// sieveMech reports whether a sieve URL scheme sets the authentication
+// mechanism.
+func sieveMech(scheme string) bool {
+	for _, p := range strings.Split(scheme, "+")[1:] {
+		if p != "insecure" {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *AccountConfig) ParsePgpErrorLevel(sec *ini.Section, key *ini.Key) (int, error) {
 	var level int
 	var err error
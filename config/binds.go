@@ -25,6 +25,9 @@ type BindingConfig struct {
 	MessageView            *KeyBindings
 	MessageViewPassthrough *KeyBindings
 	Terminal               *KeyBindings
+	Contacts               *KeyBindings
+	ThreadView             *KeyBindings
+	Sieve                  *KeyBindings
 }
 
 type bindsContextType int
@@ -93,6 +96,9 @@ func defaultBindsConfig() *BindingConfig {
 		MessageView:            NewKeyBindings(),
 		MessageViewPassthrough: NewKeyBindings(),
 		Terminal:               NewKeyBindings(),
+		Contacts:               NewKeyBindings(),
+		ThreadView:             NewKeyBindings(),
+		Sieve:                  NewKeyBindings(),
 	}
 }
 
@@ -112,16 +118,7 @@ func parseBinds(root string) error {
 		return err
 	}
 
-	baseGroups := map[string]**KeyBindings{
-		"default":           &Binds.Global,
-		"compose":           &Binds.Compose,
-		"messages":          &Binds.MessageList,
-		"terminal":          &Binds.Terminal,
-		"view":              &Binds.MessageView,
-		"view::passthrough": &Binds.MessageViewPassthrough,
-		"compose::editor":   &Binds.ComposeEditor,
-		"compose::review":   &Binds.ComposeReview,
-	}
+	baseGroups := bindsGroups(Binds)
 
 	// Base Bindings
 	for _, sectionName := range binds.SectionStrings() {
@@ -148,6 +145,23 @@ func parseBinds(root string) error {
 	return nil
 }
 
+// bindsGroups maps the base sections of binds.conf to their key bindings.
+func bindsGroups(binds *BindingConfig) map[string]**KeyBindings {
+	return map[string]**KeyBindings{
+		"default":           &binds.Global,
+		"compose":           &binds.Compose,
+		"messages":          &binds.MessageList,
+		"terminal":          &binds.Terminal,
+		"view":              &binds.MessageView,
+		"view::passthrough": &binds.MessageViewPassthrough,
+		"compose::editor":   &binds.ComposeEditor,
+		"compose::review":   &binds.ComposeReview,
+		"contacts":          &binds.Contacts,
+		"view-thread":       &binds.ThreadView,
+		"sieve":             &binds.Sieve,
+	}
+}
+
 func LoadBindingSection(sec *ini.Section) (*KeyBindings, error) {
 	bindings := NewKeyBindings()
 	for key, value := range sec.KeysHash() {
@@ -0,0 +1,884 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/go-ini/ini"
+	"github.com/google/shlex"
+	"github.com/kyoh86/xdg"
+
+	"git.sr.ht/~rjarry/aerc/lib/rules"
+	"git.sr.ht/~rjarry/aerc/lib/templates"
+)
+
+// Problem is an error or a warning found in a configuration file.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+	Warning bool
+}
+
+func (p *Problem) String() string {
+	location := p.File
+	if p.Line > 0 {
+		location += fmt.Sprintf(":%d", p.Line)
+	}
+	if p.Warning {
+		return location + ": warning: " + p.Message
+	}
+	return location + ": error: " + p.Message
+}
+
+// Check parses aerc.conf, binds.conf, accounts.conf and the stylesets they
+// use in root, or in the default directory when root is nil, and returns all
+// the problems found sorted by file and line. The loaded configuration is
+// not modified. commandExists reports if a command can be run from a section
+// of binds.conf. When nil, the commands of the bindings are not checked.
+func Check(
+	root *string, commandExists func(section, name string) bool,
+) []*Problem {
+	if root == nil {
+		_root := path.Join(xdg.ConfigHome(), "aerc")
+		root = &_root
+	}
+	// parse methods record the deprecated settings they convert
+	defer func(warnings []Warning, mute bool) {
+		Warnings, statuslineMute = warnings, mute
+	}(Warnings, statuslineMute)
+
+	c := &checker{commandExists: commandExists}
+	stylesets, unsafe := c.checkAercConf(path.Join(*root, "aerc.conf"))
+	accounts := c.checkAccounts(path.Join(*root, "accounts.conf"), unsafe)
+	c.checkBinds(path.Join(*root, "binds.conf"), accounts)
+	for _, styleset := range stylesets {
+		c.checkStyleSet(styleset)
+	}
+
+	rank := make(map[string]int)
+	for i, file := range c.files {
+		rank[file] = i
+	}
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		a, b := c.problems[i], c.problems[j]
+		if a.File != b.File {
+			return rank[a.File] < rank[b.File]
+		}
+		return a.Line < b.Line
+	})
+	return c.problems
+}
+
+type checker struct {
+	problems      []*Problem
+	files         []string
+	commandExists func(section, name string) bool
+}
+
+func (c *checker) errorf(f *checkedFile, line int, format string, a ...interface{}) {
+	c.problems = append(c.problems, &Problem{
+		File: f.path, Line: line, Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (c *checker) warnf(f *checkedFile, line int, format string, a ...interface{}) {
+	c.problems = append(c.problems, &Problem{
+		File: f.path, Line: line, Message: fmt.Sprintf(format, a...),
+		Warning: true,
+	})
+}
+
+// checkedFile is an ini file with the line numbers of its sections and keys.
+type checkedFile struct {
+	*ini.File
+	path     string
+	sections map[string]int
+	// the lines of all the occurrences of each key, by section
+	keys map[string]map[string][]int
+}
+
+// load parses an ini file. It returns nil if the file does not exist or
+// cannot be parsed.
+func (c *checker) load(filename string, options ini.LoadOptions) *checkedFile {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	f := &checkedFile{
+		path:     filename,
+		sections: map[string]int{ini.DefaultSection: 0},
+		keys:     make(map[string]map[string][]int),
+	}
+	c.files = append(c.files, filename)
+	if err != nil {
+		c.errorf(f, 0, "%v", err)
+		return nil
+	}
+	f.File, err = ini.LoadSources(options, data)
+	if err != nil {
+		c.errorf(f, 0, "%v", err)
+		return nil
+	}
+
+	delimiters := options.KeyValueDelimiters
+	if delimiters == "" {
+		delimiters = "=:"
+	}
+	section := ini.DefaultSection
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", line[0] == ';', line[0] == '#':
+			continue
+		case line[0] == '[' && strings.Contains(line, "]"):
+			section = strings.TrimSpace(line[1:strings.Index(line, "]")])
+			if _, ok := f.sections[section]; !ok {
+				f.sections[section] = n
+			}
+			continue
+		}
+		i := strings.IndexAny(line, delimiters)
+		if i < 0 {
+			continue
+		}
+		key := strings.Trim(strings.TrimSpace(line[:i]), "\"`")
+		if f.keys[section] == nil {
+			f.keys[section] = make(map[string][]int)
+		}
+		f.keys[section][key] = append(f.keys[section][key], n)
+	}
+	return f
+}
+
+func (f *checkedFile) sectionLine(name string) int {
+	return f.sections[name]
+}
+
+// keyLine returns the line of the occurrence of a key that is in effect, or
+// the line of its section if the key was not found.
+func (f *checkedFile) keyLine(section, key string) int {
+	lines := f.keys[section][key]
+	if len(lines) == 0 {
+		return f.sectionLine(section)
+	}
+	return lines[len(lines)-1]
+}
+
+// checkFields validates the keys of a section mapped to the fields of the
+// structure pointed to by v. The keys that are not mapped are unknown unless
+// allowed returns true. The parse method of the fields in skip is not called.
+func (c *checker) checkFields(
+	f *checkedFile, sec *ini.Section, v interface{},
+	allowed func(key string) bool, skip ...string,
+) {
+	val := reflect.ValueOf(v).Elem()
+	typ := val.Type()
+	fields := make(map[string]int)
+	for i := 0; i < typ.NumField(); i++ {
+		if name := typ.Field(i).Tag.Get("ini"); name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	for _, key := range sec.Keys() {
+		line := f.keyLine(sec.Name(), key.Name())
+		i, ok := fields[key.Name()]
+		switch {
+		case !ok && (allowed == nil || !allowed(key.Name())):
+			c.errorf(f, line, "[%s]: unknown key %q", sec.Name(), key.Name())
+			continue
+		case !ok, contains(skip, key.Name()):
+			continue
+		}
+		err := setField(sec, key, reflect.ValueOf(v), val.Field(i), typ.Field(i))
+		if err != nil {
+			c.errorf(f, line, "[%s].%s: %v", sec.Name(), key.Name(), err)
+		}
+	}
+}
+
+// deprecated settings of aerc.conf and their replacements
+var deprecatedKeys = map[string]map[string]string{
+	"ui": {
+		"index-format":   "index-columns",
+		"dirlist-format": "dirlist-left and dirlist-right",
+	},
+	"statusline": {
+		"render-format": "status-columns",
+	},
+}
+
+var columnKeyRe = regexp.MustCompile(`^column-[\w-]+$`)
+
+// stylesetRef is a styleset selected by a section of aerc.conf.
+type stylesetRef struct {
+	name string
+	dirs []string
+	line int
+}
+
+// checkAercConf checks aerc.conf and returns the paths of the stylesets it
+// uses and the value of unsafe-accounts-conf.
+func (c *checker) checkAercConf(filename string) ([]string, bool) {
+	f := c.load(filename, ini.LoadOptions{KeyValueDelimiters: "="})
+	if f == nil {
+		return nil, false
+	}
+
+	var unsafe bool
+	var refs []*stylesetRef
+	var base *stylesetRef
+	for _, sec := range f.Sections() {
+		name := sec.Name()
+		switch {
+		case name == ini.DefaultSection:
+			for _, key := range sec.Keys() {
+				c.errorf(f, f.keyLine(name, key.Name()),
+					"key %q outside of a section", key.Name())
+			}
+		case name == "general":
+			general := new(GeneralConfig)
+			c.checkFields(f, sec, general, nil)
+			unsafe = general.UnsafeAccountsConf
+		case name == "ui" || strings.HasPrefix(name, "ui:"):
+			if name != "ui" && !c.checkUiContext(f, name) {
+				continue
+			}
+			c.checkUi(f, sec)
+			ref := &stylesetRef{line: f.sectionLine(name)}
+			if key, err := sec.GetKey("stylesets-dirs"); err == nil {
+				ref.dirs = key.Strings(":")
+				ref.line = f.keyLine(name, key.Name())
+			}
+			if key, err := sec.GetKey("styleset-name"); err == nil {
+				ref.name = key.String()
+				ref.line = f.keyLine(name, key.Name())
+			}
+			if name == "ui" {
+				base = ref
+			} else if ref.name != "" || len(ref.dirs) > 0 {
+				refs = append(refs, ref)
+			}
+		case name == "statusline":
+			c.checkDeprecated(f, sec)
+			c.checkColumns(f, sec, new(StatuslineConfig))
+		case name == "viewer":
+			viewer := new(ViewerConfig)
+			c.checkFields(f, sec, viewer, nil)
+			if sec.HasKey("image-protocol") {
+				switch viewer.ImageProtocol {
+				case "auto", "kitty", "sixel", "halfblocks", "none":
+				default:
+					c.errorf(f, f.keyLine(name, "image-protocol"),
+						"[viewer].image-protocol: invalid value %q",
+						viewer.ImageProtocol)
+				}
+			}
+		case name == "compose":
+			c.checkFields(f, sec, new(ComposeConfig), nil)
+		case name == "triggers":
+			c.checkFields(f, sec, new(TriggersConfig), nil)
+			if key, err := sec.GetKey("new-email"); err == nil &&
+				indexFmtRegexp.MatchString(key.String()) {
+				c.warnf(f, f.keyLine(name, "new-email"),
+					"[triggers].new-email: %%-based placeholders are deprecated, use templates")
+			}
+		case name == "templates":
+			c.checkTemplates(f, sec)
+		case name == "filters":
+			c.checkFilters(f, sec)
+		case name == "openers":
+			for _, key := range sec.Keys() {
+				if _, err := shlex.Split(key.String()); err != nil {
+					c.errorf(f, f.keyLine(name, key.Name()),
+						"[openers].%s: %v", key.Name(), err)
+				}
+			}
+		case name == "multipart-converters":
+			for _, key := range sec.Keys() {
+				mime := strings.ToLower(key.Name())
+				line := f.keyLine(name, key.Name())
+				if mime == "text/plain" {
+					c.errorf(f, line, "[%s]: text/plain is reserved", name)
+				} else if !strings.HasPrefix(mime, "text/") {
+					c.errorf(f, line, "[%s]: %q: only text/* MIME types are supported",
+						name, mime)
+				}
+			}
+		case name == "rules":
+			for _, key := range sec.Keys() {
+				if _, err := rules.Parse(key.Name(), key.Value()); err != nil {
+					c.errorf(f, f.keyLine(name, key.Name()),
+						"[rules] %s: %v", key.Name(), err)
+				}
+			}
+		default:
+			c.errorf(f, f.sectionLine(name), "unknown section [%s]", name)
+		}
+	}
+
+	if base == nil {
+		base = &stylesetRef{}
+	}
+	if base.name == "" {
+		base.name = "default"
+	}
+	for _, dir := range SearchDirs {
+		base.dirs = append(base.dirs, path.Join(dir, "stylesets"))
+	}
+	var stylesets []string
+	for _, ref := range append([]*stylesetRef{base}, refs...) {
+		// contextual sections fill in the missing part from the base
+		if ref.name == "" {
+			ref.name = base.name
+		} else if len(ref.dirs) == 0 {
+			ref.dirs = base.dirs
+		}
+		styleset, err := findStyleSet(ref.name, ref.dirs)
+		if err != nil {
+			c.errorf(f, ref.line, "%v", err)
+		} else if !contains(stylesets, styleset) {
+			stylesets = append(stylesets, styleset)
+		}
+	}
+	return stylesets, unsafe
+}
+
+// checkUiContext checks the name of a contextual [ui:...] section.
+func (c *checker) checkUiContext(f *checkedFile, name string) bool {
+	line := f.sectionLine(name)
+	index := strings.IndexAny(name, "~=")
+	if index < 0 {
+		c.errorf(f, line, "invalid ui context regex in [%s]", name)
+		return false
+	}
+	switch name[3:index] {
+	case "account", "folder", "subject":
+	default:
+		c.errorf(f, line, "unknown contextual ui section [%s]", name)
+		return false
+	}
+	if name[index] == '~' {
+		if _, err := regexp.Compile(name[index+1:]); err != nil {
+			c.errorf(f, line, "[%s]: %v", name, err)
+			return false
+		}
+	}
+	return true
+}
+
+func (c *checker) checkUi(f *checkedFile, sec *ini.Section) {
+	c.checkDeprecated(f, sec)
+	if key, err := sec.GetKey("index-format"); err == nil {
+		if _, err := convertIndexFormat(key.String()); err != nil {
+			c.errorf(f, f.keyLine(sec.Name(), key.Name()),
+				"[%s].index-format: %v", sec.Name(), err)
+		}
+	}
+	if key, err := sec.GetKey("dirlist-format"); err == nil {
+		left, right := convertDirlistFormat(key.String())
+		for _, t := range []string{left, right} {
+			if _, err := templates.ParseTemplate(t, t); err != nil {
+				c.errorf(f, f.keyLine(sec.Name(), key.Name()),
+					"[%s].dirlist-format: %v", sec.Name(), err)
+				break
+			}
+		}
+	}
+	c.checkColumns(f, sec, new(UIConfig))
+}
+
+func (c *checker) checkDeprecated(f *checkedFile, sec *ini.Section) {
+	base := strings.SplitN(sec.Name(), ":", 2)[0]
+	for _, key := range sec.Keys() {
+		if replacement, ok := deprecatedKeys[base][key.Name()]; ok {
+			c.warnf(f, f.keyLine(sec.Name(), key.Name()),
+				"[%s].%s is deprecated, use %s", sec.Name(), key.Name(),
+				replacement)
+		}
+	}
+}
+
+// checkColumns checks the fields of a section that may define column
+// templates. The errors in column-* keys are reported on their own line
+// rather than on the line of the columns list.
+func (c *checker) checkColumns(f *checkedFile, sec *ini.Section, v interface{}) {
+	failed := false
+	for _, key := range sec.Keys() {
+		if !columnKeyRe.MatchString(key.Name()) {
+			continue
+		}
+		t, err := templates.ParseTemplate(key.Name(), key.String())
+		if err == nil {
+			err = templates.Render(t, &bytes.Buffer{}, &dummyData{})
+		}
+		if err != nil {
+			c.errorf(f, f.keyLine(sec.Name(), key.Name()),
+				"[%s].%s: %v", sec.Name(), key.Name(), err)
+			failed = true
+		}
+	}
+	base := strings.SplitN(sec.Name(), ":", 2)[0]
+	var skip []string
+	if failed {
+		skip = []string{"index-columns", "status-columns"}
+	}
+	c.checkFields(f, sec, v, func(key string) bool {
+		_, deprecated := deprecatedKeys[base][key]
+		return deprecated || columnKeyRe.MatchString(key)
+	}, skip...)
+}
+
+func (c *checker) checkTemplates(f *checkedFile, sec *ini.Section) {
+	conf := new(TemplateConfig)
+	c.checkFields(f, sec, conf, nil)
+	dirs := conf.TemplateDirs
+	for _, dir := range SearchDirs {
+		dirs = append(dirs, path.Join(dir, "templates"))
+	}
+	for _, name := range []string{"new-message", "quoted-reply", "forwards"} {
+		if !sec.HasKey(name) {
+			continue
+		}
+		if err := checkTemplate(sec.Key(name).String(), dirs); err != nil {
+			c.errorf(f, f.keyLine(sec.Name(), name),
+				"[templates].%s: %v", name, err)
+		}
+	}
+}
+
+func (c *checker) checkFilters(f *checkedFile, sec *ini.Section) {
+	for _, key := range sec.Keys() {
+		filter := key.Name()
+		if !strings.Contains(filter, ",~") {
+			continue
+		}
+		regex := filter[strings.Index(filter, "~")+1:]
+		if _, err := regexp.Compile(regex); err != nil {
+			c.errorf(f, f.keyLine(sec.Name(), filter),
+				"[filters] %s: %v", filter, err)
+		}
+	}
+}
+
+// credential settings of accounts.conf
+var credentialKeys = []string{
+	"source-cred-cmd", "outgoing-cred-cmd", "outgoing-cred-cmd-cache",
+	"sieve-cred-cmd",
+}
+
+// backend specific settings of accounts.conf and their validation, by source
+// scheme
+var backendKeys = map[string]map[string]func(string) error{
+	"imap": {
+		"idle-timeout":       checkDuration,
+		"idle-debounce":      checkDuration,
+		"reconnect-maxwait":  checkDuration,
+		"connection-timeout": checkDuration,
+		"keepalive-period":   checkDuration,
+		"keepalive-probes":   checkInt,
+		"keepalive-interval": checkDuration,
+		"cache-headers":      checkBool,
+		"cache-max-age":      checkDuration,
+	},
+	"notmuch": {
+		"query-map":     nil,
+		"exclude-tags":  nil,
+		"maildir-store": nil,
+	},
+}
+
+func checkDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err == nil && d < 0 {
+		err = errors.New("negative duration")
+	}
+	return err
+}
+
+func checkInt(value string) error {
+	_, err := strconv.Atoi(value)
+	return err
+}
+
+func checkBool(value string) error {
+	_, err := strconv.ParseBool(value)
+	return err
+}
+
+// checkAccounts checks accounts.conf and returns the names of the accounts.
+func (c *checker) checkAccounts(filename string, unsafe bool) []string {
+	f := c.load(filename, ini.LoadOptions{})
+	if f == nil {
+		return nil
+	}
+	if info, err := os.Stat(filename); err == nil && !unsafe &&
+		info.Mode().Perm()&0o44 != 0 {
+		c.errorf(f, 0, "permissions are too open, run `chmod 600 %s`", filename)
+	}
+
+	var names []string
+	for _, sec := range f.Sections() {
+		name := sec.Name()
+		if name == ini.DefaultSection {
+			for _, key := range sec.Keys() {
+				c.warnf(f, f.keyLine(name, key.Name()),
+					"key %q outside of an account is ignored", key.Name())
+			}
+			continue
+		}
+		names = append(names, name)
+
+		var scheme string
+		if !sec.HasKey("source") {
+			c.errorf(f, f.sectionLine(name), "[%s]: expected source", name)
+		} else if u, err := url.Parse(sec.Key("source").String()); err != nil {
+			c.errorf(f, f.keyLine(name, "source"), "[%s].source: %v", name, err)
+		} else {
+			scheme = strings.SplitN(u.Scheme, "+", 2)[0]
+			if scheme == "imaps" {
+				scheme = "imap"
+			}
+		}
+		if !sec.HasKey("from") {
+			c.errorf(f, f.sectionLine(name), "[%s]: expected from", name)
+		}
+		// the source parse method runs source-cred-cmd
+		c.checkFields(f, sec, new(AccountConfig), func(key string) bool {
+			_, ok := backendKeys[scheme][key]
+			return ok || contains(credentialKeys, key)
+		}, "source")
+		for _, key := range sec.Keys() {
+			check := backendKeys[scheme][key.Name()]
+			if check == nil {
+				continue
+			}
+			if err := check(key.String()); err != nil {
+				c.errorf(f, f.keyLine(name, key.Name()),
+					"[%s].%s: %v", name, key.Name(), err)
+			}
+		}
+	}
+	return names
+}
+
+// checkBinds checks binds.conf. The account contexts are matched against the
+// accounts names.
+func (c *checker) checkBinds(filename string, accounts []string) {
+	f := c.load(filename, ini.LoadOptions{})
+	if f == nil {
+		return
+	}
+
+	// the commands of bindings are started with the global ex key
+	exKey := KeyStroke{tcell.ModNone, tcell.KeyRune, ':'}
+	if key, err := f.Section(ini.DefaultSection).GetKey("$ex"); err == nil {
+		strokes, err := ParseKeyStrokes(key.String())
+		if err == nil && len(strokes) == 1 {
+			exKey = strokes[0]
+		}
+	}
+
+	groups := bindsGroups(new(BindingConfig))
+	bases := make(map[string][]*checkedBinding)
+	contexts := make(map[string][][]*checkedBinding)
+	for _, sec := range f.Sections() {
+		name := sec.Name()
+		line := f.sectionLine(name)
+		group, context := name, ""
+		if i := strings.Index(strings.ReplaceAll(name, "::", "//"), ":"); i >= 0 {
+			group, context = name[:i], name[i+1:]
+		}
+		group = strings.ToLower(group)
+		if name == ini.DefaultSection {
+			group = "default"
+		}
+		if _, ok := groups[group]; !ok {
+			c.errorf(f, line, "unknown keybinding group [%s]", name)
+			continue
+		}
+		if context != "" && !c.checkBindsContext(f, name, context, accounts) {
+			continue
+		}
+		bindings := c.checkBindingSection(f, sec, group, exKey)
+		if context == "" {
+			bases[group] = bindings
+		} else {
+			contexts[group] = append(contexts[group], bindings)
+		}
+	}
+
+	// contextual bindings take precedence over the base ones
+	for group, sections := range contexts {
+		for _, bindings := range sections {
+			for _, b := range bindings {
+				for _, base := range bases[group] {
+					c.checkConflict(f, b, base, true)
+				}
+			}
+		}
+	}
+}
+
+// checkBindsContext checks the context of a [group:account=...] or
+// [group:folder=...] section.
+func (c *checker) checkBindsContext(
+	f *checkedFile, name, context string, accounts []string,
+) bool {
+	line := f.sectionLine(name)
+	index := strings.Index(context, "=")
+	if index < 0 {
+		c.errorf(f, line, "invalid bind context regex in [%s]", name)
+		return false
+	}
+	regex, err := regexp.Compile(context[index+1:])
+	if err != nil {
+		c.errorf(f, line, "[%s]: %v", name, err)
+		return false
+	}
+	switch context[:index] {
+	case "account":
+		for _, account := range accounts {
+			if regex.FindString(account) != "" {
+				return true
+			}
+		}
+		c.warnf(f, line, "[%s]: no account matches %q", name, regex)
+	case "folder":
+	default:
+		c.errorf(f, line, "unknown context bind section [%s]", name)
+		return false
+	}
+	return true
+}
+
+// checkedBinding is a key binding with its location.
+type checkedBinding struct {
+	key     string
+	section string
+	line    int
+	input   []KeyStroke
+}
+
+// checkBindingSection checks the keys of a section of binds.conf and
+// returns its bindings.
+func (c *checker) checkBindingSection(
+	f *checkedFile, sec *ini.Section, group string, exKey KeyStroke,
+) []*checkedBinding {
+	var bindings []*checkedBinding
+	for _, key := range sec.Keys() {
+		name := sec.Name()
+		line := f.keyLine(name, key.Name())
+		if lines := f.keys[name][key.Name()]; len(lines) > 1 {
+			for _, l := range lines[:len(lines)-1] {
+				c.warnf(f, l, "[%s] %s: bound again at line %d",
+					name, key.Name(), line)
+			}
+		}
+		switch key.Name() {
+		case "$ex":
+			strokes, err := ParseKeyStrokes(key.String())
+			if err == nil && len(strokes) != 1 {
+				err = errors.New("expected a single key")
+			}
+			if err != nil {
+				c.errorf(f, line, "[%s].$ex: %v", name, err)
+			}
+			continue
+		case "$noinherit":
+			if v := key.String(); v != "true" && v != "false" {
+				c.errorf(f, line, "[%s].$noinherit: expected true or false", name)
+			}
+			continue
+		}
+		input, err := ParseKeyStrokes(key.Name())
+		if err != nil {
+			c.errorf(f, line, "[%s] %s: %v", name, key.Name(), err)
+			continue
+		}
+		output, err := ParseKeyStrokes(key.String())
+		if err != nil {
+			c.errorf(f, line, "[%s] %s: %v", name, key.Name(), err)
+			continue
+		}
+		if c.commandExists != nil {
+			for _, cmd := range boundCommands(output, exKey) {
+				if !c.commandExists(group, cmd) {
+					c.errorf(f, line, "[%s] %s: unknown command %q",
+						name, key.Name(), cmd)
+				}
+			}
+		}
+		b := &checkedBinding{
+			key: key.Name(), section: name, line: line, input: input,
+		}
+		for _, other := range bindings {
+			c.checkConflict(f, b, other, false)
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings
+}
+
+// checkConflict reports if a binding makes another one unreachable. When
+// override is true, bindings of the same keys are not conflicting.
+func (c *checker) checkConflict(f *checkedFile, b, other *checkedBinding, override bool) {
+	switch {
+	case sameKeyStrokes(b.input, other.input):
+		if !override {
+			c.errorf(f, b.line, "[%s] %s: same keys as %s at line %d",
+				b.section, b.key, other.key, other.line)
+		}
+	case isPrefix(b.input, other.input):
+		c.errorf(f, b.line, "[%s] %s: makes %s at line %d unreachable",
+			b.section, b.key, other.key, other.line)
+	case isPrefix(other.input, b.input):
+		c.errorf(f, b.line, "[%s] %s: unreachable, %s is bound at line %d",
+			b.section, b.key, other.key, other.line)
+	}
+}
+
+func sameKeyStroke(a, b KeyStroke) bool {
+	if a.Modifiers != b.Modifiers || a.Key != b.Key {
+		return false
+	}
+	return a.Key != tcell.KeyRune || a.Rune == b.Rune
+}
+
+func sameKeyStrokes(a, b []KeyStroke) bool {
+	return len(a) == len(b) && isPrefix(a, b)
+}
+
+// isPrefix returns true if the strokes of a start the strokes of b.
+func isPrefix(a, b []KeyStroke) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameKeyStroke(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// boundCommands returns the names of the commands typed by the output of a
+// binding. The commands that are neither followed by a space nor executed
+// are not complete and are ignored.
+func boundCommands(output []KeyStroke, exKey KeyStroke) []string {
+	var cmds []string
+	var name strings.Builder
+	typing, done := false, false
+	for _, stroke := range output {
+		switch {
+		case !typing:
+			if sameKeyStroke(stroke, exKey) {
+				typing, done = true, false
+				name.Reset()
+			}
+		case stroke.Key == tcell.KeyEnter:
+			if !done && name.Len() > 0 {
+				cmds = append(cmds, name.String())
+			}
+			typing = false
+		case stroke.Key != tcell.KeyRune:
+			// the command line is edited
+			typing = false
+		case done:
+		case stroke.Rune == ' ':
+			if name.Len() > 0 {
+				cmds = append(cmds, name.String())
+			}
+			done = true
+		default:
+			name.WriteRune(stroke.Rune)
+		}
+	}
+	return cmds
+}
+
+// checkStyleSet checks a styleset file.
+func (c *checker) checkStyleSet(filename string) {
+	f := c.load(filename, ini.LoadOptions{SpaceBeforeInlineComment: true})
+	if f == nil {
+		return
+	}
+	for _, sec := range f.Sections() {
+		name := sec.Name()
+		switch name {
+		case ini.DefaultSection, "viewer", "user":
+		default:
+			c.errorf(f, f.sectionLine(name), "unknown section [%s]", name)
+			continue
+		}
+		for _, key := range sec.Keys() {
+			if err := checkStyle(name, key.Name(), key.String()); err != nil {
+				c.errorf(f, f.keyLine(name, key.Name()), "%s: %v",
+					key.Name(), err)
+			}
+		}
+	}
+}
+
+// checkStyle checks a style setting of a styleset section.
+func checkStyle(section, key, value string) error {
+	tokens := strings.Split(key, ".")
+	switch {
+	case len(tokens) == 3 && section == ini.DefaultSection:
+		if tokens[1] != "selected" {
+			return errors.New("unknown modifier: " + tokens[1])
+		}
+	case len(tokens) != 2:
+		return errors.New("style parsing error")
+	}
+	styleName, attr := tokens[0], tokens[len(tokens)-1]
+
+	switch {
+	case section == "user":
+	case section == "viewer":
+		regex, err := regexp.Compile("^" + fnmatchToRegex(styleName) + "$")
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, name := range ViewerStyleNames {
+			found = found || regex.MatchString(name)
+		}
+		if !found {
+			return errors.New("unknown viewer style object: " + styleName)
+		}
+	case strings.ContainsAny(styleName, "*?"):
+		if _, err := regexp.Compile(fnmatchToRegex(styleName)); err != nil {
+			return err
+		}
+	default:
+		if _, ok := StyleNames[styleName]; !ok {
+			return errors.New("unknown style object: " + styleName)
+		}
+	}
+
+	if err := new(Style).Set(attr, value); err != nil {
+		return err
+	}
+	if attr == "fg" || attr == "bg" {
+		_, err := strconv.ParseUint(value, 10, 8)
+		if err != nil && value != "default" &&
+			tcell.GetColor(value) == tcell.ColorDefault {
+			return fmt.Errorf("unknown color %q", value)
+		}
+	}
+	return nil
+}
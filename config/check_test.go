@@ -0,0 +1,120 @@
+package config
+
+import (
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestCheck(t *testing.T) {
+	stylesets, err := filepath.Abs("../stylesets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	writeConfig(t, root, "aerc.conf", `[general]
+log-level=info
+[ui]
+stylesets-dirs=`+stylesets+`
+sidebar-width=wide
+dirlist-delay=5 parsecs
+index-format=%D %s
+column-foo={{.Nope}}
+[ui:folder~(]
+[triggers]
+new-email=exec notify-send %s
+[bogus]
+`)
+	writeConfig(t, root, "accounts.conf", `[work]
+source=imaps://me@example.com
+from=me <me@example.com>
+idle-timeout=3 years
+foo=bar
+`)
+	writeConfig(t, root, "binds.conf", `<C-p> = :prev-tab<Enter>
+[messages]
+z = :fold<Enter>
+zz = :unfold<Enter>
+q = :quit<Enter>
+q = :nope<Enter>
+c = :cf<space>
+[messages:account=home]
+[compose::review]
+y = :send<Enter>
+`)
+
+	commands := map[string]bool{
+		"prev-tab": true, "fold": true, "unfold": true, "quit": true,
+		"cf": true, "send": true,
+	}
+	problems := Check(&root, func(section, name string) bool {
+		return commands[name]
+	})
+
+	aerc := path.Join(root, "aerc.conf")
+	accounts := path.Join(root, "accounts.conf")
+	binds := path.Join(root, "binds.conf")
+	expected := []Problem{
+		{aerc, 5, `[ui].sidebar-width: strconv.ParseInt: parsing "wide": invalid syntax`, false},
+		{aerc, 6, `[ui].dirlist-delay: time: unknown unit " parsecs" in duration "5 parsecs"`, false},
+		{aerc, 7, `[ui].index-format is deprecated, use index-columns`, true},
+		{aerc, 8, `[ui].column-foo: template: column-foo:1:2: executing "column-foo" at <.Nope>: can't evaluate field Nope in type *config.dummyData`, false},
+		{aerc, 9, "[ui:folder~(]: error parsing regexp: missing closing ): `(`", false},
+		{aerc, 11, `[triggers].new-email: %-based placeholders are deprecated, use templates`, true},
+		{aerc, 12, `unknown section [bogus]`, false},
+		{accounts, 4, `[work].idle-timeout: time: unknown unit " years" in duration "3 years"`, false},
+		{accounts, 5, `[work]: unknown key "foo"`, false},
+		{binds, 4, `[messages] zz: unreachable, z is bound at line 3`, false},
+		{binds, 5, `[messages] q: bound again at line 6`, true},
+		{binds, 6, `[messages] q: unknown command "nope"`, false},
+		{binds, 8, `[messages:account=home]: no account matches "home"`, true},
+	}
+	if len(problems) != len(expected) {
+		for _, p := range problems {
+			t.Log(p)
+		}
+		t.Fatalf("expected %d problems, got %d", len(expected), len(problems))
+	}
+	for i, p := range problems {
+		if *p != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i].String(), p.String())
+		}
+	}
+	if len(Warnings) != 0 {
+		t.Errorf("warnings recorded by the check: %#v", Warnings)
+	}
+}
+
+func TestBoundCommands(t *testing.T) {
+	exKey := KeyStroke{tcell.ModNone, tcell.KeyRune, ':'}
+	for output, expected := range map[string]string{
+		":next<Enter>":                       "next",
+		":cf<space>":                         "cf",
+		":cf":                                "",
+		":prompt 'Delete?' delete<Enter>":    "prompt",
+		":toggle-key-passthrough<Enter>/":    "toggle-key-passthrough",
+		":mark -a<Enter>:delete<Enter>":      "mark delete",
+		":foo<C-u>:bar<Enter>":               "bar",
+		"<C-x>:baz<Enter>":                   "baz",
+		"jj":                                 "",
+		":open-link <space>":                 "open-link",
+		":choose -o y yes quit<Enter><Down>": "choose",
+	} {
+		strokes, err := ParseKeyStrokes(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names string
+		for i, name := range boundCommands(strokes, exKey) {
+			if i > 0 {
+				names += " "
+			}
+			names += name
+		}
+		if names != expected {
+			t.Errorf("%s: expected %q, got %q", output, expected, names)
+		}
+	}
+}
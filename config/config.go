@@ -94,6 +94,8 @@ func LoadConfigFromFile(root *string, accts []string) error {
 		_root := path.Join(xdg.ConfigHome(), "aerc")
 		root = &_root
 	}
+	configRoot = *root
+	configAccts = accts
 	filename := path.Join(*root, "aerc.conf")
 
 	// if it doesn't exist copy over the template, then load
@@ -135,6 +137,9 @@ func LoadConfigFromFile(root *string, accts []string) error {
 	if err := parseTriggers(file); err != nil {
 		return err
 	}
+	if err := parseRules(file); err != nil {
+		return err
+	}
 	if err := parseUi(file); err != nil {
 		return err
 	}
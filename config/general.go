@@ -17,17 +17,26 @@ type GeneralConfig struct {
 	LogFile            string       `ini:"log-file"`
 	LogLevel           log.LogLevel `ini:"log-level" default:"info" parse:"ParseLogLevel"`
 	DisableIPC         bool         `ini:"disable-ipc"`
+	WatchConfig        bool         `ini:"watch-config"`
 }
 
 var General = new(GeneralConfig)
 
+// Batch is set when aerc runs a script without user interface. The standard
+// output is then not used for logging.
+var Batch bool
+
 func parseGeneral(file *ini.File) error {
 	var logFile *os.File
 
 	if err := MapToStruct(file.Section("general"), General, true); err != nil {
 		return err
 	}
-	if !isatty.IsTerminal(os.Stdout.Fd()) {
+	if reloading {
+		log.Debugf("aerc.conf: [general] %#v", General)
+		return nil
+	}
+	if !Batch && !isatty.IsTerminal(os.Stdout.Fd()) {
 		logFile = os.Stdout
 		// redirected to file, force TRACE level
 		General.LogLevel = log.TRACE
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"path"
+	"reflect"
+)
+
+// directory and accounts given to LoadConfigFromFile, used to reload
+var (
+	configRoot  string
+	configAccts []string
+	// the log file is only opened at startup
+	reloading bool
+	// accounts before the reload, their resolved sources are reused
+	previousAccounts []*AccountConfig
+)
+
+// Reload parses the configuration files again with the directory and the
+// accounts given at startup. The current configuration is kept on errors.
+// The top-level objects are updated in place so that the references kept by
+// the user interface remain valid.
+func Reload() error {
+	general, compose, viewer := General, Compose, Viewer
+	statusline, triggers, ui := Statusline, Triggers, Ui
+	templates, binds := Templates, Binds
+	filters, converters, openers := Filters, Converters, Openers
+	rules, accounts, warnings := Rules, Accounts, Warnings
+
+	restore := func() {
+		General, Compose, Viewer = general, compose, viewer
+		Statusline, Triggers, Ui = statusline, triggers, ui
+		Templates, Binds = templates, binds
+		Filters, Converters, Openers = filters, converters, openers
+		Rules, Accounts, Warnings = rules, accounts, warnings
+	}
+
+	General = new(GeneralConfig)
+	Compose = new(ComposeConfig)
+	Viewer = new(ViewerConfig)
+	Statusline = new(StatuslineConfig)
+	Triggers = new(TriggersConfig)
+	Ui = defaultUiConfig()
+	Templates = new(TemplateConfig)
+	Binds = defaultBindsConfig()
+	Filters = nil
+	Converters = make(map[string]string)
+	Openers = make(map[string][]string)
+	Rules = nil
+	Accounts = nil
+	Warnings = nil
+
+	root := configRoot
+	reloading, previousAccounts = true, accounts
+	err := LoadConfigFromFile(&root, configAccts)
+	reloading, previousAccounts = false, nil
+	if err != nil {
+		restore()
+		return err
+	}
+	if len(Accounts) == 0 && len(accounts) > 0 {
+		restore()
+		return errors.New("accounts.conf: no account left")
+	}
+
+	*general, *compose, *viewer = *General, *Compose, *Viewer
+	*statusline, *triggers, *ui = *Statusline, *Triggers, *Ui
+	*templates, *binds = *Templates, *Binds
+	General, Compose, Viewer = general, compose, viewer
+	Statusline, Triggers, Ui = statusline, triggers, ui
+	Templates, Binds = templates, binds
+
+	return nil
+}
+
+// Files returns the configuration files and the stylesets in use.
+func Files() []string {
+	files := []string{
+		path.Join(configRoot, "aerc.conf"),
+		path.Join(configRoot, "binds.conf"),
+		path.Join(configRoot, "accounts.conf"),
+	}
+	stylesets := []string{Ui.StyleSetPath()}
+	for _, contextualUi := range Ui.contextualUis {
+		if contextualUi.UiConfig.style.path != "" {
+			stylesets = append(stylesets, contextualUi.UiConfig.style.path)
+		}
+	}
+	for _, styleset := range stylesets {
+		if !contains(files, styleset) {
+			files = append(files, styleset)
+		}
+	}
+	return files
+}
+
+// Equal returns true if both accounts have the same settings.
+func (a *AccountConfig) Equal(b *AccountConfig) bool {
+	x, y := *a, *b
+	// cached passwords are not settings
+	x.Outgoing.cache, y.Outgoing.cache = "", ""
+	x.Sieve.cache, y.Sieve.cache = "", ""
+	if (x.LocalizedRe == nil) != (y.LocalizedRe == nil) {
+		return false
+	}
+	if x.LocalizedRe != nil && x.LocalizedRe.String() != y.LocalizedRe.String() {
+		return false
+	}
+	x.LocalizedRe, y.LocalizedRe = nil, nil
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/go-ini/ini"
+
+	"git.sr.ht/~rjarry/aerc/lib/rules"
+	"git.sr.ht/~rjarry/aerc/log"
+)
+
+var Rules []*rules.Rule
+
+func parseRules(file *ini.File) error {
+	section, err := file.GetSection("rules")
+	if err != nil {
+		goto out
+	}
+	for _, key := range section.Keys() {
+		rule, err := rules.Parse(key.Name(), key.Value())
+		if err != nil {
+			return fmt.Errorf("[rules] %s: %w", key.Name(), err)
+		}
+		Rules = append(Rules, rule)
+	}
+out:
+	log.Debugf("aerc.conf: [rules] %#v", Rules)
+	return nil
+}
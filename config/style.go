@@ -251,10 +251,48 @@ func (s Style) composeWith(styles []*Style) Style {
 	return newStyle
 }
 
+// ViewerStyleNames are the style objects of the [viewer] section of
+// stylesets. They are used by the built-in colorize filter, HTML renderer and
+// pager.
+var ViewerStyleNames = []string{
+	"url", "header", "signature",
+	"diff_meta", "diff_chunk", "diff_add", "diff_del",
+	"quote_1", "quote_2", "quote_3", "quote_4", "quote_x",
+	"search", "search_current", "selection",
+}
+
+// pagerStyleNames are the viewer styles of the built-in pager. They keep
+// their default when a [viewer] section does not set them.
+var pagerStyleNames = map[string]bool{
+	"search": true, "search_current": true, "selection": true,
+}
+
+// defaultViewerStyles mirrors the default theme of the colorize filter. It
+// is only used when the styleset has no [viewer] section.
+var defaultViewerStyles = map[string]Style{
+	"url":        {Fg: tcell.NewHexColor(0xffffaf), Underline: true},
+	"header":     {Fg: tcell.NewHexColor(0xaf87ff), Bold: true},
+	"signature":  {Fg: tcell.NewHexColor(0xaf87ff), Dim: true},
+	"diff_meta":  {Fg: tcell.NewHexColor(0xffffff), Bold: true},
+	"diff_chunk": {Fg: tcell.NewHexColor(0x00cdcd)},
+	"diff_add":   {Fg: tcell.NewHexColor(0x00cd00)},
+	"diff_del":   {Fg: tcell.NewHexColor(0xcd0000)},
+	"quote_1":    {Fg: tcell.NewHexColor(0x5fafff)},
+	"quote_2":    {Fg: tcell.NewHexColor(0xff8700)},
+	"quote_3":    {Fg: tcell.NewHexColor(0xaf87ff)},
+	"quote_4":    {Fg: tcell.NewHexColor(0xff5fd7)},
+	"quote_x":    {Fg: tcell.NewHexColor(0x808080)},
+
+	"search":         {Fg: tcell.ColorBlack, Bg: tcell.NewHexColor(0xffff5f)},
+	"search_current": {Fg: tcell.ColorBlack, Bg: tcell.NewHexColor(0xff8700), Bold: true},
+	"selection":      {Reverse: true},
+}
+
 type StyleSet struct {
 	objects  map[StyleObject]*Style
 	selected map[StyleObject]*Style
 	user     map[string]*Style
+	viewer   map[string]*Style
 	path     string
 }
 
@@ -263,11 +301,13 @@ func NewStyleSet() StyleSet {
 		objects:  make(map[StyleObject]*Style),
 		selected: make(map[StyleObject]*Style),
 		user:     make(map[string]*Style),
+		viewer:   make(map[string]*Style),
 	}
 	for _, so := range StyleNames {
 		ss.objects[so] = new(Style)
 		ss.selected[so] = new(Style)
 	}
+	ss.resetViewer()
 
 	return ss
 }
@@ -277,6 +317,14 @@ func (ss StyleSet) reset() {
 		ss.objects[so].Reset()
 		ss.selected[so].Reset()
 	}
+	ss.resetViewer()
+}
+
+func (ss StyleSet) resetViewer() {
+	for name, style := range defaultViewerStyles {
+		s := style
+		ss.viewer[name] = &s
+	}
 }
 
 func (ss StyleSet) Get(so StyleObject) tcell.Style {
@@ -294,6 +342,15 @@ func (ss StyleSet) UserStyle(name string) tcell.Style {
 	return tcell.StyleDefault
 }
 
+// ViewerStyle returns the style of a [viewer] section object such as "url" or
+// "quote_1".
+func (ss StyleSet) ViewerStyle(name string) tcell.Style {
+	if style, found := ss.viewer[name]; found {
+		return style.Get()
+	}
+	return tcell.StyleDefault
+}
+
 func (ss StyleSet) Compose(so StyleObject, sos []StyleObject) tcell.Style {
 	base := *ss.objects[so]
 	styles := make([]*Style, len(sos))
@@ -446,6 +503,10 @@ func (ss *StyleSet) ParseStyleSet(file *ini.File) error {
 		}
 	}
 
+	if err := ss.parseViewer(file); err != nil {
+		return err
+	}
+
 	user, err := file.GetSection("user")
 	if err != nil {
 		// This errors if the section doesn't exist, which is ok
@@ -475,6 +536,47 @@ func (ss *StyleSet) ParseStyleSet(file *ini.File) error {
 	return nil
 }
 
+func (ss *StyleSet) parseViewer(file *ini.File) error {
+	viewer, err := file.GetSection("viewer")
+	if err != nil {
+		// This errors if the section doesn't exist, which is ok
+		return nil
+	}
+	// like the colorize filter, a [viewer] section disables the default theme
+	for _, name := range ViewerStyleNames {
+		if !pagerStyleNames[name] {
+			ss.viewer[name] = new(Style)
+		}
+	}
+	for _, key := range viewer.KeyStrings() {
+		tokens := strings.Split(key, ".")
+		if len(tokens) != 2 {
+			return errors.New("Style parsing error: " + key)
+		}
+		styleName, attr := tokens[0], tokens[1]
+		val := viewer.KeysHash()[key]
+		regex := "^" + fnmatchToRegex(styleName) + "$"
+		found := false
+		for _, name := range ViewerStyleNames {
+			matched, err := regexp.MatchString(regex, name)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+			found = true
+			if err := ss.viewer[name].Set(attr, val); err != nil {
+				return err
+			}
+		}
+		if !found {
+			return errors.New("Unknown viewer style object: " + styleName)
+		}
+	}
+	return nil
+}
+
 func (ss *StyleSet) LoadStyleSet(stylesetName string, stylesetDirs []string) error {
 	filepath, err := findStyleSet(stylesetName, stylesetDirs)
 	if err != nil {
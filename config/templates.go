@@ -76,12 +76,18 @@ func (d *dummyData) Date() time.Time                 { return time.Now() }
 func (d *dummyData) DateAutoFormat(time.Time) string { return "" }
 func (d *dummyData) Header(string) string            { return "" }
 func (d *dummyData) ThreadPrefix() string            { return "└─>" }
+func (d *dummyData) ThreadFolded() bool              { return false }
+func (d *dummyData) ThreadCount() int                { return 0 }
+func (d *dummyData) ThreadUnread() int               { return 0 }
 func (d *dummyData) Subject() string                 { return "Re: [PATCH] hey" }
 func (d *dummyData) SubjectBase() string             { return "[PATCH] hey" }
 func (d *dummyData) Number() int                     { return 0 }
 func (d *dummyData) Labels() []string                { return nil }
 func (d *dummyData) Flags() []string                 { return nil }
 func (d *dummyData) MessageId() string               { return "123456789@foo.org" }
+func (d *dummyData) ListId() string                  { return "list.example.org" }
+func (d *dummyData) SenderWarnings() []string        { return nil }
+func (d *dummyData) SenderTrust() string             { return "" }
 func (d *dummyData) Size() int                       { return 420 }
 func (d *dummyData) OriginalText() string            { return "Blah blah blah" }
 func (d *dummyData) OriginalDate() time.Time         { return time.Now() }
@@ -98,6 +104,7 @@ func (d *dummyData) ContentInfo() string             { return "" }
 func (d *dummyData) StatusInfo() string              { return "" }
 func (d *dummyData) TrayInfo() string                { return "" }
 func (d *dummyData) PendingKeys() string             { return "" }
+func (d *dummyData) ErrorText() string               { return "" }
 
 func (d *dummyData) Style(string, string) string               { return "" }
 func (d *dummyData) StyleSwitch(string, ...models.Case) string { return "" }
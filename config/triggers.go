@@ -1,15 +1,41 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/go-ini/ini"
 	"github.com/google/shlex"
 
 	"git.sr.ht/~rjarry/aerc/lib/format"
+	"git.sr.ht/~rjarry/aerc/lib/templates"
 	"git.sr.ht/~rjarry/aerc/log"
 )
 
+// Trigger is executed when an event occurs. It is either an aerc command
+// split in arguments or, when the value starts with !, a shell command.
+type Trigger struct {
+	Name    string
+	Command []string
+	Shell   string
+}
+
+// IsSet returns false when no command is configured for the trigger.
+func (t Trigger) IsSet() bool {
+	return len(t.Command) > 0 || t.Shell != ""
+}
+
 type TriggersConfig struct {
-	NewEmail []string `ini:"new-email" parse:"ParseNewEmail"`
+	NewEmail           Trigger `ini:"new-email" parse:"ParseNewEmail"`
+	MailSent           Trigger `ini:"mail-sent" parse:"ParseTrigger"`
+	MailDeleted        Trigger `ini:"mail-deleted" parse:"ParseTrigger"`
+	MailMoved          Trigger `ini:"mail-moved" parse:"ParseTrigger"`
+	FlagChanged        Trigger `ini:"flag-changed" parse:"ParseTrigger"`
+	FolderOpened       Trigger `ini:"folder-opened" parse:"ParseTrigger"`
+	ConnectionLost     Trigger `ini:"connection-lost" parse:"ParseTrigger"`
+	ConnectionRestored Trigger `ini:"connection-restored" parse:"ParseTrigger"`
+	AercStartup        Trigger `ini:"aerc-startup" parse:"ParseTrigger"`
+	AercShutdown       Trigger `ini:"aerc-shutdown" parse:"ParseTrigger"`
+	ComposeOpened      Trigger `ini:"compose-opened" parse:"ParseTrigger"`
 }
 
 var Triggers = new(TriggersConfig)
@@ -22,7 +48,32 @@ func parseTriggers(file *ini.File) error {
 	return nil
 }
 
-func (t *TriggersConfig) ParseNewEmail(_ *ini.Section, key *ini.Key) ([]string, error) {
+func (t *TriggersConfig) ParseTrigger(_ *ini.Section, key *ini.Key) (Trigger, error) {
+	return parseTrigger(key.Name(), key.String())
+}
+
+func parseTrigger(name, value string) (Trigger, error) {
+	trigger := Trigger{Name: name}
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "!") {
+		trigger.Shell = strings.TrimSpace(value[1:])
+		_, err := templates.ParseTemplate(name, trigger.Shell)
+		return trigger, err
+	}
+	args, err := shlex.Split(value)
+	if err != nil {
+		return trigger, err
+	}
+	for _, arg := range args {
+		if _, err := templates.ParseTemplate(name, arg); err != nil {
+			return trigger, err
+		}
+	}
+	trigger.Command = args
+	return trigger, nil
+}
+
+func (t *TriggersConfig) ParseNewEmail(_ *ini.Section, key *ini.Key) (Trigger, error) {
 	cmd := indexFmtRegexp.ReplaceAllStringFunc(
 		key.String(),
 		func(s string) string {
@@ -31,14 +82,18 @@ func (t *TriggersConfig) ParseNewEmail(_ *ini.Section, key *ini.Key) ([]string,
 			return t
 		},
 	)
-	args, err := shlex.Split(cmd)
+	trigger, err := parseTrigger(key.Name(), cmd)
 	if err != nil {
-		return nil, err
+		return trigger, err
 	}
 	if cmd != key.String() {
 		log.Warnf("%s %s",
 			"The new-email trigger now uses templates instead of %-based placeholders.",
 			"Backward compatibility will be removed in aerc 0.17.")
+		converted := "!" + trigger.Shell
+		if trigger.Shell == "" {
+			converted = format.ShellQuote(trigger.Command)
+		}
 		Warnings = append(Warnings, Warning{
 			Title: "FORMAT CHANGED: [triggers].new-email",
 			Body: `
@@ -47,7 +102,7 @@ The new-email trigger now uses templates instead of %-based placeholders.
 Your configuration in this instance was automatically converted to:
 
 [triggers]
-new-email = ` + format.ShellQuote(args) + `
+new-email = ` + converted + `
 
 Your configuration file was not changed. To make this change permanent and to
 dismiss this warning on launch, replace the above line into aerc.conf. See
@@ -57,5 +112,5 @@ The automatic conversion of new-email will be removed in aerc 0.17.
 `,
 		})
 	}
-	return args, nil
+	return trigger, nil
 }
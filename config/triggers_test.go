@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-ini/ini"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTriggers(t *testing.T) {
+	assert := assert.New(t)
+
+	file, err := ini.Load([]byte(`
+[triggers]
+new-email = exec notify-send "New email from %n" "%s"
+mail-sent = !notify-send {{.Subject | shellquote}}
+folder-opened = exec echo '{{.Folder}}'
+`))
+	assert.Nil(err)
+	Triggers = new(TriggersConfig)
+	Warnings = nil
+	assert.Nil(parseTriggers(file))
+
+	assert.Equal(Trigger{
+		Name: "new-email",
+		Command: []string{
+			"exec", "notify-send",
+			"New email from {{index (.From | names) 0}}",
+			"{{.ThreadPrefix}}{{.Subject}}",
+		},
+	}, Triggers.NewEmail)
+	assert.Len(Warnings, 1)
+	assert.Equal(Trigger{
+		Name:  "mail-sent",
+		Shell: "notify-send {{.Subject | shellquote}}",
+	}, Triggers.MailSent)
+	assert.Equal([]string{"exec", "echo", "{{.Folder}}"},
+		Triggers.FolderOpened.Command)
+	assert.False(Triggers.MailDeleted.IsSet())
+
+	_, err = parseTrigger("mail-moved", "!echo {{.Folder")
+	assert.NotNil(err)
+}
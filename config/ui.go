@@ -52,6 +52,7 @@ type UIConfig struct {
 	IconUnknown                   string        `ini:"icon-unknown" default:"[s?]"`
 	IconInvalid                   string        `ini:"icon-invalid" default:"[s!]"`
 	IconAttachment                string        `ini:"icon-attachment" default:"a"`
+	IconSenderWarning             string        `ini:"icon-sender-warning"`
 	DirListDelay                  time.Duration `ini:"dirlist-delay" default:"200ms"`
 	DirListTree                   bool          `ini:"dirlist-tree"`
 	DirListCollapse               int           `ini:"dirlist-collapse"`
@@ -102,11 +103,15 @@ type uiContextKey struct {
 
 const unreadExists string = `{{if .Unread}}{{humanReadable .Unread}}/{{end}}{{if .Exists}}{{humanReadable .Exists}}{{end}}`
 
-var Ui = &UIConfig{
-	contextualCounts: make(map[uiContextType]int),
-	contextualCache:  make(map[uiContextKey]*UIConfig),
+func defaultUiConfig() *UIConfig {
+	return &UIConfig{
+		contextualCounts: make(map[uiContextType]int),
+		contextualCache:  make(map[uiContextKey]*UIConfig),
+	}
 }
 
+var Ui = defaultUiConfig()
+
 func parseUi(file *ini.File) error {
 	if err := Ui.parse(file.Section("ui")); err != nil {
 		return err
@@ -488,6 +493,10 @@ func (uiConfig *UIConfig) GetStyle(so StyleObject) tcell.Style {
 	return uiConfig.style.Get(so)
 }
 
+func (uiConfig *UIConfig) GetViewerStyle(name string) tcell.Style {
+	return uiConfig.style.ViewerStyle(name)
+}
+
 func (uiConfig *UIConfig) GetStyleSelected(so StyleObject) tcell.Style {
 	return uiConfig.style.Selected(so)
 }
@@ -1,16 +1,22 @@
 package config
 
 import (
+	"fmt"
+
 	"git.sr.ht/~rjarry/aerc/log"
 	"github.com/go-ini/ini"
 )
 
 type ViewerConfig struct {
 	Pager          string     `ini:"pager" default:"less -R"`
+	BuiltinPager   bool       `ini:"builtin-pager"`
 	Alternatives   []string   `ini:"alternatives" default:"text/plain,text/html" delim:","`
 	ShowHeaders    bool       `ini:"show-headers"`
 	AlwaysShowMime bool       `ini:"always-show-mime"`
 	ParseHttpLinks bool       `ini:"parse-http-links" default:"true"`
+	StripTracking  bool       `ini:"strip-tracking-params"`
+	PrivacySummary bool       `ini:"privacy-summary" default:"true"`
+	ImageProtocol  string     `ini:"image-protocol" default:"auto"`
 	HeaderLayout   [][]string `ini:"header-layout" parse:"ParseLayout" default:"From|To,Cc|Bcc,Date,Subject"`
 	KeyPassthrough bool
 }
@@ -21,6 +27,12 @@ func parseViewer(file *ini.File) error {
 	if err := MapToStruct(file.Section("viewer"), Viewer, true); err != nil {
 		return err
 	}
+	switch Viewer.ImageProtocol {
+	case "auto", "kitty", "sixel", "halfblocks", "none":
+	default:
+		return fmt.Errorf("[viewer].image-protocol: invalid value %q",
+			Viewer.ImageProtocol)
+	}
 	log.Debugf("aerc.conf: [viewer] %#v", Viewer)
 	return nil
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"git.sr.ht/~sircmpwn/getopt"
+	"github.com/kyoh86/xdg"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/contacts"
+	"git.sr.ht/~rjarry/aerc/lib/mutt"
+)
+
+const importMuttUsage = `usage: aerc --import-mutt [-n <account-name>] [-o <dir>] <muttrc>`
+
+// importMuttMain converts a muttrc file to aerc configuration files and adds
+// its aliases to the contacts, or to a contacts file of the output directory
+// given with -o. The existing files are never overwritten. It returns the exit
+// status.
+func importMuttMain(args []string) int {
+	opts, optind, err := getopt.Getopts(args, "n:o:")
+	if err != nil || optind != len(args)-1 {
+		fmt.Fprintln(os.Stderr, importMuttUsage)
+		return 1
+	}
+	name := ""
+	dir := path.Join(xdg.ConfigHome(), "aerc")
+	contactsPath := contacts.DefaultPath()
+	for _, opt := range opts {
+		switch opt.Option {
+		case 'n':
+			name = opt.Value
+		case 'o':
+			dir = opt.Value
+			contactsPath = path.Join(dir, "contacts")
+		}
+	}
+	imp, err := mutt.Convert(args[optind], name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	files := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+	}{
+		{"accounts.conf", imp.AccountsConf(), 0o600},
+		{"binds.conf", imp.BindsConf(readTemplate("binds.conf")), 0o644},
+		{path.Join("stylesets", "mutt"), imp.StyleSet(readTemplate(path.Join("stylesets", "default"))), 0o644},
+	}
+	var existing []string
+	for _, f := range files {
+		if f.content == "" {
+			continue
+		}
+		if _, err := os.Stat(path.Join(dir, f.name)); !errors.Is(err, os.ErrNotExist) {
+			existing = append(existing, path.Join(dir, f.name))
+		}
+	}
+	if len(existing) > 0 {
+		for _, f := range existing {
+			fmt.Fprintf(os.Stderr, "%s: file exists\n", f)
+		}
+		fmt.Fprintln(os.Stderr, "Use -o to write the configuration to another directory.")
+		return 1
+	}
+	for _, f := range files {
+		if f.content == "" {
+			continue
+		}
+		filename := path.Join(dir, f.name)
+		err := os.MkdirAll(path.Dir(filename), 0o755)
+		if err == nil {
+			err = os.WriteFile(filename, []byte(f.content), f.mode)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Wrote %s\n", filename)
+	}
+
+	if len(imp.Aliases) > 0 {
+		book := contacts.NewBook(contactsPath)
+		if err := book.Load(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		added := book.Harvest(imp.Aliases)
+		if err := book.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Added %d of %d aliases to %s\n",
+			added, len(imp.Aliases), contactsPath)
+	}
+	if imp.AccountsConf() == "" {
+		fmt.Println("No account found, the muttrc does not set folder or spoolfile.")
+	}
+	if len(imp.Styles) > 0 || len(imp.ViewerStyles) > 0 {
+		fmt.Println("Set styleset-name=mutt in the [ui] section of aerc.conf to use the colors.")
+	}
+
+	if len(imp.Unmapped) > 0 {
+		fmt.Printf("\n%d setting(s) could not be imported:\n", len(imp.Unmapped))
+		for _, u := range imp.Unmapped {
+			fmt.Println(u)
+		}
+	}
+	if _, err := os.Stat(path.Join(dir, "aerc.conf")); err == nil {
+		problems := config.Check(&dir, commandExists)
+		if len(problems) > 0 {
+			fmt.Printf("\n%d configuration problem(s):\n", len(problems))
+			for _, p := range problems {
+				fmt.Println(p)
+			}
+		}
+	}
+	return 0
+}
+
+// readTemplate returns the content of a file of the configuration search
+// directories, or an empty string if it is not found.
+func readTemplate(name string) string {
+	for _, dir := range config.SearchDirs {
+		data, err := os.ReadFile(path.Join(dir, name))
+		if err == nil {
+			return string(data)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~sircmpwn/getopt"
+
+	"git.sr.ht/~rjarry/aerc/lib/ipc"
+)
+
+const jsonUsage = `usage: aerc --json accounts
+       aerc --json folders [-a <account>]
+       aerc --json messages [-a <account>] [-f <folder>] [-l <limit>]
+       aerc --json body [-a <account>] [-f <folder>] [-r] <uid>
+       aerc --json select [-a <account>] [-f <folder>] [<uid>]
+       aerc --json command <command> [<args>...]
+       aerc --json subscribe [<event>...]`
+
+// jsonMain sends a request of the JSON API to the running aerc instance and
+// prints its result on stdout. It returns the exit status.
+func jsonMain(args []string) int {
+	if len(args) > 0 && args[0] == ipc.MethodSubscribe {
+		err := ipc.Subscribe(args[1:], func(event json.RawMessage) {
+			fmt.Println(string(event))
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "aerc: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	params, err := parseJSONArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error: "+err.Error())
+		fmt.Fprintln(os.Stderr, jsonUsage)
+		return 1
+	}
+	result, err := ipc.Call(args[0], params)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "aerc: %v\n", err)
+		return 1
+	}
+	if len(result) > 0 {
+		fmt.Println(string(result))
+	}
+	return 0
+}
+
+func parseJSONArgs(args []string) (*ipc.Params, error) {
+	if len(args) == 0 {
+		return nil, errors.New("missing method")
+	}
+	var params ipc.Params
+	switch args[0] {
+	case ipc.MethodAccounts:
+		if len(args) > 1 {
+			return nil, errors.New("too many arguments")
+		}
+		return &params, nil
+	case ipc.MethodCommand:
+		if len(args) < 2 {
+			return nil, errors.New("missing command")
+		}
+		params.Command = args[1:]
+		return &params, nil
+	case ipc.MethodFolders, ipc.MethodMessages, ipc.MethodBody, ipc.MethodSelect:
+	default:
+		return nil, fmt.Errorf("unknown method %q", args[0])
+	}
+
+	opts, optind, err := getopt.Getopts(args, "a:f:l:r")
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		switch opt.Option {
+		case 'a':
+			params.Account = opt.Value
+		case 'f':
+			params.Folder = opt.Value
+		case 'l':
+			params.Limit, err = strconv.Atoi(opt.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid limit: %w", err)
+			}
+		case 'r':
+			params.Raw = true
+		}
+	}
+	allowed := map[string]string{
+		ipc.MethodFolders:  "a",
+		ipc.MethodMessages: "afl",
+		ipc.MethodBody:     "afr",
+		ipc.MethodSelect:   "af",
+	}
+	for _, opt := range opts {
+		if !strings.ContainsRune(allowed[args[0]], opt.Option) {
+			return nil, fmt.Errorf("%s: unexpected option -%c",
+				args[0], opt.Option)
+		}
+	}
+
+	rest := args[optind:]
+	switch {
+	case len(rest) > 1:
+		return nil, errors.New("too many arguments")
+	case len(rest) == 1 && (args[0] == ipc.MethodBody || args[0] == ipc.MethodSelect):
+		uid, err := strconv.ParseUint(rest[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uid: %w", err)
+		}
+		params.Uid = new(uint32)
+		*params.Uid = uint32(uid)
+	case len(rest) == 1:
+		return nil, errors.New("too many arguments")
+	case args[0] == ipc.MethodBody:
+		return nil, errors.New("missing uid")
+	}
+	return &params, nil
+}
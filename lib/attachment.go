@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"git.sr.ht/~rjarry/aerc/log"
+	"github.com/emersion/go-message"
 	"github.com/emersion/go-message/mail"
 	"github.com/pkg/errors"
 )
@@ -147,6 +148,58 @@ func (pa *PartAttachment) WriteTo(w *mail.Writer) error {
 	return nil
 }
 
+// DigestAttachment bundles several messages as message/rfc822 parts of a
+// single multipart/digest entity (RFC 2046, section 5.1.5).
+type DigestAttachment struct {
+	name     string
+	messages [][]byte
+}
+
+func NewDigestAttachment(name string, messages [][]byte) *DigestAttachment {
+	return &DigestAttachment{
+		name,
+		messages,
+	}
+}
+
+func (da *DigestAttachment) Name() string {
+	return da.name
+}
+
+func (da *DigestAttachment) WriteTo(w *mail.Writer) error {
+	ah := mail.AttachmentHeader{}
+	ah.SetContentType("multipart/digest", nil)
+	ah.SetFilename(da.Name())
+
+	aw, err := w.CreateAttachment(ah)
+	if err != nil {
+		return errors.Wrap(err, "CreateAttachment")
+	}
+	mw, ok := aw.(*message.Writer)
+	if !ok {
+		aw.Close()
+		return errors.New("cannot create multipart/digest writer")
+	}
+	for _, msg := range da.messages {
+		var h message.Header
+		h.SetContentType("message/rfc822", nil)
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			mw.Close()
+			return errors.Wrap(err, "CreatePart")
+		}
+		if _, err := pw.Write(msg); err != nil {
+			mw.Close()
+			return errors.Wrap(err, "Write")
+		}
+		if err := pw.Close(); err != nil {
+			mw.Close()
+			return errors.Wrap(err, "Close")
+		}
+	}
+	return mw.Close()
+}
+
 // SetUtf8Charset sets the charset in a params map to UTF-8.
 func SetUtf8Charset(origParams map[string]string) map[string]string {
 	params := make(map[string]string)
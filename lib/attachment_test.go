@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+)
+
+func TestDigestAttachment(t *testing.T) {
+	messages := [][]byte{
+		[]byte("Subject: first\r\n\r\nhello\r\n"),
+		[]byte("Subject: second\r\n\r\nworld\r\n"),
+	}
+	var h mail.Header
+	h.SetSubject("digest")
+	var buf bytes.Buffer
+	w, err := mail.CreateWriter(&buf, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewDigestAttachment("forwarded", messages).WriteTo(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	e, err := message.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part, err := e.MultipartReader().NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, _, _ := part.Header.ContentType()
+	if mediaType != "multipart/digest" {
+		t.Fatalf("expected multipart/digest, got %s", mediaType)
+	}
+	body, err := io.ReadAll(part.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range messages {
+		if !bytes.Contains(body, msg) {
+			t.Errorf("digest does not contain %q", msg)
+		}
+	}
+	if n := strings.Count(string(body), "Content-Type: message/rfc822"); n != 2 {
+		t.Errorf("expected 2 message/rfc822 parts, got %d", n)
+	}
+}
@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"git.sr.ht/~rjarry/aerc/lib/contacts"
+	"git.sr.ht/~rjarry/aerc/lib/parse"
+	"git.sr.ht/~rjarry/aerc/models"
+	"github.com/emersion/go-message/mail"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// SenderChecker looks for signs of sender spoofing in messages: display
+// names of known contacts used with other addresses, domains imitating
+// trusted domains, replies redirected to another domain and failed DMARC
+// checks. Trusted domains are the domains of the known contacts and of the
+// user's own addresses.
+type SenderChecker struct {
+	names          map[string][]*contacts.Contact
+	addrs          map[string]bool
+	domains        []string
+	trusted        map[string]bool
+	trustedAuthRes []string
+
+	mu    sync.Mutex
+	cache map[uint32]senderCheck
+}
+
+// senderCheck is the cached result of a message, only valid for the same
+// message info.
+type senderCheck struct {
+	info    *models.MessageInfo
+	reasons []string
+}
+
+// the cache is cleared when it holds that many messages
+const senderCacheSize = 4096
+
+// NewSenderChecker creates a checker trusting the given contacts and own
+// addresses. trustedAuthRes are the authentication servers whose
+// Authentication-Results headers are trusted.
+func NewSenderChecker(
+	known []*contacts.Contact, own []string, trustedAuthRes []string,
+) *SenderChecker {
+	c := &SenderChecker{
+		names:          make(map[string][]*contacts.Contact),
+		addrs:          make(map[string]bool),
+		trusted:        make(map[string]bool),
+		trustedAuthRes: trustedAuthRes,
+		cache:          make(map[uint32]senderCheck),
+	}
+	trust := func(addr string) {
+		addr = strings.ToLower(addr)
+		c.addrs[addr] = true
+		if domain := parse.RegistrableDomain(addrDomain(addr)); domain != "" &&
+			!c.trusted[domain] {
+			c.trusted[domain] = true
+			c.domains = append(c.domains, domain)
+		}
+	}
+	for _, contact := range known {
+		if name := normalizeName(contact.Name); name != "" {
+			c.names[name] = append(c.names[name], contact)
+		}
+		for _, addr := range contact.Addresses {
+			trust(addr)
+		}
+	}
+	for _, addr := range own {
+		trust(addr)
+	}
+	sort.Strings(c.domains)
+	return c
+}
+
+// CheckMessage is Check with the envelope and headers of a message. The
+// result is cached until the message info is updated.
+func (c *SenderChecker) CheckMessage(msg *models.MessageInfo) []string {
+	if msg == nil || msg.Envelope == nil {
+		return nil
+	}
+	c.mu.Lock()
+	cached, ok := c.cache[msg.Uid]
+	c.mu.Unlock()
+	if ok && cached.info == msg {
+		return cached.reasons
+	}
+	reasons := c.Check(msg.Envelope.From, msg.Envelope.ReplyTo,
+		msg.RFC822Headers)
+	c.mu.Lock()
+	if len(c.cache) >= senderCacheSize {
+		c.cache = make(map[uint32]senderCheck)
+	}
+	c.cache[msg.Uid] = senderCheck{info: msg, reasons: reasons}
+	c.mu.Unlock()
+	return reasons
+}
+
+// Check returns the reasons why the sender of a message may not be who it
+// pretends to be. The header is optional and only used for the DMARC results
+// and to recognize mailing lists.
+func (c *SenderChecker) Check(
+	from, replyTo []*mail.Address, header *mail.Header,
+) []string {
+	list := header != nil &&
+		(header.Get("List-Id") != "" || header.Get("List-Unsubscribe") != "")
+	reasons := c.checkAddresses(from, replyTo, list)
+
+	if header != nil {
+		details, err := CreateParser(DMARC)(header, c.trustedAuthRes)
+		if err == nil {
+			for _, result := range details.Results {
+				if result == ResultFail {
+					reasons = append(reasons,
+						"DMARC authentication failed")
+					break
+				}
+			}
+		}
+	}
+	return reasons
+}
+
+var nameAddrRe = regexp.MustCompile(`[^\s<>@"']+@[^\s<>@"']+\.[\pL]{2,}`)
+
+func (c *SenderChecker) checkAddresses(
+	from, replyTo []*mail.Address, list bool,
+) []string {
+	var reasons []string
+	var fromDomain string
+
+	for _, addr := range from {
+		if addr == nil {
+			continue
+		}
+		address := strings.ToLower(addr.Address)
+		fromDomain = parse.RegistrableDomain(addrDomain(address))
+
+		if shown := nameAddrRe.FindString(addr.Name); shown != "" &&
+			!strings.EqualFold(shown, address) {
+			reasons = append(reasons, fmt.Sprintf(
+				"display name shows %s but the address is %s",
+				shown, addr.Address))
+		}
+
+		if known := c.names[normalizeName(addr.Name)]; len(known) > 0 &&
+			!c.addrs[address] {
+			has := false
+			for _, contact := range known {
+				has = has || contact.Has(address)
+			}
+			if !has {
+				reasons = append(reasons, fmt.Sprintf(
+					"%s is a known contact but not with address %s",
+					known[0].Name, addr.Address))
+			}
+		}
+
+		if fromDomain != "" && !c.trusted[fromDomain] {
+			for _, domain := range c.domains {
+				if lookalike(fromDomain, domain) {
+					reasons = append(reasons, fmt.Sprintf(
+						"%s looks like the trusted domain %s",
+						fromDomain, domain))
+					break
+				}
+			}
+		}
+	}
+
+	if list || fromDomain == "" {
+		// mailing lists legitimately redirect replies to the list
+		return reasons
+	}
+	for _, addr := range replyTo {
+		if addr == nil {
+			continue
+		}
+		address := strings.ToLower(addr.Address)
+		domain := parse.RegistrableDomain(addrDomain(address))
+		if domain != "" && domain != fromDomain && !c.addrs[address] {
+			reasons = append(reasons, fmt.Sprintf(
+				"replies go to %s, not to %s", addr.Address, fromDomain))
+		}
+	}
+	return reasons
+}
+
+func normalizeName(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), `"'`)
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func addrDomain(addr string) string {
+	if i := strings.LastIndexByte(addr, '@'); i >= 0 {
+		return strings.TrimSuffix(addr[i+1:], ".")
+	}
+	return ""
+}
+
+var asciiLookalikes = strings.NewReplacer(
+	"rn", "m", "vv", "w", "0", "o", "1", "l", "i", "l", "_", "-",
+)
+
+// lookalike returns true if domain a imitates domain b: they only differ by
+// lookalike characters, or by a single edit for long enough names.
+func lookalike(a, b string) bool {
+	if a == b {
+		return false
+	}
+	skeleton := func(domain string) string {
+		if u, err := idna.ToUnicode(domain); err == nil {
+			domain = u
+		}
+		return asciiLookalikes.Replace(parse.Skeleton(domain))
+	}
+	if skeleton(a) == skeleton(b) {
+		return true
+	}
+	labelA, tldA := splitTLD(a)
+	labelB, tldB := splitTLD(b)
+	if labelA == labelB {
+		// same name under another top level domain
+		return true
+	}
+	return len(labelB) >= 6 && tldA == tldB && editDistance(labelA, labelB) == 1
+}
+
+func splitTLD(domain string) (string, string) {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return strings.TrimSuffix(strings.TrimSuffix(domain, suffix), "."), suffix
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: the number of insertions, deletions, substitutions and transpositions
+// of adjacent characters needed to turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
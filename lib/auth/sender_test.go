@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rjarry/aerc/lib/contacts"
+	"git.sr.ht/~rjarry/aerc/models"
+	"github.com/emersion/go-message/mail"
+)
+
+func TestSenderChecker(t *testing.T) {
+	checker := NewSenderChecker([]*contacts.Contact{
+		{Name: "Jane Doe", Addresses: []string{"jane@example.com"}},
+		{Name: "Bob", Addresses: []string{"bob@paypal.com"}},
+	}, []string{"me@mycompany.org"}, nil)
+
+	tests := []struct {
+		from    string
+		replyTo string
+		list    bool
+		warning string
+	}{
+		{"Jane Doe <jane@example.com>", "", false, ""},
+		{"\"jane  doe\" <JANE@example.com>", "", false, ""},
+		{"Someone <someone@elsewhere.net>", "", false, ""},
+		{"Jane Doe <ceo.jane@gmail.com>", "", false, "known contact"},
+		{"\"jane@example.com\" <x@evil.net>", "", false, "display name shows jane@example.com"},
+		{"Support <support@paypa1.com>", "", false, "trusted domain paypal.com"},
+		{"Boss <boss@mycornpany.org>", "", false, "trusted domain mycompany.org"},
+		{"Boss <boss@mycompany.com>", "", false, "trusted domain mycompany.org"},
+		{"Boss <boss@mycompamy.org>", "", false, "trusted domain mycompany.org"},
+		{"Jane Doe <jane@example.com>", "jane@mail.example.com", false, ""},
+		{"Jane Doe <jane@example.com>", "jane@evil.net", false, "replies go to jane@evil.net"},
+		{"News <news@shop.net>", "info@mailer.net", true, ""},
+	}
+	for _, test := range tests {
+		from, err := mail.ParseAddressList(test.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var replyTo []*mail.Address
+		if test.replyTo != "" {
+			replyTo, err = mail.ParseAddressList(test.replyTo)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		var h mail.Header
+		if test.list {
+			h.Set("List-Id", "<news.shop.net>")
+		}
+		reasons := checker.Check(from, replyTo, &h)
+		joined := strings.Join(reasons, "; ")
+		switch {
+		case test.warning == "" && len(reasons) > 0:
+			t.Errorf("%s: unexpected warning: %s", test.from, joined)
+		case test.warning != "" && !strings.Contains(joined, test.warning):
+			t.Errorf("%s: expected %q, got %q", test.from, test.warning, joined)
+		}
+	}
+}
+
+func TestSenderCheckerDMARC(t *testing.T) {
+	checker := NewSenderChecker(nil, nil, []string{"mx.example.net"})
+	var h mail.Header
+	h.Set(AuthHeader, "mx.example.net; dmarc=fail header.from=example.com")
+	from := []*mail.Address{{Address: "x@example.com"}}
+	reasons := checker.Check(from, nil, &h)
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "DMARC") {
+		t.Errorf("expected DMARC failure, got %v", reasons)
+	}
+}
+
+func TestSenderCheckerCache(t *testing.T) {
+	checker := NewSenderChecker(nil, []string{"me@paypal.com"}, nil)
+	msg := &models.MessageInfo{Uid: 1, Envelope: &models.Envelope{
+		From: []*mail.Address{{Address: "support@paypa1.com"}},
+	}}
+	if reasons := checker.CheckMessage(msg); len(reasons) != 1 {
+		t.Fatalf("expected a warning, got %v", reasons)
+	}
+	// same uid in another folder
+	other := &models.MessageInfo{Uid: 1, Envelope: &models.Envelope{
+		From: []*mail.Address{{Address: "support@paypal.com"}},
+	}}
+	if reasons := checker.CheckMessage(other); len(reasons) != 0 {
+		t.Errorf("unexpected warning from the cache: %v", reasons)
+	}
+	for uid := uint32(2); uid <= senderCacheSize+1; uid++ {
+		checker.CheckMessage(&models.MessageInfo{Uid: uid, Envelope: msg.Envelope})
+	}
+	if len(checker.cache) > senderCacheSize {
+		t.Errorf("cache holds %d messages", len(checker.cache))
+	}
+}
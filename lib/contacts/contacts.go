@@ -0,0 +1,286 @@
+package contacts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kyoh86/xdg"
+)
+
+// A Contact is a person known under a display name and one or more email
+// addresses.
+type Contact struct {
+	Name      string
+	Addresses []string
+}
+
+// String returns the contact formatted as a RFC 5322 address using its first
+// email address.
+func (c *Contact) String() string {
+	if len(c.Addresses) == 0 {
+		return c.Name
+	}
+	addr := mail.Address{Name: c.Name, Address: c.Addresses[0]}
+	return addr.String()
+}
+
+// Title returns the name of the contact or its first address if no name is
+// known.
+func (c *Contact) Title() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if len(c.Addresses) > 0 {
+		return c.Addresses[0]
+	}
+	return ""
+}
+
+// Has returns true if addr is one of the contact's addresses.
+func (c *Contact) Has(addr string) bool {
+	for _, a := range c.Addresses {
+		if strings.EqualFold(a, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// A Book is a set of contacts persisted to a plain text file. Each line of
+// the file holds one contact: its name followed by its addresses, all
+// separated by tab characters.
+type Book struct {
+	mu       sync.Mutex
+	path     string
+	contacts []*Contact
+}
+
+// DefaultPath returns the location of the contacts file in the XDG data
+// directory.
+func DefaultPath() string {
+	return path.Join(xdg.DataHome(), "aerc", "contacts")
+}
+
+// NewBook creates an empty book stored at the given path.
+func NewBook(path string) *Book {
+	return &Book{path: path}
+}
+
+// Load reads the contacts file. A missing file is not an error.
+func (b *Book) Load() error {
+	f, err := os.Open(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.Read(f)
+}
+
+// Read parses contacts from r and adds them to the book.
+func (b *Book) Read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		var c *Contact
+		for _, addr := range fields[1:] {
+			addr = strings.TrimSpace(addr)
+			if addr == "" {
+				continue
+			}
+			if c == nil {
+				c = b.Add(fields[0], addr)
+			} else if other := b.Lookup(addr); other == nil {
+				b.mu.Lock()
+				c.Addresses = append(c.Addresses, addr)
+				b.mu.Unlock()
+			} else if other != c {
+				b.Merge(c, other)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// ReadAddressBook adds contacts from the output of an address book command.
+// Each line must contain an email address optionally followed by a tab
+// character and the contact name. Additional fields are ignored.
+func (b *Book) ReadAddressBook(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		addr, err := mail.ParseAddress(strings.TrimSpace(fields[0]))
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 {
+			addr.Name = strings.TrimSpace(fields[1])
+		}
+		b.Add(addr.Name, addr.Address)
+	}
+	return scanner.Err()
+}
+
+// Save writes all contacts to the book file.
+func (b *Book) Save() error {
+	if err := os.MkdirAll(path.Dir(b.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(b.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.Write(f)
+}
+
+// Write serializes all contacts to w.
+func (b *Book) Write(w io.Writer) error {
+	for _, c := range b.Contacts() {
+		fields := append([]string{c.Name}, c.Addresses...)
+		if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Contacts returns all contacts sorted by name.
+func (b *Book) Contacts() []*Contact {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	contacts := make([]*Contact, len(b.contacts))
+	copy(contacts, b.contacts)
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return strings.ToLower(contacts[i].Title()) <
+			strings.ToLower(contacts[j].Title())
+	})
+	return contacts
+}
+
+// Lookup returns the contact owning addr or nil.
+func (b *Book) Lookup(addr string) *Contact {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lookup(addr)
+}
+
+func (b *Book) lookup(addr string) *Contact {
+	for _, c := range b.contacts {
+		if c.Has(addr) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Add records addr under the given name. If the address is already known,
+// the existing contact is returned and its name is only set if it was empty.
+func (b *Book) Add(name, addr string) *Contact {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	name = strings.TrimSpace(name)
+	if c := b.lookup(addr); c != nil {
+		if c.Name == "" {
+			c.Name = name
+		}
+		return c
+	}
+	c := &Contact{Name: name, Addresses: []string{addr}}
+	b.contacts = append(b.contacts, c)
+	return c
+}
+
+// Harvest adds all given addresses to the book. It returns the number of new
+// contacts.
+func (b *Book) Harvest(addrs []*mail.Address) int {
+	n := 0
+	for _, addr := range addrs {
+		if addr == nil || addr.Address == "" {
+			continue
+		}
+		if b.Lookup(addr.Address) == nil {
+			n++
+		}
+		b.Add(addr.Name, addr.Address)
+	}
+	return n
+}
+
+// Merge moves all addresses of src into dst and removes src from the book.
+func (b *Book) Merge(dst, src *Contact) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if dst == src {
+		return
+	}
+	for _, addr := range src.Addresses {
+		if !dst.Has(addr) {
+			dst.Addresses = append(dst.Addresses, addr)
+		}
+	}
+	if dst.Name == "" {
+		dst.Name = src.Name
+	}
+	b.remove(src)
+}
+
+// Edit renames a contact when name is not empty and adds or removes
+// addresses. The contact is deleted from the book when it has no addresses
+// left.
+func (b *Book) Edit(c *Contact, name string, add, remove []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, addr := range add {
+		if other := b.lookup(addr); other != nil && other != c {
+			return fmt.Errorf("%s belongs to %s", addr, other.Title())
+		}
+	}
+	if name != "" {
+		c.Name = name
+	}
+	for _, addr := range add {
+		if !c.Has(addr) {
+			c.Addresses = append(c.Addresses, addr)
+		}
+	}
+	for _, addr := range remove {
+		for i, a := range c.Addresses {
+			if strings.EqualFold(a, addr) {
+				c.Addresses = append(c.Addresses[:i], c.Addresses[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(c.Addresses) == 0 {
+		b.remove(c)
+	}
+	return nil
+}
+
+// Remove deletes a contact from the book.
+func (b *Book) Remove(c *Contact) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(c)
+}
+
+func (b *Book) remove(c *Contact) {
+	for i, other := range b.contacts {
+		if other == c {
+			b.contacts = append(b.contacts[:i], b.contacts[i+1:]...)
+			return
+		}
+	}
+}
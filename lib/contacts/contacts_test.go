@@ -0,0 +1,103 @@
+package contacts
+
+import (
+	"bytes"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBook_ReadWrite(t *testing.T) {
+	input := "John Doe\tjohn@example.com\tjd@work.example.com\n" +
+		"\talice@example.com\n" +
+		"garbage\n"
+	b := NewBook("")
+	if err := b.Read(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	contacts := b.Contacts()
+	if len(contacts) != 2 {
+		t.Fatalf("expected 2 contacts, got %d", len(contacts))
+	}
+	if contacts[0].Title() != "alice@example.com" {
+		t.Errorf("unexpected first contact: %q", contacts[0].Title())
+	}
+	if c := b.Lookup("JD@work.example.com"); c == nil || c.Name != "John Doe" {
+		t.Errorf("lookup failed: %v", c)
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "\talice@example.com\n" +
+		"John Doe\tjohn@example.com\tjd@work.example.com\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestBook_ReadAddressBook(t *testing.T) {
+	b := NewBook("")
+	b.Add("", "bob@example.com")
+	err := b.ReadAddressBook(strings.NewReader(
+		"bob@example.com\tBob\textra\nnot an address\ncarol@example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := b.Lookup("bob@example.com"); c == nil || c.Name != "Bob" {
+		t.Errorf("name was not filled: %v", c)
+	}
+	if len(b.Contacts()) != 2 {
+		t.Errorf("expected 2 contacts, got %d", len(b.Contacts()))
+	}
+}
+
+func TestBook_HarvestMerge(t *testing.T) {
+	b := NewBook("")
+	n := b.Harvest([]*mail.Address{
+		{Name: "Bob", Address: "bob@example.com"},
+		{Name: "Robert", Address: "robert@example.org"},
+		{Name: "Bob", Address: "bob@example.com"},
+		nil,
+	})
+	if n != 2 {
+		t.Errorf("expected 2 new contacts, got %d", n)
+	}
+	bob := b.Lookup("bob@example.com")
+	b.Merge(bob, b.Lookup("robert@example.org"))
+	contacts := b.Contacts()
+	if len(contacts) != 1 {
+		t.Fatalf("expected 1 contact after merge, got %d", len(contacts))
+	}
+	if !contacts[0].Has("robert@example.org") || contacts[0].Name != "Bob" {
+		t.Errorf("unexpected merged contact: %#v", contacts[0])
+	}
+	if contacts[0].String() != `"Bob" <bob@example.com>` {
+		t.Errorf("unexpected string: %s", contacts[0].String())
+	}
+}
+
+func TestBook_Edit(t *testing.T) {
+	b := NewBook("")
+	bob := b.Add("Bob", "bob@example.com")
+	b.Add("Alice", "alice@example.com")
+	if err := b.Edit(bob, "", []string{"alice@example.com"}, nil); err == nil {
+		t.Error("expected an error when adding another contact's address")
+	}
+	err := b.Edit(bob, "Robert", []string{"robert@example.org"},
+		[]string{"BOB@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Name != "Robert" || len(bob.Addresses) != 1 ||
+		bob.Addresses[0] != "robert@example.org" {
+		t.Errorf("unexpected contact: %#v", bob)
+	}
+	if err := b.Edit(bob, "", nil, []string{"robert@example.org"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Contacts()) != 1 {
+		t.Errorf("contact without addresses not removed: %v", b.Contacts())
+	}
+}
@@ -0,0 +1,134 @@
+// Package graphics displays images in terminals, either with the sixel or
+// kitty graphics protocols or with Unicode half-block characters.
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"os"
+	"strings"
+
+	// register the supported image formats
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/sys/unix"
+)
+
+// Protocol is a way of drawing images in a terminal.
+type Protocol string
+
+const (
+	HalfBlocks Protocol = "halfblocks"
+	Sixel      Protocol = "sixel"
+	Kitty      Protocol = "kitty"
+)
+
+// Detect guesses the best protocol supported by the terminal from the
+// environment. Terminal multiplexers do not forward graphics reliably,
+// half-blocks are used inside them.
+func Detect() Protocol {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "screen"):
+		return HalfBlocks
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" ||
+		term == "xterm-ghostty" || program == "ghostty" ||
+		program == "WezTerm":
+		return Kitty
+	case strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm") ||
+		strings.HasPrefix(term, "contour") || program == "iTerm.app" ||
+		os.Getenv("WT_SESSION") != "":
+		return Sixel
+	}
+	return HalfBlocks
+}
+
+// Supported returns true if images of the given MIME type can be decoded.
+func Supported(mime string) bool {
+	switch strings.ToLower(mime) {
+	case "image/png", "image/jpeg", "image/jpg", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Decode reads a PNG, JPEG or GIF image.
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// CellSize returns the size in pixels of a terminal cell. It falls back to
+// 8x16 when the terminal does not report its size in pixels.
+func CellSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return 8, 16
+	}
+	return int(ws.Xpixel / ws.Col), int(ws.Ypixel / ws.Row)
+}
+
+// Fit returns the largest size with the aspect ratio of width x height that
+// fits in maxWidth x maxHeight. Images are never enlarged.
+func Fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= 0 || height <= 0 || maxWidth <= 0 || maxHeight <= 0 {
+		return 0, 0
+	}
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	w, h := maxWidth, height*maxWidth/width
+	if h > maxHeight {
+		w, h = width*maxHeight/height, maxHeight
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// Scale resizes an image to width x height by averaging the source pixels
+// covered by each destination pixel. Transparent pixels are blended with
+// the background color.
+func Scale(img image.Image, width, height int, background color.Color) *image.RGBA {
+	src := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := src.RGBAAt(sx, sy)
+					r += uint32(c.R)
+					g += uint32(c.G)
+					b += uint32(c.B)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 0xff,
+			})
+		}
+	}
+	return dst
+}
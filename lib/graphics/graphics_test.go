@@ -0,0 +1,164 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	red   = color.RGBA{R: 0xff, A: 0xff}
+	blue  = color.RGBA{B: 0xff, A: 0xff}
+	white = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+func solid(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		w, h, maxW, maxH int
+		ew, eh           int
+	}{
+		{100, 50, 200, 200, 100, 50},
+		{400, 200, 200, 200, 200, 100},
+		{200, 400, 200, 200, 100, 200},
+		{1000, 1, 10, 10, 10, 1},
+		{10, 10, 0, 10, 0, 0},
+	}
+	for _, test := range tests {
+		w, h := Fit(test.w, test.h, test.maxW, test.maxH)
+		if w != test.ew || h != test.eh {
+			t.Errorf("Fit(%d, %d, %d, %d) = %d, %d, expected %d, %d",
+				test.w, test.h, test.maxW, test.maxH, w, h, test.ew, test.eh)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	img := solid(4, 4, red)
+	for x := 2; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			img.Set(x, y, blue)
+		}
+	}
+	// transparent pixel blended with the background
+	img.Set(0, 0, color.RGBA{})
+	scaled := Scale(img, 2, 2, white)
+	if c := scaled.RGBAAt(1, 1); c != blue {
+		t.Errorf("expected blue, got %v", c)
+	}
+	if c := scaled.RGBAAt(0, 1); c != red {
+		t.Errorf("expected red, got %v", c)
+	}
+	expected := color.RGBA{R: 0xff, G: 0x3f, B: 0x3f, A: 0xff}
+	if c := scaled.RGBAAt(0, 0); c != expected {
+		t.Errorf("expected %v, got %v", expected, c)
+	}
+}
+
+func TestEncodeHalfBlocks(t *testing.T) {
+	img := solid(2, 4, red)
+	img.Set(1, 1, blue)
+	img.Set(0, 2, blue)
+	cells := EncodeHalfBlocks(img, 2, 2, white)
+	expected := [][]Cell{
+		{{Top: red, Bottom: red}, {Top: red, Bottom: blue}},
+		{{Top: blue, Bottom: red}, {Top: red, Bottom: red}},
+	}
+	for y := range expected {
+		for x := range expected[y] {
+			if cells[y][x] != expected[y][x] {
+				t.Errorf("cell %d,%d: expected %v, got %v",
+					x, y, expected[y][x], cells[y][x])
+			}
+		}
+	}
+}
+
+func TestEncodeSixel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeSixel(&buf, solid(5, 8, red)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	re := regexp.MustCompile(`^\x1bPq"1;1;5;8#(\d+);2;100;0;0#(\d+)!5~-#(\d+)!5B\x1b\\$`)
+	m := re.FindStringSubmatch(out)
+	if m == nil {
+		t.Fatalf("unexpected sixel output: %q", out)
+	}
+	if m[1] != m[2] || m[2] != m[3] {
+		t.Errorf("inconsistent color registers: %q", out)
+	}
+}
+
+func TestEncodeSixelColors(t *testing.T) {
+	img := solid(2, 1, red)
+	img.Set(1, 0, blue)
+	var buf bytes.Buffer
+	if err := EncodeSixel(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	// one run per color, separated by a carriage return, the trailing
+	// empty sixel of red is omitted
+	re := regexp.MustCompile(`#\d+;2;0;0;100#\d+;2;100;0;0#\d+\?@\$#\d+@\x1b\\$`)
+	if !re.MatchString(out) {
+		t.Errorf("unexpected sixel output: %q", out)
+	}
+}
+
+func TestEncodeKitty(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	// noise does not compress, forcing several chunks
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	var buf bytes.Buffer
+	if err := EncodeKitty(&buf, img, 8, 4); err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`\x1b_G([^;]*);([^\x1b]*)\x1b\\`)
+	chunks := re.FindAllStringSubmatch(buf.String(), -1)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	var payload strings.Builder
+	for i, chunk := range chunks {
+		if len(chunk[2]) > kittyChunkSize {
+			t.Errorf("chunk %d too large: %d", i, len(chunk[2]))
+		}
+		switch {
+		case i == 0 && !strings.HasPrefix(chunk[1], "a=T,f=100,") ||
+			i == 0 && !strings.Contains(chunk[1], "c=8,r=4,m=1"):
+			t.Errorf("unexpected first chunk control data: %s", chunk[1])
+		case i > 0 && i < len(chunks)-1 && chunk[1] != "m=1":
+			t.Errorf("unexpected chunk %d control data: %s", i, chunk[1])
+		case i == len(chunks)-1 && chunk[1] != "m=0":
+			t.Errorf("unexpected last chunk control data: %s", chunk[1])
+		}
+		payload.WriteString(chunk[2])
+	}
+	data, err := base64.StdEncoding.DecodeString(payload.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Errorf("unexpected image size %v", decoded.Bounds())
+	}
+}
@@ -0,0 +1,35 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+)
+
+// A Cell is a terminal cell drawn with the upper half block character: the
+// foreground color is the top pixel and the background color the bottom
+// pixel.
+type Cell struct {
+	Top    color.RGBA
+	Bottom color.RGBA
+}
+
+// UpperHalfBlock is the character used to draw cells.
+const UpperHalfBlock = '▀'
+
+// EncodeHalfBlocks scales an image to cols x rows cells of two pixels each.
+func EncodeHalfBlocks(
+	img image.Image, cols, rows int, background color.Color,
+) [][]Cell {
+	scaled := Scale(img, cols, rows*2, background)
+	cells := make([][]Cell, rows)
+	for y := range cells {
+		cells[y] = make([]Cell, cols)
+		for x := range cells[y] {
+			cells[y][x] = Cell{
+				Top:    scaled.RGBAAt(x, 2*y),
+				Bottom: scaled.RGBAAt(x, 2*y+1),
+			}
+		}
+	}
+	return cells
+}
@@ -0,0 +1,50 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+)
+
+// kittyChunkSize is the maximum size of the base64 payload of each escape
+// sequence.
+const kittyChunkSize = 4096
+
+// EncodeKitty writes an image with the kitty graphics protocol, displayed
+// over cols x rows cells without moving the cursor.
+func EncodeKitty(w io.Writer, img image.Image, cols, rows int) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+	for first := true; first || payload != ""; first = false {
+		chunk := payload
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		var err error
+		if first {
+			_, err = fmt.Fprintf(w, "\x1b_Ga=T,f=100,t=d,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\",
+				cols, rows, more, chunk)
+		} else {
+			_, err = fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KittyClear is the escape sequence deleting all images displayed with the
+// kitty graphics protocol.
+const KittyClear = "\x1b_Ga=d,q=2\x1b\\"
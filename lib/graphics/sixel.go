@@ -0,0 +1,101 @@
+package graphics
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"io"
+)
+
+// EncodeSixel writes an image with the sixel protocol. The image is reduced
+// to a 256 colors palette with dithering. It should be scaled to its
+// display size first.
+func EncodeSixel(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "\x1bPq\"1;1;%d;%d", width, height)
+
+	used := make([]bool, len(paletted.Palette))
+	for _, i := range paletted.Pix {
+		used[i] = true
+	}
+	for i, c := range paletted.Palette {
+		if !used[i] {
+			continue
+		}
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(out, "#%d;2;%d;%d;%d", i,
+			percent(r), percent(g), percent(b))
+	}
+
+	sixels := make([]byte, width)
+	for y0 := 0; y0 < height; y0 += 6 {
+		if y0 > 0 {
+			out.WriteByte('-')
+		}
+		first := true
+		for i := range paletted.Palette {
+			if !used[i] || !sixelBand(paletted, uint8(i), y0, sixels) {
+				continue
+			}
+			if !first {
+				out.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(out, "#%d", i)
+			writeSixelRun(out, sixels)
+		}
+	}
+	out.WriteString("\x1b\\")
+	return out.Flush()
+}
+
+// sixelBand fills sixels with the pixels of color index i in the six rows
+// starting at y0. It returns false if there are none.
+func sixelBand(img *image.Paletted, i uint8, y0 int, sixels []byte) bool {
+	found := false
+	for x := range sixels {
+		var bits byte
+		for dy := 0; dy < 6 && y0+dy < img.Rect.Dy(); dy++ {
+			if img.ColorIndexAt(x, y0+dy) == i {
+				bits |= 1 << dy
+			}
+		}
+		sixels[x] = '?' + bits
+		found = found || bits != 0
+	}
+	return found
+}
+
+// writeSixelRun writes sixels with run-length encoding, omitting the
+// trailing empty sixels.
+func writeSixelRun(out *bufio.Writer, sixels []byte) {
+	end := len(sixels)
+	for end > 0 && sixels[end-1] == '?' {
+		end--
+	}
+	for x := 0; x < end; {
+		n := 1
+		for x+n < end && sixels[x+n] == sixels[x] {
+			n++
+		}
+		if n > 3 {
+			fmt.Fprintf(out, "!%d%c", n, sixels[x])
+		} else {
+			for j := 0; j < n; j++ {
+				out.WriteByte(sixels[x])
+			}
+		}
+		x += n
+	}
+}
+
+func percent(v uint32) uint32 {
+	return (v*100 + 0x7fff) / 0xffff
+}
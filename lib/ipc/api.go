@@ -0,0 +1,160 @@
+package ipc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/config"
+)
+
+// APIVersion is the version of the JSON API. It is increased when the
+// methods, their parameters or their results change in incompatible ways.
+const APIVersion = 1
+
+// Methods of the JSON API.
+const (
+	MethodAccounts = "accounts"
+	MethodFolders  = "folders"
+	MethodMessages = "messages"
+	MethodBody     = "body"
+	MethodCommand  = "command"
+	MethodSelect   = "select"
+)
+
+// Methods lists the methods of the JSON API.
+var Methods = []string{
+	MethodAccounts, MethodFolders, MethodMessages, MethodBody,
+	MethodCommand, MethodSelect, MethodSubscribe,
+}
+
+// API is implemented by the main aerc instance to answer JSON requests.
+type API interface {
+	Accounts() ([]Account, error)
+	Folders(params *Params) ([]Folder, error)
+	Messages(params *Params) ([]Message, error)
+	Body(params *Params) (*Body, error)
+	Execute(params *Params) (*CommandResult, error)
+	Select(params *Params) error
+}
+
+// Params are the parameters of all methods. The account and folder default
+// to the selected ones.
+type Params struct {
+	Account string   `json:"account,omitempty"`
+	Folder  string   `json:"folder,omitempty"`
+	Uid     *uint32  `json:"uid,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+	Raw     bool     `json:"raw,omitempty"`
+	Command []string `json:"command,omitempty"`
+	Events  []string `json:"events,omitempty"`
+}
+
+type Account struct {
+	Name      string `json:"name"`
+	Selected  bool   `json:"selected"`
+	Connected bool   `json:"connected"`
+	Folder    string `json:"folder"`
+}
+
+type Folder struct {
+	Name     string `json:"name"`
+	Selected bool   `json:"selected"`
+	Recent   int    `json:"recent"`
+	Unread   int    `json:"unread"`
+	Exists   int    `json:"exists"`
+}
+
+type Address struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+}
+
+type Message struct {
+	Uid       uint32    `json:"uid"`
+	Date      time.Time `json:"date"`
+	Subject   string    `json:"subject"`
+	From      []Address `json:"from"`
+	To        []Address `json:"to"`
+	Cc        []Address `json:"cc,omitempty"`
+	MessageId string    `json:"message_id"`
+	InReplyTo string    `json:"in_reply_to,omitempty"`
+	Flags     []string  `json:"flags"`
+	Labels    []string  `json:"labels,omitempty"`
+	Size      uint32    `json:"size,omitempty"`
+	Selected  bool      `json:"selected"`
+	Marked    bool      `json:"marked"`
+}
+
+type Body struct {
+	Uid      uint32 `json:"uid"`
+	MimeType string `json:"mime_type"`
+	Text     string `json:"text"`
+}
+
+// CommandResult contains the status messages pushed while the command was
+// executed.
+type CommandResult struct {
+	Messages []string `json:"messages"`
+}
+
+func (as *AercServer) handleAPI(req *Request) *Response {
+	if req.Version != APIVersion {
+		return apiError(fmt.Errorf("unsupported API version %d, expected %d",
+			req.Version, APIVersion))
+	}
+	if config.General.DisableIPC {
+		return apiError(fmt.Errorf("%s rejected: IPC is disabled", req.Method))
+	}
+	api, ok := as.handler.(API)
+	if !ok {
+		return apiError(fmt.Errorf("JSON API not available"))
+	}
+	var params Params
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return apiError(fmt.Errorf("invalid params: %w", err))
+		}
+	}
+
+	var result interface{}
+	var err error
+	switch req.Method {
+	case MethodAccounts:
+		result, err = api.Accounts()
+	case MethodFolders:
+		result, err = api.Folders(&params)
+	case MethodMessages:
+		result, err = api.Messages(&params)
+	case MethodBody:
+		if params.Uid == nil {
+			return apiError(fmt.Errorf("%s: missing uid", req.Method))
+		}
+		result, err = api.Body(&params)
+	case MethodCommand:
+		if len(params.Command) == 0 {
+			return apiError(fmt.Errorf("%s: missing command", req.Method))
+		}
+		result, err = api.Execute(&params)
+	case MethodSelect:
+		err = api.Select(&params)
+	default:
+		return apiError(fmt.Errorf("unknown method %q", req.Method))
+	}
+	if err != nil {
+		return apiError(err)
+	}
+
+	resp := &Response{Version: APIVersion}
+	if result != nil {
+		resp.Result, err = json.Marshal(result)
+		if err != nil {
+			return apiError(err)
+		}
+	}
+	return resp
+}
+
+func apiError(err error) *Response {
+	return &Response{Version: APIVersion, Error: err.Error()}
+}
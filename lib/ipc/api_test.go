@@ -0,0 +1,166 @@
+package ipc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"git.sr.ht/~rjarry/aerc/config"
+)
+
+type testHandler struct {
+	params *Params
+}
+
+func (h *testHandler) Mailto(*url.URL) error  { return nil }
+func (h *testHandler) Mbox(string) error      { return nil }
+func (h *testHandler) Command([]string) error { return nil }
+
+func (h *testHandler) Accounts() ([]Account, error) {
+	return []Account{{Name: "work", Selected: true, Folder: "INBOX"}}, nil
+}
+
+func (h *testHandler) Folders(p *Params) ([]Folder, error) {
+	h.params = p
+	return []Folder{{Name: "INBOX", Unread: 2, Exists: 5}}, nil
+}
+
+func (h *testHandler) Messages(p *Params) ([]Message, error) {
+	h.params = p
+	return nil, errors.New("folder Archive is not selected")
+}
+
+func (h *testHandler) Body(p *Params) (*Body, error) {
+	h.params = p
+	return &Body{Uid: *p.Uid, MimeType: "text/plain", Text: "hello"}, nil
+}
+
+func (h *testHandler) Execute(p *Params) (*CommandResult, error) {
+	h.params = p
+	return &CommandResult{Messages: []string{"done"}}, nil
+}
+
+func (h *testHandler) Select(p *Params) error {
+	h.params = p
+	return nil
+}
+
+func request(t *testing.T, method string, params interface{}) *Request {
+	t.Helper()
+	req := &Request{Version: APIVersion, Method: method}
+	if params != nil {
+		var err error
+		if req.Params, err = json.Marshal(params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return req
+}
+
+func TestHandleAPI(t *testing.T) {
+	h := &testHandler{}
+	as := &AercServer{handler: h}
+
+	resp := as.handleMessage(request(t, MethodAccounts, nil))
+	if resp.Error != "" || resp.Version != APIVersion {
+		t.Fatalf("unexpected response: %#v", resp)
+	}
+	var accounts []Account
+	if err := json.Unmarshal(resp.Result, &accounts); err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[0].Name != "work" {
+		t.Errorf("unexpected accounts: %#v", accounts)
+	}
+
+	resp = as.handleMessage(request(t, MethodFolders,
+		map[string]string{"account": "work"}))
+	if resp.Error != "" || h.params.Account != "work" {
+		t.Errorf("unexpected response: %#v, params %#v", resp, h.params)
+	}
+
+	resp = as.handleMessage(request(t, MethodMessages,
+		map[string]string{"folder": "Archive"}))
+	if resp.Error != "folder Archive is not selected" || resp.Result != nil {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+
+	resp = as.handleMessage(request(t, MethodBody, nil))
+	if resp.Error != "body: missing uid" {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+	resp = as.handleMessage(request(t, MethodBody,
+		map[string]interface{}{"uid": 42}))
+	var body Body
+	if err := json.Unmarshal(resp.Result, &body); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(body, Body{Uid: 42, MimeType: "text/plain", Text: "hello"}) {
+		t.Errorf("unexpected body: %#v", body)
+	}
+
+	resp = as.handleMessage(request(t, MethodCommand,
+		map[string][]string{"command": {":next", "1"}}))
+	if resp.Error != "" || string(resp.Result) != `{"messages":["done"]}` {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+	if !reflect.DeepEqual(h.params.Command, []string{":next", "1"}) {
+		t.Errorf("unexpected command: %#v", h.params.Command)
+	}
+
+	resp = as.handleMessage(request(t, MethodSelect, nil))
+	if resp.Error != "" || resp.Result != nil {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+
+	resp = as.handleMessage(request(t, "unknown", nil))
+	if resp.Error != `unknown method "unknown"` {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+
+	req := request(t, MethodAccounts, nil)
+	req.Version = APIVersion + 1
+	resp = as.handleMessage(req)
+	if resp.Error == "" {
+		t.Error("expected an error for an unsupported version")
+	}
+
+	config.General.DisableIPC = true
+	defer func() { config.General.DisableIPC = false }()
+	for _, method := range []string{MethodAccounts, MethodMessages, MethodBody} {
+		resp = as.handleMessage(request(t, method,
+			map[string]interface{}{"uid": 42}))
+		if resp.Error != method+" rejected: IPC is disabled" {
+			t.Errorf("unexpected response: %#v", resp)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	h := &testHandler{}
+	as, err := StartServer(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer as.Close()
+
+	result, err := Call(MethodFolders, &Params{Account: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var folders []Folder
+	if err := json.Unmarshal(result, &folders); err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 1 || folders[0].Unread != 2 || h.params.Account != "work" {
+		t.Errorf("unexpected folders: %#v", folders)
+	}
+
+	_, err = Call(MethodMessages, nil)
+	if err == nil || err.Error() != "folder Archive is not selected" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
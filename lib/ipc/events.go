@@ -0,0 +1,146 @@
+package ipc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"git.sr.ht/~rjarry/aerc/log"
+)
+
+// MethodSubscribe turns the connection into a stream of events. The params
+// list the event types, all events are sent when the list is empty.
+const MethodSubscribe = "subscribe"
+
+// Event types.
+const (
+	EventNewMail    = "new-mail"
+	EventUnread     = "unread"
+	EventFolder     = "folder"
+	EventMailSent   = "mail-sent"
+	EventSendFailed = "send-failed"
+	EventConnection = "connection"
+)
+
+// Events lists the event types that can be subscribed to.
+var Events = []string{
+	EventNewMail, EventUnread, EventFolder, EventMailSent, EventSendFailed,
+	EventConnection,
+}
+
+// Event is sent as a JSON line to the subscribed clients.
+type Event struct {
+	Event     string   `json:"event"`
+	Account   string   `json:"account,omitempty"`
+	Folder    string   `json:"folder,omitempty"`
+	Counts    *Counts  `json:"counts,omitempty"`
+	Message   *Message `json:"message,omitempty"`
+	Connected *bool    `json:"connected,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Counts are the message counts of a folder.
+type Counts struct {
+	Recent int `json:"recent"`
+	Unread int `json:"unread"`
+	Exists int `json:"exists"`
+}
+
+// Subscription is the result of the subscribe method.
+type Subscription struct {
+	Events []string `json:"events"`
+}
+
+// subscriberBuffer is the number of events queued for a client before new
+// events are dropped.
+const subscriberBuffer = 256
+
+type subscriber struct {
+	id     int64
+	events map[string]bool
+	queue  chan []byte
+}
+
+var (
+	subscribersLock sync.Mutex
+	subscribers     = make(map[*subscriber]bool)
+)
+
+// Subscribed returns true if a client is subscribed to the event type. It
+// avoids computing events that nobody receives.
+func Subscribed(event string) bool {
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+	for s := range subscribers {
+		if s.events[event] {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish sends an event to the subscribed clients without blocking. Events
+// are dropped for the clients that do not read them fast enough.
+func Publish(ev *Event) {
+	if !Subscribed(ev.Event) {
+		return
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Errorf("ipc: failed to encode event: %v", err)
+		return
+	}
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+	for s := range subscribers {
+		if !s.events[ev.Event] {
+			continue
+		}
+		select {
+		case s.queue <- data:
+		default:
+			log.Warnf("unix:%d event queue full, dropping %s", s.id, ev.Event)
+		}
+	}
+}
+
+func parseSubscription(params *Params) (map[string]bool, error) {
+	events := make(map[string]bool)
+	for _, ev := range params.Events {
+		known := false
+		for _, e := range Events {
+			if ev == e {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event %q", ev)
+		}
+		events[ev] = true
+	}
+	if len(events) == 0 {
+		for _, e := range Events {
+			events[e] = true
+		}
+	}
+	return events, nil
+}
+
+func subscribe(id int64, events map[string]bool) *subscriber {
+	s := &subscriber{
+		id:     id,
+		events: events,
+		queue:  make(chan []byte, subscriberBuffer),
+	}
+	subscribersLock.Lock()
+	subscribers[s] = true
+	subscribersLock.Unlock()
+	return s
+}
+
+func unsubscribe(s *subscriber) {
+	subscribersLock.Lock()
+	delete(subscribers, s)
+	subscribersLock.Unlock()
+}
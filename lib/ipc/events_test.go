@@ -0,0 +1,69 @@
+package ipc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	as, err := StartServer(&testHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer as.Close()
+
+	if err := Subscribe([]string{"unknown"}, nil); err == nil ||
+		err.Error() != `unknown event "unknown"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	events := make(chan Event)
+	go func() {
+		_ = Subscribe([]string{EventUnread}, func(data json.RawMessage) {
+			var ev Event
+			if err := json.Unmarshal(data, &ev); err != nil {
+				t.Error(err)
+			}
+			events <- ev
+		})
+		close(events)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for !Subscribed(EventUnread) {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if Subscribed(EventNewMail) {
+		t.Error("unexpected subscription to new-mail")
+	}
+
+	// not subscribed, dropped
+	Publish(&Event{Event: EventNewMail, Account: "work"})
+	Publish(&Event{
+		Event:   EventUnread,
+		Account: "work",
+		Folder:  "INBOX",
+		Counts:  &Counts{Unread: 3, Exists: 10},
+	})
+	select {
+	case ev := <-events:
+		if ev.Event != EventUnread || ev.Folder != "INBOX" ||
+			ev.Counts == nil || ev.Counts.Unread != 3 {
+			t.Errorf("unexpected event: %#v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	// closing the server ends the stream
+	as.Close()
+	for range events {
+	}
+	if Subscribed(EventUnread) {
+		t.Error("subscription not removed")
+	}
+}
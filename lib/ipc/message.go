@@ -8,6 +8,12 @@ type Request struct {
 	// Arguments contains the commandline arguments. The detection of what
 	// action to take is left to the receiver.
 	Arguments []string `json:"arguments"`
+
+	// Version is set for requests of the JSON API. Method and Params are
+	// used instead of Arguments.
+	Version int             `json:"version,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
 }
 
 // Response is used to report the results of a command.
@@ -15,6 +21,10 @@ type Response struct {
 	// Error contains the success-state of the command. Error is an empty
 	// string if everything ran successfully.
 	Error string `json:"error"`
+
+	// Version and Result are only set in responses of the JSON API.
+	Version int             `json:"version,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
 }
 
 // Encode transforms the message in an easier to transfer format
@@ -2,12 +2,15 @@ package ipc
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/url"
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -19,6 +22,8 @@ import (
 type AercServer struct {
 	listener net.Listener
 	handler  Handler
+	closed   chan struct{}
+	close    sync.Once
 }
 
 func StartServer(handler Handler) (*AercServer, error) {
@@ -32,14 +37,21 @@ func StartServer(handler Handler) (*AercServer, error) {
 	if err != nil {
 		return nil, err
 	}
-	as := &AercServer{listener: l, handler: handler}
+	as := &AercServer{
+		listener: l,
+		handler:  handler,
+		closed:   make(chan struct{}),
+	}
 	go as.Serve()
 
 	return as, nil
 }
 
 func (as *AercServer) Close() {
-	as.listener.Close()
+	as.close.Do(func() {
+		as.listener.Close()
+		close(as.closed)
+	})
 }
 
 var lastId int64 = 0 // access via atomic
@@ -57,45 +69,127 @@ func (as *AercServer) Serve() {
 			log.Errorf("ipc: accepting connection failed: %v", err)
 			continue
 		}
+		go as.handleConn(conn)
+	}
+}
 
-		defer conn.Close()
-		clientId := atomic.AddInt64(&lastId, 1)
-		log.Debugf("unix:%d accepted connection", clientId)
-		scanner := bufio.NewScanner(conn)
+func (as *AercServer) handleConn(conn net.Conn) {
+	defer log.PanicHandler()
+	defer conn.Close()
+
+	clientId := atomic.AddInt64(&lastId, 1)
+	log.Debugf("unix:%d accepted connection", clientId)
+	scanner := bufio.NewScanner(conn)
+	err := conn.SetDeadline(time.Now().Add(1 * time.Minute))
+	if err != nil {
+		log.Errorf("unix:%d failed to set deadline: %v", clientId, err)
+	}
+	for scanner.Scan() {
+		// allow up to 1 minute between commands
 		err = conn.SetDeadline(time.Now().Add(1 * time.Minute))
 		if err != nil {
-			log.Errorf("unix:%d failed to set deadline: %v", clientId, err)
+			log.Errorf("unix:%d failed to update deadline: %v", clientId, err)
+		}
+		msg, err := DecodeRequest(scanner.Bytes())
+		log.Tracef("unix:%d got message %s", clientId, scanner.Text())
+		if err != nil {
+			log.Errorf("unix:%d failed to parse request: %v", clientId, err)
+			continue
 		}
-		for scanner.Scan() {
-			// allow up to 1 minute between commands
-			err = conn.SetDeadline(time.Now().Add(1 * time.Minute))
-			if err != nil {
-				log.Errorf("unix:%d failed to update deadline: %v", clientId, err)
-			}
-			msg, err := DecodeRequest(scanner.Bytes())
-			log.Tracef("unix:%d got message %s", clientId, scanner.Text())
-			if err != nil {
-				log.Errorf("unix:%d failed to parse request: %v", clientId, err)
-				continue
-			}
 
-			response := as.handleMessage(msg)
-			result, err := response.Encode()
-			if err != nil {
-				log.Errorf("unix:%d failed to encode result: %v", clientId, err)
-				continue
-			}
-			_, err = conn.Write(append(result, '\n'))
-			if err != nil {
-				log.Errorf("unix:%d failed to send response: %v", clientId, err)
-				break
+		if msg.Method == MethodSubscribe {
+			as.stream(clientId, conn, scanner, msg)
+			break
+		}
+		response := as.handleMessage(msg)
+		if err := writeResponse(conn, response); err != nil {
+			log.Errorf("unix:%d failed to send response: %v", clientId, err)
+			break
+		}
+	}
+	log.Tracef("unix:%d closed connection", clientId)
+}
+
+func writeResponse(conn net.Conn, response *Response) error {
+	result, err := response.Encode()
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(append(result, '\n'))
+	return err
+}
+
+// stream answers a subscribe request and sends the events to the client
+// until it closes the connection.
+func (as *AercServer) stream(
+	clientId int64, conn net.Conn, scanner *bufio.Scanner, req *Request,
+) {
+	var params Params
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			_ = writeResponse(conn, apiError(fmt.Errorf("invalid params: %w", err)))
+			return
+		}
+	}
+	events, err := parseSubscription(&params)
+	if err == nil && req.Version != APIVersion {
+		err = fmt.Errorf("unsupported API version %d, expected %d",
+			req.Version, APIVersion)
+	}
+	if err == nil && config.General.DisableIPC {
+		err = fmt.Errorf("%s rejected: IPC is disabled", req.Method)
+	}
+	if err != nil {
+		_ = writeResponse(conn, apiError(err))
+		return
+	}
+	var sub Subscription
+	for _, e := range Events {
+		if events[e] {
+			sub.Events = append(sub.Events, e)
+		}
+	}
+	resp := &Response{Version: APIVersion}
+	resp.Result, _ = json.Marshal(&sub)
+
+	s := subscribe(clientId, events)
+	defer unsubscribe(s)
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		log.Errorf("unix:%d failed to clear deadline: %v", clientId, err)
+	}
+	if err := writeResponse(conn, resp); err != nil {
+		log.Errorf("unix:%d failed to send response: %v", clientId, err)
+		return
+	}
+	log.Debugf("unix:%d subscribed to %v", clientId, sub.Events)
+
+	// the client does not send anything else, wait until it disconnects
+	gone := make(chan struct{})
+	go func() {
+		defer log.PanicHandler()
+		for scanner.Scan() {
+		}
+		close(gone)
+	}()
+	for {
+		select {
+		case data := <-s.queue:
+			if _, err := conn.Write(append(data, '\n')); err != nil {
+				log.Errorf("unix:%d failed to send event: %v", clientId, err)
+				return
 			}
+		case <-gone:
+			return
+		case <-as.closed:
+			return
 		}
-		log.Tracef("unix:%d closed connection", clientId)
 	}
 }
 
 func (as *AercServer) handleMessage(req *Request) *Response {
+	if req.Version != 0 || req.Method != "" {
+		return as.handleAPI(req)
+	}
 	if len(req.Arguments) == 0 {
 		return &Response{} // send noop success message, i.e. ping
 	}
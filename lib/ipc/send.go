@@ -2,6 +2,7 @@ package ipc
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -11,35 +12,105 @@ import (
 )
 
 func ConnectAndExec(args []string) error {
-	sockpath := path.Join(xdg.RuntimeDir(), "aerc.sock")
-	conn, err := net.Dial("unix", sockpath)
+	resp, err := send(&Request{Arguments: args})
+	if err != nil {
+		return err
+	}
+
+	// TODO: handle this in a more elegant manner
+	if resp.Error != "" {
+		fmt.Println("result: ", resp.Error)
+	}
+
+	return nil
+}
+
+// Call sends a request of the JSON API to the running aerc instance and
+// returns the raw result of the method.
+func Call(method string, params *Params) (json.RawMessage, error) {
+	req := &Request{Version: APIVersion, Method: method}
+	if params != nil {
+		var err error
+		req.Params, err = json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode params: %w", err)
+		}
+	}
+	resp, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// Subscribe subscribes to events of the running aerc instance and calls fn
+// with each event until the connection is closed.
+func Subscribe(events []string, fn func(json.RawMessage)) error {
+	params, err := json.Marshal(&Params{Events: events})
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+	conn, scanner, resp, err := roundTrip(&Request{
+		Version: APIVersion,
+		Method:  MethodSubscribe,
+		Params:  params,
+	})
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	for scanner.Scan() {
+		fn(json.RawMessage(scanner.Bytes()))
+	}
+	return scanner.Err()
+}
+
+func send(req *Request) (*Response, error) {
+	conn, _, resp, err := roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return resp, nil
+}
+
+// roundTrip sends a request and reads the response. The connection is left
+// open to read further data.
+func roundTrip(req *Request) (net.Conn, *bufio.Scanner, *Response, error) {
+	sockpath := path.Join(xdg.RuntimeDir(), "aerc.sock")
+	conn, err := net.Dial("unix", sockpath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-	req, err := (&Request{Arguments: args}).Encode()
+	data, err := req.Encode()
 	if err != nil {
-		return fmt.Errorf("failed to encode request: %w", err)
+		conn.Close()
+		return nil, nil, nil, fmt.Errorf("failed to encode request: %w", err)
 	}
 
-	_, err = conn.Write(append(req, '\n'))
+	_, err = conn.Write(append(data, '\n'))
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		conn.Close()
+		return nil, nil, nil, fmt.Errorf("failed to send message: %w", err)
 	}
 	scanner := bufio.NewScanner(conn)
+	// message bodies may exceed the default token size
+	scanner.Buffer(nil, 64<<20)
 	if !scanner.Scan() {
-		return errors.New("No response from server")
+		conn.Close()
+		return nil, nil, nil, errors.New("No response from server")
 	}
 	resp, err := DecodeResponse(scanner.Bytes())
 	if err != nil {
-		return err
+		conn.Close()
+		return nil, nil, nil, err
 	}
-
-	// TODO: handle this in a more elegant manner
-	if resp.Error != "" {
-		fmt.Println("result: ", resp.Error)
-	}
-
-	return nil
+	return conn, scanner, resp, nil
 }
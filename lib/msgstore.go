@@ -1,12 +1,14 @@
 package lib
 
 import (
+	"errors"
 	"io"
 	"sync"
 	"time"
 
 	"git.sr.ht/~rjarry/aerc/lib/iterator"
 	"git.sr.ht/~rjarry/aerc/lib/marker"
+	"git.sr.ht/~rjarry/aerc/lib/mute"
 	"git.sr.ht/~rjarry/aerc/lib/sort"
 	"git.sr.ht/~rjarry/aerc/lib/ui"
 	"git.sr.ht/~rjarry/aerc/log"
@@ -60,12 +62,26 @@ type MessageStore struct {
 
 	triggerNewEmail        func(*models.MessageInfo)
 	triggerDirectoryChange func()
+	events                 StoreEvents
 
 	threadBuilderDebounce *time.Timer
 	threadBuilderDelay    time.Duration
 	threadCallback        func()
 
-	// threads mutex protects the store.threads and store.threadCallback
+	// uids of the folded threads
+	folded map[uint32]bool
+
+	// muted threads
+	muted       *mute.List
+	onMuted     func(*models.MessageInfo)
+	mutedUids   map[uint32]bool
+	showMuted   bool
+	visibleUids []uint32
+	// muted messages to hide at the next rebuild of the server threads
+	hideMuted bool
+
+	// threads mutex protects the store.threads, store.threadCallback and
+	// store.folded
 	threadsMutex sync.Mutex
 
 	iterFactory iterator.Factory
@@ -92,6 +108,7 @@ func NewMessageStore(worker *types.Worker,
 		Messages: make(map[uint32]*models.MessageInfo),
 
 		selectedUid: MagicUid,
+		mutedUids:   make(map[uint32]bool),
 
 		bodyCallbacks: make(map[uint32][]func(*types.FullMessage)),
 
@@ -148,11 +165,30 @@ func (store *MessageStore) FetchHeaders(uids []uint32,
 }
 
 func (store *MessageStore) FetchFull(uids []uint32, cb func(*types.FullMessage)) {
+	store.FetchFullDone(uids, cb, nil)
+}
+
+// FetchFullDone is FetchFull with a done callback invoked once the worker has
+// answered, with the uids of the messages that could not be fetched.
+func (store *MessageStore) FetchFullDone(uids []uint32,
+	cb func(*types.FullMessage), done func(missing []uint32),
+) {
+	received := make(map[uint32]bool)
+	if done != nil {
+		fetched := cb
+		cb = func(msg *types.FullMessage) {
+			received[msg.Content.Uid] = true
+			if fetched != nil {
+				fetched(msg)
+			}
+		}
+	}
 	// TODO: this could be optimized by pre-allocating toFetch and trimming it
 	// at the end. In practice we expect to get most messages back in one frame.
 	var toFetch []uint32
 	for _, uid := range uids {
-		if _, ok := store.pendingBodies[uid]; !ok {
+		// pending bodies are fetched again when the caller waits for them
+		if _, ok := store.pendingBodies[uid]; !ok || done != nil {
 			toFetch = append(toFetch, uid)
 			store.pendingBodies[uid] = nil
 			if cb != nil {
@@ -164,18 +200,36 @@ func (store *MessageStore) FetchFull(uids []uint32, cb func(*types.FullMessage))
 			}
 		}
 	}
-	if len(toFetch) > 0 {
-		store.worker.PostAction(&types.FetchFullMessages{
-			Uids: toFetch,
-		}, func(msg types.WorkerMessage) {
-			if _, ok := msg.(*types.Error); ok {
-				for _, uid := range toFetch {
-					delete(store.pendingBodies, uid)
-					delete(store.bodyCallbacks, uid)
+	if len(toFetch) == 0 {
+		if done != nil {
+			done(nil)
+		}
+		return
+	}
+	store.worker.PostAction(&types.FetchFullMessages{
+		Uids: toFetch,
+	}, func(msg types.WorkerMessage) {
+		switch msg.(type) {
+		case *types.Error, *types.Unsupported:
+			for _, uid := range toFetch {
+				delete(store.pendingBodies, uid)
+				delete(store.bodyCallbacks, uid)
+			}
+		case *types.Done:
+		default:
+			return
+		}
+		if done != nil {
+			var missing []uint32
+			for _, uid := range uids {
+				if !received[uid] {
+					missing = append(missing, uid)
 				}
 			}
-		})
-	}
+			done(missing)
+			done = nil
+		}
+	})
 }
 
 func (store *MessageStore) FetchBodyPart(uid uint32, part []int, cb func(io.Reader)) {
@@ -232,6 +286,7 @@ func (store *MessageStore) Update(msg types.WorkerMessage) {
 		}
 		store.Messages = newMap
 		store.uids = msg.Uids
+		store.visibleUids = nil
 		if store.threadedView {
 			store.runThreadBuilderNow()
 		}
@@ -241,7 +296,11 @@ func (store *MessageStore) Update(msg types.WorkerMessage) {
 		store.builder = NewThreadBuilder(store.iterFactory)
 		store.builder.RebuildUids(msg.Threads, store.reverseThreadOrder)
 		store.uids = store.builder.Uids()
+		store.visibleUids = nil
+		store.threadsMutex.Lock()
 		store.threads = msg.Threads
+		store.applyThreadState()
+		store.threadsMutex.Unlock()
 
 		for _, uid := range store.uids {
 			if msg, ok := store.Messages[uid]; ok {
@@ -255,6 +314,7 @@ func (store *MessageStore) Update(msg types.WorkerMessage) {
 		store.Messages = newMap
 		update = true
 	case *types.MessageInfo:
+		muted := false
 		if existing, ok := store.Messages[msg.Info.Uid]; ok && existing != nil {
 			merge(existing, msg.Info)
 		} else if msg.Info.Envelope != nil {
@@ -262,6 +322,7 @@ func (store *MessageStore) Update(msg types.WorkerMessage) {
 			if store.selectedUid == msg.Info.Uid {
 				store.onSelect(msg.Info)
 			}
+			muted = store.checkMuted(msg.Info)
 		}
 		if msg.NeedsFlags {
 			store.Lock()
@@ -271,7 +332,7 @@ func (store *MessageStore) Update(msg types.WorkerMessage) {
 		}
 		seen := msg.Info.Flags.Has(models.SeenFlag)
 		recent := msg.Info.Flags.Has(models.RecentFlag)
-		if !seen && recent {
+		if !seen && recent && !muted {
 			store.triggerNewEmail(msg.Info)
 		}
 		if _, ok := store.pendingHeaders[msg.Info.Uid]; msg.Info.Envelope != nil && ok {
@@ -303,6 +364,7 @@ func (store *MessageStore) Update(msg types.WorkerMessage) {
 			toDelete[uid] = nil
 			delete(store.Messages, uid)
 			delete(store.Deleted, uid)
+			delete(store.mutedUids, uid)
 		}
 		uids := make([]uint32, len(store.uids)-len(msg.Uids))
 		j := 0
@@ -313,6 +375,7 @@ func (store *MessageStore) Update(msg types.WorkerMessage) {
 			}
 		}
 		store.uids = uids
+		store.visibleUids = nil
 		if len(uids) == 0 {
 			store.Select(MagicUid)
 		}
@@ -374,7 +437,14 @@ func (store *MessageStore) update(threads bool) {
 			if store.builder == nil {
 				store.builder = NewThreadBuilder(store.iterFactory)
 			}
-			store.builder.RebuildUids(store.Threads(), store.reverseThreadOrder)
+			store.threadsMutex.Lock()
+			if store.hideMuted {
+				store.hideMuted = false
+				store.applyThreadState()
+			} else {
+				store.builder.RebuildUids(store.threads, store.reverseThreadOrder)
+			}
+			store.threadsMutex.Unlock()
 		}
 	}
 }
@@ -454,6 +524,7 @@ func (store *MessageStore) runThreadBuilderNow() {
 	// run callback if defined (callback should reposition cursor)
 	store.threadsMutex.Lock()
 	store.threads = th
+	store.applyThreadState()
 	if store.threadCallback != nil {
 		store.threadCallback()
 	}
@@ -487,20 +558,346 @@ func (store *MessageStore) SelectedThread() *types.Thread {
 	return thread
 }
 
+// Fold collapses the thread of the message with the given uid to its first
+// message, or expands it back. Commands acting on the first message of a
+// folded thread act on the whole thread.
+func (store *MessageStore) Fold(uid uint32, fold bool) error {
+	if !store.ThreadedView() || store.builder == nil {
+		return errors.New("threads are not enabled")
+	}
+	store.threadsMutex.Lock()
+	var top *types.Thread
+	for _, root := range store.threads {
+		_ = root.Walk(func(t *types.Thread, _ int, _ error) error {
+			if t.Uid == uid {
+				top = t
+			}
+			return nil
+		})
+		if top != nil {
+			break
+		}
+	}
+	if top == nil {
+		store.threadsMutex.Unlock()
+		return errors.New("message not found in threads")
+	}
+	// fold the topmost message still displayed
+	for top.Parent != nil && !top.Parent.Hidden && !top.Parent.Deleted {
+		top = top.Parent
+	}
+	if top.FirstChild == nil {
+		store.threadsMutex.Unlock()
+		return errors.New("the message has no replies")
+	}
+	if store.folded == nil {
+		store.folded = make(map[uint32]bool)
+	}
+	if fold {
+		store.folded[top.Uid] = true
+	} else {
+		delete(store.folded, top.Uid)
+	}
+	top.Folded = fold
+	store.builder.RebuildUids(store.threads, store.reverseThreadOrder)
+	store.threadsMutex.Unlock()
+
+	if store.FindIndexByUid(store.selectedUid) < 0 {
+		store.Select(top.Uid)
+	}
+	store.update(false)
+	return nil
+}
+
+// FoldAll collapses or expands all the threads.
+func (store *MessageStore) FoldAll(fold bool) error {
+	if !store.ThreadedView() || store.builder == nil {
+		return errors.New("threads are not enabled")
+	}
+	store.threadsMutex.Lock()
+	store.folded = make(map[uint32]bool)
+	for _, root := range store.threads {
+		_ = root.Walk(func(t *types.Thread, _ int, _ error) error {
+			t.Folded = false
+			if fold && !t.Hidden && !t.Deleted && t.FirstChild != nil {
+				store.folded[t.Uid] = true
+				t.Folded = true
+				return types.ErrSkipThread
+			}
+			return nil
+		})
+	}
+	store.builder.RebuildUids(store.threads, store.reverseThreadOrder)
+	store.threadsMutex.Unlock()
+
+	if store.FindIndexByUid(store.selectedUid) < 0 {
+		if thread := store.SelectedThread(); thread != nil {
+			store.Select(thread.Root().Uid)
+		}
+	}
+	store.update(false)
+	return nil
+}
+
+// IsFolded returns true if the message with the given uid is the first
+// message of a folded thread.
+func (store *MessageStore) IsFolded(uid uint32) bool {
+	store.threadsMutex.Lock()
+	defer store.threadsMutex.Unlock()
+	return store.folded[uid]
+}
+
+// ExpandFolded replaces the uids of the folded threads with the uids of all
+// their messages.
+func (store *MessageStore) ExpandFolded(uids []uint32) []uint32 {
+	store.threadsMutex.Lock()
+	defer store.threadsMutex.Unlock()
+	if len(store.folded) == 0 {
+		return uids
+	}
+	folded := make(map[uint32]*types.Thread)
+	for _, root := range store.threads {
+		_ = root.Walk(func(t *types.Thread, _ int, _ error) error {
+			if t.Folded {
+				folded[t.Uid] = t
+			}
+			return nil
+		})
+	}
+	var expanded []uint32
+	for _, uid := range uids {
+		thread, ok := folded[uid]
+		if !ok {
+			expanded = append(expanded, uid)
+			continue
+		}
+		_ = thread.Walk(func(t *types.Thread, _ int, _ error) error {
+			if !t.Hidden && !t.Deleted {
+				expanded = append(expanded, t.Uid)
+			}
+			return nil
+		})
+	}
+	return expanded
+}
+
+// applyThreadState folds the new threads which were folded before they were
+// rebuilt and hides the muted messages. The caller must hold the threads
+// mutex.
+func (store *MessageStore) applyThreadState() {
+	hideMuted := !store.showMuted && len(store.mutedUids) > 0
+	if len(store.folded) == 0 && !hideMuted {
+		return
+	}
+	for _, root := range store.threads {
+		_ = root.Walk(func(t *types.Thread, _ int, _ error) error {
+			t.Folded = store.folded[t.Uid] && t.FirstChild != nil
+			if hideMuted && store.mutedUids[t.Uid] {
+				t.Hidden = true
+			}
+			return nil
+		})
+	}
+	store.builder.RebuildUids(store.threads, store.reverseThreadOrder)
+}
+
+// SetMuted sets the list of muted threads of the account. onMuted is called
+// for each message found in a muted thread.
+func (store *MessageStore) SetMuted(list *mute.List,
+	onMuted func(*models.MessageInfo),
+) {
+	store.muted = list
+	store.onMuted = onMuted
+}
+
+// Mute adds the threads of the given messages to the muted threads. The
+// messages are marked as read and hidden unless muted messages are shown.
+func (store *MessageStore) Mute(uids []uint32) error {
+	if store.muted == nil {
+		return errors.New("muted threads are not available")
+	}
+	for _, uid := range uids {
+		if msg := store.Messages[uid]; msg != nil {
+			store.muted.Add(mute.ThreadIds(msg)...)
+		}
+	}
+	for _, uid := range uids {
+		if msg := store.Messages[uid]; msg != nil {
+			store.checkMuted(msg)
+		}
+	}
+	store.refreshMuted()
+	return nil
+}
+
+// Unmute removes the threads of the given messages from the muted threads.
+// Since the Message-IDs of the messages found in a muted thread were added to
+// the list, those of all the loaded messages of the threads are removed.
+func (store *MessageStore) Unmute(uids []uint32) error {
+	if store.muted == nil {
+		return errors.New("muted threads are not available")
+	}
+	thread := mute.NewList("")
+	var ids []string
+	unmuted := make(map[uint32]bool)
+	for _, uid := range uids {
+		if msg := store.Messages[uid]; msg != nil {
+			thread.Add(mute.ThreadIds(msg)...)
+			ids = append(ids, mute.ThreadIds(msg)...)
+		}
+		unmuted[uid] = true
+	}
+	// follow the references until no other message joins the threads
+	for found := true; found; {
+		found = false
+		for uid, msg := range store.Messages {
+			if msg == nil || unmuted[uid] {
+				continue
+			}
+			if msgIds := mute.ThreadIds(msg); thread.Match(msgIds...) {
+				thread.Add(msgIds...)
+				ids = append(ids, msgIds...)
+				unmuted[uid] = true
+				found = true
+			}
+		}
+	}
+	store.muted.Remove(ids...)
+	for uid := range unmuted {
+		delete(store.mutedUids, uid)
+	}
+	store.refreshMuted()
+	return nil
+}
+
+// IsMuted returns true if the message belongs to a muted thread.
+func (store *MessageStore) IsMuted(uid uint32) bool {
+	return store.mutedUids[uid]
+}
+
+// ShowMuted returns true if the messages of muted threads are listed.
+func (store *MessageStore) ShowMuted() bool {
+	return store.showMuted
+}
+
+// SetShowMuted shows or hides the messages of muted threads.
+func (store *MessageStore) SetShowMuted(show bool) {
+	store.showMuted = show
+	store.refreshMuted()
+}
+
+// checkMuted marks the messages of muted threads as read and hides them. It
+// returns true if the message belongs to a muted thread.
+func (store *MessageStore) checkMuted(msg *models.MessageInfo) bool {
+	if store.muted == nil || !store.muted.Muted(msg) {
+		return false
+	}
+	store.mutedUids[msg.Uid] = true
+	store.visibleUids = nil
+	if !msg.Flags.Has(models.SeenFlag) {
+		store.Flag([]uint32{msg.Uid}, models.SeenFlag, true, nil)
+	}
+	if store.onMuted != nil {
+		store.onMuted(msg)
+	}
+	if !store.showMuted && store.ThreadedView() && !store.BuildThreads() {
+		// hidden by the next update, once for all the new messages
+		store.hideMuted = true
+	}
+	return true
+}
+
+// refreshMuted updates the list after muted messages were hidden or shown.
+func (store *MessageStore) refreshMuted() {
+	store.visibleUids = nil
+	store.hideMuted = false
+	if store.ThreadedView() {
+		if store.BuildThreads() {
+			store.runThreadBuilderNow()
+		} else {
+			// the hidden flags can only be reset by the backend
+			store.Sort(store.sortCriteria, nil)
+		}
+	}
+	if store.FindIndexByUid(store.selectedUid) < 0 {
+		store.selectedUid = MagicUid
+	}
+	store.update(false)
+}
+
+// visible returns the uids of the messages which are not hidden because
+// they belong to muted threads.
+func (store *MessageStore) visible() []uint32 {
+	if store.showMuted || len(store.mutedUids) == 0 {
+		return store.uids
+	}
+	if store.visibleUids == nil {
+		store.visibleUids = make([]uint32, 0, len(store.uids))
+		for _, uid := range store.uids {
+			if !store.mutedUids[uid] {
+				store.visibleUids = append(store.visibleUids, uid)
+			}
+		}
+	}
+	return store.visibleUids
+}
+
+// StoreEvents are called when messages were successfully changed with the
+// store. Nil callbacks are ignored.
+type StoreEvents struct {
+	// Deleted receives the messages as they were before deletion.
+	Deleted func(msgs []*models.MessageInfo)
+	// Moved receives the messages as they were before the move.
+	Moved func(dest string, msgs []*models.MessageInfo)
+	// FlagChanged receives the messages with their updated flags.
+	FlagChanged func(msgs []*models.MessageInfo)
+}
+
+func (store *MessageStore) SetEvents(events StoreEvents) {
+	store.events = events
+}
+
+// messageInfos returns the known messages among uids.
+func (store *MessageStore) messageInfos(uids []uint32) []*models.MessageInfo {
+	var msgs []*models.MessageInfo
+	for _, uid := range uids {
+		if msg := store.Messages[uid]; msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func (store *MessageStore) flagChanged(uids []uint32,
+	cb func(msg types.WorkerMessage),
+) func(msg types.WorkerMessage) {
+	return func(msg types.WorkerMessage) {
+		if _, ok := msg.(*types.Done); ok && store.events.FlagChanged != nil {
+			store.events.FlagChanged(store.messageInfos(uids))
+		}
+		if cb != nil {
+			cb(msg)
+		}
+	}
+}
+
 func (store *MessageStore) Delete(uids []uint32,
 	cb func(msg types.WorkerMessage),
 ) {
+	msgs := store.messageInfos(uids)
 	for _, uid := range uids {
 		store.Deleted[uid] = nil
 	}
 
 	store.worker.PostAction(&types.DeleteMessages{Uids: uids},
 		func(msg types.WorkerMessage) {
-			if _, ok := msg.(*types.Error); ok {
-				store.revertDeleted(uids)
-			}
-			if _, ok := msg.(*types.Unsupported); ok {
+			switch msg.(type) {
+			case *types.Error, *types.Unsupported:
 				store.revertDeleted(uids)
+			case *types.Done:
+				if store.events.Deleted != nil {
+					store.events.Deleted(msgs)
+				}
 			}
 			cb(msg)
 		})
@@ -531,6 +928,7 @@ func (store *MessageStore) Copy(uids []uint32, dest string, createDest bool,
 func (store *MessageStore) Move(uids []uint32, dest string, createDest bool,
 	cb func(msg types.WorkerMessage),
 ) {
+	msgs := store.messageInfos(uids)
 	for _, uid := range uids {
 		store.Deleted[uid] = nil
 	}
@@ -551,6 +949,9 @@ func (store *MessageStore) Move(uids []uint32, dest string, createDest bool,
 			store.revertDeleted(uids)
 			cb(msg)
 		case *types.Done:
+			if store.events.Moved != nil {
+				store.events.Moved(dest, msgs)
+			}
 			cb(msg)
 		}
 	})
@@ -563,7 +964,7 @@ func (store *MessageStore) Flag(uids []uint32, flags models.Flags,
 		Enable: enable,
 		Flags:  flags,
 		Uids:   uids,
-	}, cb)
+	}, store.flagChanged(uids, cb))
 }
 
 func (store *MessageStore) Answered(uids []uint32, answered bool,
@@ -572,7 +973,7 @@ func (store *MessageStore) Answered(uids []uint32, answered bool,
 	store.worker.PostAction(&types.AnsweredMessages{
 		Answered: answered,
 		Uids:     uids,
-	}, cb)
+	}, store.flagChanged(uids, cb))
 }
 
 func (store *MessageStore) Uids() []uint32 {
@@ -581,7 +982,7 @@ func (store *MessageStore) Uids() []uint32 {
 			return uids
 		}
 	}
-	return store.uids
+	return store.visible()
 }
 
 func (store *MessageStore) UidsIterator() iterator.Iterator {
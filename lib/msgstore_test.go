@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rjarry/aerc/lib/mute"
+	"git.sr.ht/~rjarry/aerc/models"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+)
+
+func TestMessageStoreDeleteMuted(t *testing.T) {
+	store := NewMessageStore(nil, &models.DirectoryInfo{Caps: &models.Capabilities{}}, nil,
+		false, false, 0, false, false, false, nil, nil, nil)
+	store.Update(&types.DirectoryContents{Uids: []uint32{1, 2, 3}})
+	store.mutedUids[2] = true
+	if uids := store.Uids(); !reflect.DeepEqual(uids, []uint32{1, 3}) {
+		t.Fatalf("expected [1 3] before deletion, got %v", uids)
+	}
+	store.Update(&types.MessagesDeleted{Uids: []uint32{2, 3}})
+	if uids := store.Uids(); !reflect.DeepEqual(uids, []uint32{1}) {
+		t.Errorf("expected [1] after deletion, got %v", uids)
+	}
+	if store.IsMuted(2) {
+		t.Error("deleted message is still muted")
+	}
+}
+
+func TestMessageStoreUnmute(t *testing.T) {
+	store := NewMessageStore(nil, &models.DirectoryInfo{Caps: &models.Capabilities{}}, nil,
+		false, false, 0, false, false, false, nil, nil, nil)
+	list := mute.NewList("")
+	store.SetMuted(list, nil)
+	receive := func(uid uint32, id, inReplyTo string) {
+		store.Update(&types.MessageInfo{Info: &models.MessageInfo{
+			Uid:      uid,
+			Flags:    models.SeenFlag,
+			Envelope: &models.Envelope{MessageId: id, InReplyTo: inReplyTo},
+		}})
+	}
+	store.Update(&types.DirectoryContents{Uids: []uint32{1}})
+	receive(1, "root@example.org", "")
+	if err := store.Mute([]uint32{1}); err != nil {
+		t.Fatal(err)
+	}
+	receive(2, "a@example.org", "root@example.org")
+	if !store.IsMuted(2) {
+		t.Fatal("reply to a muted thread is not muted")
+	}
+	// unmute from the root in the flat view
+	if err := store.Unmute([]uint32{1}); err != nil {
+		t.Fatal(err)
+	}
+	if store.IsMuted(2) || list.Len() != 0 {
+		t.Errorf("thread still muted: %v %d", store.IsMuted(2), list.Len())
+	}
+	receive(3, "b@example.org", "a@example.org")
+	if store.IsMuted(3) {
+		t.Error("reply to an unmuted thread is muted")
+	}
+}
+
+func TestMessageStoreFetchFullDone(t *testing.T) {
+	worker := types.NewWorker("test")
+	store := NewMessageStore(worker, &models.DirectoryInfo{Caps: &models.Capabilities{}}, nil,
+		false, false, 0, false, false, false, nil, nil, nil)
+	var fetched, missing []uint32
+	done := false
+	store.FetchFullDone([]uint32{1, 2, 3}, func(fm *types.FullMessage) {
+		fetched = append(fetched, fm.Content.Uid)
+	}, func(m []uint32) {
+		missing, done = m, true
+	})
+	action := (<-worker.Actions).(*types.FetchFullMessages)
+	for _, msg := range []types.WorkerMessage{
+		&types.FullMessage{
+			Message: types.RespondTo(action),
+			Content: &models.FullMessage{Uid: 2, Reader: strings.NewReader("")},
+		},
+		&types.Error{Message: types.RespondTo(action)},
+	} {
+		store.Update(worker.ProcessMessage(msg))
+	}
+	if !done {
+		t.Fatal("done callback not called")
+	}
+	if !reflect.DeepEqual(fetched, []uint32{2}) ||
+		!reflect.DeepEqual(missing, []uint32{1, 3}) {
+		t.Errorf("unexpected fetched %v and missing %v", fetched, missing)
+	}
+}
@@ -0,0 +1,168 @@
+package mute
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kyoh86/xdg"
+
+	"git.sr.ht/~rjarry/aerc/models"
+)
+
+// A List is the set of muted threads of an account, persisted to a plain
+// text file. Threads are identified by the Message-IDs of their messages,
+// one per line. A message whose Message-ID, In-Reply-To or References
+// contain one of them belongs to a muted thread.
+type List struct {
+	mu   sync.Mutex
+	path string
+	ids  map[string]bool
+}
+
+// DefaultPath returns the location of the muted threads of an account in
+// the XDG data directory.
+func DefaultPath(account string) string {
+	name := strings.ReplaceAll(account, "/", "_")
+	return path.Join(xdg.DataHome(), "aerc", "muted", name)
+}
+
+// NewList creates an empty list stored at the given path.
+func NewList(path string) *List {
+	return &List{path: path, ids: make(map[string]bool)}
+}
+
+// Load reads the list file. A missing file is not an error.
+func (l *List) Load() error {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	return l.Read(f)
+}
+
+// Read adds the Message-IDs read from r to the list.
+func (l *List) Read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	var ids []string
+	for scanner.Scan() {
+		ids = append(ids, scanner.Text())
+	}
+	l.Add(ids...)
+	return scanner.Err()
+}
+
+// Save writes the list file.
+func (l *List) Save() error {
+	if err := os.MkdirAll(path.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return l.Write(f)
+}
+
+// Write serializes the list to w, sorted.
+func (l *List) Write(w io.Writer) error {
+	l.mu.Lock()
+	ids := make([]string, 0, len(l.ids))
+	for id := range l.ids {
+		ids = append(ids, id)
+	}
+	l.mu.Unlock()
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, err := fmt.Fprintln(w, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add mutes the threads containing the given Message-IDs. It returns true
+// if one of them was not already muted.
+func (l *List) Add(ids ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	added := false
+	for _, id := range ids {
+		id = normalize(id)
+		if id != "" && !l.ids[id] {
+			l.ids[id] = true
+			added = true
+		}
+	}
+	return added
+}
+
+// Remove unmutes the threads containing the given Message-IDs.
+func (l *List) Remove(ids ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range ids {
+		delete(l.ids, normalize(id))
+	}
+}
+
+// Len returns the number of Message-IDs in the list.
+func (l *List) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.ids)
+}
+
+// Match returns true if one of the given Message-IDs is muted.
+func (l *List) Match(ids ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range ids {
+		if l.ids[normalize(id)] {
+			return true
+		}
+	}
+	return false
+}
+
+// Muted returns true if the message belongs to a muted thread. Its own
+// Message-ID is then added to the list so that replies to it are muted as
+// well, even if they do not carry all the references.
+func (l *List) Muted(msg *models.MessageInfo) bool {
+	ids := ThreadIds(msg)
+	if len(ids) == 0 || !l.Match(ids...) {
+		return false
+	}
+	l.Add(ids[0])
+	return true
+}
+
+// ThreadIds returns the Message-ID of a message followed by the Message-IDs
+// it refers to.
+func ThreadIds(msg *models.MessageInfo) []string {
+	msgid, err := msg.MsgId()
+	if err != nil || msgid == "" {
+		return nil
+	}
+	ids := []string{msgid}
+	if irt, err := msg.InReplyTo(); err == nil && irt != "" {
+		ids = append(ids, irt)
+	}
+	if refs, err := msg.References(); err == nil {
+		ids = append(ids, refs...)
+	}
+	return ids
+}
+
+func normalize(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
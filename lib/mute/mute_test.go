@@ -0,0 +1,60 @@
+package mute
+
+import (
+	"bytes"
+	"testing"
+
+	"git.sr.ht/~rjarry/aerc/models"
+)
+
+func message(id, inReplyTo string, refs ...string) *models.MessageInfo {
+	return &models.MessageInfo{
+		Envelope: &models.Envelope{MessageId: id, InReplyTo: inReplyTo},
+		Refs:     refs,
+	}
+}
+
+func TestMuted(t *testing.T) {
+	l := NewList("")
+	l.Add("<root@example.org>", "ref@example.org")
+
+	tests := []struct {
+		msg   *models.MessageInfo
+		muted bool
+	}{
+		{message("root@example.org", ""), true},
+		{message("a@example.org", "root@example.org"), true},
+		{message("b@example.org", "", "ref@example.org", "x@example.org"), true},
+		// reply to a muted reply without references
+		{message("c@example.org", "a@example.org"), true},
+		{message("d@example.org", "other@example.org"), false},
+		{message("", ""), false},
+	}
+	for _, test := range tests {
+		if muted := l.Muted(test.msg); muted != test.muted {
+			t.Errorf("%s: expected %v, got %v",
+				test.msg.Envelope.MessageId, test.muted, muted)
+		}
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	l := NewList("")
+	l.Add("b@example.org", "<a@example.org>", "")
+	var buf bytes.Buffer
+	if err := l.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "a@example.org\nb@example.org\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+	other := NewList("")
+	if err := other.Read(&buf); err != nil {
+		t.Fatal(err)
+	}
+	other.Remove("<b@example.org>")
+	if !other.Match("a@example.org") || other.Match("b@example.org") ||
+		other.Len() != 1 {
+		t.Errorf("unexpected list after read: %v", other.ids)
+	}
+}
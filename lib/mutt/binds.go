@@ -0,0 +1,356 @@
+package mutt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sections are the binds.conf sections of the mutt menus.
+var sections = map[string]string{
+	"generic": "default",
+	"index":   "messages",
+	"pager":   "view",
+	"compose": "compose::review",
+}
+
+// messageFunctions are the functions shared by the index and pager menus.
+var messageFunctions = map[string]string{
+	"next-entry":         "next",
+	"next-undeleted":     "next",
+	"previous-entry":     "prev",
+	"previous-undeleted": "prev",
+	"reply":              "reply -q",
+	"group-reply":        "reply -aq",
+	"list-reply":         "reply -lq",
+	"forward-message":    "forward",
+	"bounce-message":     "bounce ",
+	"delete-message":     "delete",
+	"save-message":       "move ",
+	"copy-message":       "copy ",
+	"pipe-message":       "pipe ",
+	"flag-message":       "flag -t",
+	"toggle-new":         "read -t",
+	"shell-escape":       "term ",
+}
+
+// functions are the aerc commands of the mutt functions by menu. The commands
+// ending with a space expect an argument.
+var functions = map[string]map[string]string{
+	"generic": {
+		"shell-escape": "term ",
+	},
+	"index": merge(messageFunctions, map[string]string{
+		"mail":            "compose",
+		"change-folder":   "cf ",
+		"search":          "search ",
+		"search-next":     "next-result",
+		"search-opposite": "prev-result",
+		"first-entry":     "select 0",
+		"last-entry":      "select -1",
+		"next-page":       "next 100%",
+		"previous-page":   "prev 100%",
+		"half-down":       "next 50%",
+		"half-up":         "prev 50%",
+		"display-message": "view",
+		"tag-entry":       "mark -t",
+		"limit":           "filter ",
+		"collapse-thread": "toggle-fold",
+		"collapse-all":    "toggle-fold -a",
+		"sort-mailbox":    "sort ",
+		"exit":            "quit",
+		"quit":            "quit",
+	}),
+	"pager": merge(messageFunctions, map[string]string{
+		"display-toggle-weed": "toggle-headers",
+		"exit":                "close",
+		"quit":                "quit",
+	}),
+	"compose": {
+		"send-message":     "send",
+		"postpone-message": "postpone",
+		"attach-file":      "attach ",
+		"detach-file":      "detach ",
+		"edit-message":     "edit",
+		"exit":             "abort",
+	},
+}
+
+func merge(a, b map[string]string) map[string]string {
+	m := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
+
+// menus returns the mutt menus of a bind or macro command which have a
+// binds.conf section. The others are reported.
+func (imp *Import) menus(cmd *command) []string {
+	var menus []string
+	for _, menu := range strings.Split(cmd.args[1], ",") {
+		if _, ok := sections[menu]; !ok {
+			imp.unmapped(cmd, cmd.args[0]+" "+menu+" "+cmd.args[2],
+				"no equivalent menu")
+			continue
+		}
+		menus = append(menus, menu)
+	}
+	return menus
+}
+
+func (imp *Import) bind(cmd *command) {
+	if len(cmd.args) != 4 {
+		imp.unmapped(cmd, cmd.text(3), "expected a menu, a key and a function")
+		return
+	}
+	key, err := translateKey(cmd.args[2])
+	if err != nil {
+		imp.unmapped(cmd, cmd.text(3), err.Error())
+		return
+	}
+	for _, menu := range imp.menus(cmd) {
+		section := sections[menu]
+		if cmd.args[3] == "noop" {
+			imp.addBind(section, key, "")
+			imp.Unbinds[section] = append(imp.Unbinds[section], key)
+			continue
+		}
+		cmdline, ok := functions[menu][cmd.args[3]]
+		if !ok {
+			imp.unmapped(cmd, strings.Join([]string{"bind", menu, cmd.args[2], cmd.args[3]}, " "),
+				"no equivalent function")
+			continue
+		}
+		output := ":" + cmdline + "<Enter>"
+		if strings.HasSuffix(cmdline, " ") {
+			output = ":" + strings.TrimSuffix(cmdline, " ") + "<space>"
+		}
+		imp.addBind(section, key, output)
+	}
+}
+
+// addBind binds a key of a section, replacing its previous binding. The key
+// is removed when output is empty.
+func (imp *Import) addBind(section, key, output string) {
+	binds := imp.Binds[section][:0]
+	for _, b := range imp.Binds[section] {
+		if b.Key != key {
+			binds = append(binds, b)
+		}
+	}
+	if output != "" {
+		binds = append(binds, Setting{Key: key, Value: output})
+	}
+	if len(binds) == 0 {
+		delete(imp.Binds, section)
+		return
+	}
+	imp.Binds[section] = binds
+}
+
+func (imp *Import) macro(cmd *command) {
+	if len(cmd.args) != 4 && len(cmd.args) != 5 {
+		imp.unmapped(cmd, cmd.text(3), "expected a menu, a key and a sequence")
+		return
+	}
+	key, err := translateKey(cmd.args[2])
+	if err != nil {
+		imp.unmapped(cmd, cmd.text(3), err.Error())
+		return
+	}
+	for _, menu := range imp.menus(cmd) {
+		output, err := translateMacro(menu, cmd.args[3])
+		if err != nil {
+			imp.unmapped(cmd, strings.Join([]string{"macro", menu, cmd.args[2], cmd.args[3]}, " "),
+				err.Error())
+			continue
+		}
+		imp.addBind(sections[menu], key, output)
+	}
+}
+
+// keyNames are the aerc names of the mutt keys.
+var keyNames = map[string]string{
+	"enter":     "<Enter>",
+	"return":    "<Enter>",
+	"tab":       "<Tab>",
+	"backtab":   "<Backtab>",
+	"esc":       "<Esc>",
+	"space":     "<Space>",
+	"up":        "<Up>",
+	"down":      "<Down>",
+	"left":      "<Left>",
+	"right":     "<Right>",
+	"pageup":    "<PgUp>",
+	"pagedown":  "<PgDn>",
+	"home":      "<Home>",
+	"end":       "<End>",
+	"insert":    "<Insert>",
+	"delete":    "<Delete>",
+	"backspace": "<BS>",
+}
+
+// stroke is an aerc key stroke or a mutt function of a macro.
+type stroke struct {
+	key      string
+	function string
+}
+
+// keyStrokes splits a mutt key sequence into aerc key strokes.
+func keyStrokes(keys string) ([]stroke, error) {
+	var strokes []stroke
+	runes := []rune(keys)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '<':
+			end := i + 1
+			for end < len(runes) && runes[end] != '>' {
+				end++
+			}
+			if end == len(runes) || end == i+1 {
+				strokes = append(strokes, stroke{key: rune2stroke(r)})
+				continue
+			}
+			name := strings.ToLower(string(runes[i+1 : end]))
+			i = end
+			switch {
+			case keyNames[name] != "":
+				strokes = append(strokes, stroke{key: keyNames[name]})
+			case isFunctionKey(name):
+				strokes = append(strokes, stroke{key: "<F" + name[1:] + ">"})
+			default:
+				strokes = append(strokes, stroke{function: name})
+			}
+		case r == '\\' && i+1 < len(runes):
+			i++
+			switch runes[i] {
+			case 'C', 'c':
+				if i+1 >= len(runes) || !unicode.IsLetter(runes[i+1]) {
+					return nil, fmt.Errorf("unsupported key %s", keys)
+				}
+				i++
+				strokes = append(strokes, stroke{key: "<C-" + string(unicode.ToLower(runes[i])) + ">"})
+			case 'e':
+				strokes = append(strokes, stroke{key: "<Esc>"})
+			case 'n', 'r':
+				strokes = append(strokes, stroke{key: "<Enter>"})
+			case 't':
+				strokes = append(strokes, stroke{key: "<Tab>"})
+			default:
+				strokes = append(strokes, stroke{key: rune2stroke(runes[i])})
+			}
+		case r == '^' && i+1 < len(runes) && unicode.IsLetter(runes[i+1]):
+			i++
+			strokes = append(strokes, stroke{key: "<C-" + string(unicode.ToLower(runes[i])) + ">"})
+		default:
+			strokes = append(strokes, stroke{key: rune2stroke(r)})
+		}
+	}
+	return strokes, nil
+}
+
+// isFunctionKey returns true for the names of the function keys known by
+// aerc, from f1 to f64.
+func isFunctionKey(name string) bool {
+	if !strings.HasPrefix(name, "f") {
+		return false
+	}
+	n, err := strconv.Atoi(name[1:])
+	return err == nil && n >= 1 && n <= 64 && name[1] != '0'
+}
+
+func rune2stroke(r rune) string {
+	switch r {
+	case '<', '>', '\\':
+		return `\` + string(r)
+	case ' ':
+		return "<Space>"
+	case ';':
+		return "<semicolon>"
+	}
+	return string(r)
+}
+
+// translateKey converts the key of a bind or macro command. The escape key
+// followed by a letter is the alt modifier. The keys which cannot be used as
+// is in binds.conf are quoted.
+func translateKey(keys string) (string, error) {
+	strokes, err := keyStrokes(keys)
+	if err != nil {
+		return "", err
+	}
+	var key strings.Builder
+	for i := 0; i < len(strokes); i++ {
+		s := strokes[i]
+		if s.function != "" {
+			return "", fmt.Errorf("unsupported key <%s>", s.function)
+		}
+		if s.key == "<Esc>" && i+1 < len(strokes) {
+			next := strokes[i+1].key
+			if len(next) == 1 && next[0] >= 'a' && next[0] <= 'z' {
+				s.key = "<A-" + next + ">"
+				i++
+			}
+		}
+		key.WriteString(s.key)
+	}
+	if strings.ContainsAny(key.String(), "=:#[") {
+		if strings.Contains(key.String(), `"`) {
+			return "", fmt.Errorf("unsupported key %s", keys)
+		}
+		return `"` + key.String() + `"`, nil
+	}
+	return key.String(), nil
+}
+
+// translateMacro converts a macro made of functions, each one optionally
+// followed by its argument and the enter key, to aerc commands.
+func translateMacro(menu, macro string) (string, error) {
+	strokes, err := keyStrokes(macro)
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	for i := 0; i < len(strokes); i++ {
+		name := strokes[i].function
+		if name == "" {
+			return "", fmt.Errorf("keys of mutt functions are not supported")
+		}
+		cmdline, ok := functions[menu][name]
+		if !ok {
+			return "", fmt.Errorf("no equivalent function <%s>", name)
+		}
+		if !strings.HasSuffix(cmdline, " ") {
+			output.WriteString(":" + cmdline + "<Enter>")
+			continue
+		}
+		var arg strings.Builder
+		for i+1 < len(strokes) && strokes[i+1].function == "" && strokes[i+1].key != "<Enter>" {
+			i++
+			if arg.Len() > 0 || strokes[i].key != "<Space>" {
+				arg.WriteString(strokes[i].key)
+			}
+		}
+		value := arg.String()
+		if strings.HasPrefix(value, "=") || strings.HasPrefix(value, "+") {
+			value = value[1:]
+		}
+		output.WriteString(":" + strings.TrimSuffix(cmdline, " "))
+		if i+1 < len(strokes) && strokes[i+1].key == "<Enter>" {
+			i++
+			if value != "" {
+				output.WriteString(" " + value)
+			}
+			output.WriteString("<Enter>")
+		} else {
+			output.WriteString("<space>" + value)
+		}
+	}
+	return output.String(), nil
+}
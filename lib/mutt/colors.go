@@ -0,0 +1,143 @@
+package mutt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// objects are the styles of the mutt color objects. The viewer styles are
+// prefixed with "viewer:".
+var objects = map[string]string{
+	"normal":            "*",
+	"indicator":         "*.selected",
+	"status":            "statusline_default",
+	"error":             "error",
+	"warning":           "warning",
+	"message":           "success",
+	"sidebar_ordinary":  "dirlist_default",
+	"sidebar_new":       "dirlist_unread",
+	"sidebar_unread":    "dirlist_unread",
+	"sidebar_indicator": "dirlist_*.selected",
+	"hdrdefault":        "viewer:header",
+	"quoted":            "viewer:quote_1",
+	"quoted1":           "viewer:quote_2",
+	"quoted2":           "viewer:quote_3",
+	"quoted3":           "viewer:quote_4",
+	"quoted4":           "viewer:quote_x",
+	"signature":         "viewer:signature",
+	"search":            "viewer:search",
+}
+
+// patterns are the message list styles of the index color patterns.
+var patterns = map[string]string{
+	"":   "msglist_default",
+	"~A": "msglist_default",
+	"~N": "msglist_unread",
+	"~R": "msglist_read",
+	"~F": "msglist_flagged",
+	"~D": "msglist_deleted",
+	"~T": "msglist_marked",
+	"~Q": "msglist_answered",
+}
+
+var attributes = map[string]string{
+	"bold":      "bold",
+	"underline": "underline",
+	"reverse":   "reverse",
+	"standout":  "reverse",
+	"blink":     "blink",
+	"italic":    "italic",
+}
+
+var colorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+}
+
+// color converts a color command to styleset settings.
+func (imp *Import) color(cmd *command) {
+	if len(cmd.args) < 2 {
+		imp.unmapped(cmd, cmd.text(3), "missing object")
+		return
+	}
+	object := cmd.args[1]
+	args := cmd.args[2:]
+	var attrs []string
+	for len(args) > 0 {
+		if args[0] != "none" && args[0] != "normal" {
+			if _, ok := attributes[args[0]]; !ok {
+				break
+			}
+			attrs = append(attrs, attributes[args[0]])
+		}
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		imp.unmapped(cmd, cmd.text(3), "missing colors")
+		return
+	}
+	style, ok := objects[object]
+	if object == "index" {
+		style, ok = patterns[strings.Join(args[2:], " ")]
+		if !ok {
+			imp.unmapped(cmd, cmd.text(5), "unsupported pattern")
+			return
+		}
+	} else if len(args) > 2 {
+		imp.unmapped(cmd, cmd.text(5), "patterns are not supported")
+		return
+	}
+	if !ok {
+		imp.unmapped(cmd, cmd.text(2), "no equivalent object")
+		return
+	}
+	fg, err := translateColor(args[0])
+	if err != nil {
+		imp.unmapped(cmd, cmd.text(4), err.Error())
+		return
+	}
+	bg, err := translateColor(args[1])
+	if err != nil {
+		imp.unmapped(cmd, cmd.text(4), err.Error())
+		return
+	}
+
+	styles := &imp.Styles
+	if strings.HasPrefix(style, "viewer:") {
+		styles = &imp.ViewerStyles
+		style = strings.TrimPrefix(style, "viewer:")
+	}
+	*styles = append(*styles, Setting{Key: style + ".fg", Value: fg})
+	*styles = append(*styles, Setting{Key: style + ".bg", Value: bg})
+	for _, attr := range attrs {
+		*styles = append(*styles, Setting{Key: style + "." + attr, Value: "true"})
+	}
+}
+
+// translateColor converts a mutt color to a styleset color. The named colors
+// are converted to their palette index, tcell names differ from the curses
+// ones.
+func translateColor(color string) (string, error) {
+	c := strings.ToLower(color)
+	switch {
+	case c == "default":
+		return c, nil
+	case strings.HasPrefix(c, "color"):
+		if n, err := strconv.Atoi(c[5:]); err == nil && n >= 0 && n < 256 {
+			return strconv.Itoa(n), nil
+		}
+	case strings.HasPrefix(c, "#") && len(c) == 7:
+		if _, err := strconv.ParseUint(c[1:], 16, 32); err == nil {
+			return c, nil
+		}
+	}
+	for i, name := range colorNames {
+		switch c {
+		case name:
+			return strconv.Itoa(i), nil
+		case "bright" + name:
+			return strconv.Itoa(i + 8), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported color %s", color)
+}
@@ -0,0 +1,489 @@
+// Package mutt converts the configuration of mutt and neomutt to aerc.
+package mutt
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// Setting is a key and its value in an aerc configuration file.
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// Unmapped is a muttrc setting that has no aerc equivalent.
+type Unmapped struct {
+	File   string
+	Line   int
+	Text   string
+	Reason string
+}
+
+func (u *Unmapped) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", u.File, u.Line, u.Text, u.Reason)
+}
+
+// Import is the aerc configuration converted from a muttrc file.
+type Import struct {
+	// Name of the account section in accounts.conf
+	Name string
+	// Account holds the settings of the account section
+	Account []Setting
+	// Aliases are the addresses of the alias commands, named after their
+	// alias when they have no name
+	Aliases []*mail.Address
+	// Binds holds the bindings by binds.conf section
+	Binds map[string][]Setting
+	// Unbinds holds the keys bound to noop by binds.conf section
+	Unbinds map[string][]string
+	// Styles holds the styleset settings
+	Styles []Setting
+	// ViewerStyles holds the settings of the [viewer] styleset section
+	ViewerStyles []Setting
+	// Unmapped lists what could not be converted, sorted by location
+	Unmapped []*Unmapped
+
+	vars      map[string]*variable
+	mailboxes []*command
+	sourced   map[string]bool
+}
+
+type variable struct {
+	value string
+	cmd   *command
+	used  bool
+}
+
+// Convert reads a muttrc file and the files it sources. The account is named
+// after the domain of the from address when name is empty.
+func Convert(filename string, name string) (*Import, error) {
+	imp := &Import{
+		Name:    name,
+		Binds:   make(map[string][]Setting),
+		Unbinds: make(map[string][]string),
+		vars:    make(map[string]*variable),
+		sourced: make(map[string]bool),
+	}
+	cmds, err := imp.readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, cmd := range cmds {
+		switch cmd.args[0] {
+		case "set", "unset", "toggle", "reset":
+			imp.set(cmd)
+		case "alias":
+			imp.alias(cmd)
+		case "alternates":
+			imp.alternates(cmd)
+		case "mailboxes", "named-mailboxes":
+			imp.mailboxes = append(imp.mailboxes, cmd)
+		case "bind":
+			imp.bind(cmd)
+		case "macro":
+			imp.macro(cmd)
+		case "color":
+			imp.color(cmd)
+		default:
+			reason := "no equivalent command"
+			if strings.HasSuffix(cmd.args[0], "-hook") {
+				reason = "hooks are not supported"
+			}
+			imp.unmapped(cmd, cmd.text(2), reason)
+		}
+	}
+	imp.account()
+	for n, v := range imp.vars {
+		if v.used || strings.HasPrefix(n, "my_") {
+			continue
+		}
+		reason, ok := hints[n]
+		if !ok {
+			reason = "no equivalent setting"
+		}
+		imp.unmapped(v.cmd, "set "+n, reason)
+	}
+	sort.SliceStable(imp.Unmapped, func(i, j int) bool {
+		a, b := imp.Unmapped[i], imp.Unmapped[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return imp, nil
+}
+
+// hints tell where the settings of the other aerc configuration files are.
+var hints = map[string]string{
+	"editor":           "see editor in the [compose] section of aerc.conf",
+	"pager":            "see pager in the [viewer] section of aerc.conf",
+	"sort":             "see sort and threading-enabled in the [ui] section of aerc.conf",
+	"sort_aux":         "see sort in the [ui] section of aerc.conf",
+	"date_format":      "see timestamp-format in the [ui] section of aerc.conf",
+	"index_format":     "see index-columns in the [ui] section of aerc.conf",
+	"status_format":    "see the [statusline] section of aerc.conf",
+	"sidebar_width":    "see sidebar-width in the [ui] section of aerc.conf",
+	"mailcap_path":     "see the [filters] and [openers] sections of aerc.conf",
+	"attribution":      "see quoted-reply in the [templates] section of aerc.conf",
+	"forward_format":   "see forwards in the [templates] section of aerc.conf",
+	"new_mail_command": "see new-email in the [triggers] section of aerc.conf",
+	"header_cache":     "see cache-headers in accounts.conf",
+	"mail_check":       "see check-mail in accounts.conf",
+}
+
+func (imp *Import) unmapped(cmd *command, text, reason string) {
+	imp.Unmapped = append(imp.Unmapped, &Unmapped{
+		File: cmd.file, Line: cmd.line, Text: text, Reason: reason,
+	})
+}
+
+// set records the variables of set, unset, toggle and reset commands.
+func (imp *Import) set(cmd *command) {
+	if len(cmd.args) < 2 {
+		imp.unmapped(cmd, cmd.args[0], "missing variable")
+		return
+	}
+	args, raw := cmd.args[1:], cmd.raw[1:]
+	// the value is in the next argument after a trailing =, not after an
+	// empty quoted value like foo=""
+	valueNext := func(i int) bool {
+		return i+1 < len(args) && strings.HasSuffix(raw[i], "=")
+	}
+	for i := 0; i < len(args); i++ {
+		name, value := args[i], "yes"
+		switch {
+		case strings.Contains(name, "="):
+			parts := strings.SplitN(name, "=", 2)
+			name, value = parts[0], parts[1]
+			if value == "" && valueNext(i) {
+				i++
+				value = args[i]
+			}
+		case i+1 < len(args) && strings.HasPrefix(args[i+1], "="):
+			i++
+			value = strings.TrimPrefix(args[i], "=")
+			if value == "" && valueNext(i) {
+				i++
+				value = args[i]
+			}
+		case strings.HasPrefix(name, "no"):
+			name, value = name[2:], "no"
+		}
+		switch {
+		case cmd.args[0] == "unset":
+			value = "no"
+		case cmd.args[0] != "set" ||
+			strings.HasPrefix(name, "inv") ||
+			strings.HasPrefix(name, "?") ||
+			strings.HasPrefix(name, "&"):
+			imp.unmapped(cmd, cmd.args[0]+" "+name,
+				"only plain assignments are supported")
+			continue
+		}
+		imp.vars[name] = &variable{value: imp.expand(value), cmd: cmd}
+	}
+}
+
+// use returns the value of a variable and marks it as converted.
+func (imp *Import) use(name string) (string, bool) {
+	v, ok := imp.vars[name]
+	if !ok || v.value == "" {
+		return "", false
+	}
+	v.used = true
+	return v.value, true
+}
+
+func (imp *Import) alias(cmd *command) {
+	args, raw := cmd.args[1:], cmd.raw[1:]
+	for len(args) > 1 && args[0] == "-group" {
+		args, raw = args[2:], raw[2:]
+	}
+	if len(args) < 2 {
+		imp.unmapped(cmd, cmd.text(2), "missing address")
+		return
+	}
+	addrs, err := mail.ParseAddressList(strings.Join(raw[1:], " "))
+	if err != nil {
+		imp.unmapped(cmd, cmd.text(2), err.Error())
+		return
+	}
+	for _, addr := range addrs {
+		if addr.Name == "" {
+			addr.Name = args[0]
+		}
+		imp.Aliases = append(imp.Aliases, addr)
+	}
+}
+
+// alternates converts the alternate addresses which are not regular
+// expressions to the aliases of the account.
+func (imp *Import) alternates(cmd *command) {
+	for _, arg := range cmd.args[1:] {
+		addr := strings.ReplaceAll(arg, `\.`, ".")
+		addr = strings.Trim(addr, "^$")
+		if strings.ContainsAny(addr, `\*+?[]()|`) || !strings.Contains(addr, "@") {
+			imp.unmapped(cmd, "alternates "+arg, "regular expressions are not supported")
+			continue
+		}
+		if v, ok := imp.vars["alternates"]; ok {
+			addr = v.value + "," + addr
+		}
+		imp.vars["alternates"] = &variable{value: addr, cmd: cmd}
+	}
+}
+
+// account converts the variables of the account settings.
+func (imp *Import) account() {
+	folder, _ := imp.use("folder")
+	if spool, ok := imp.vars["spoolfile"]; ok && folder == "" &&
+		!strings.HasPrefix(spool.value, "+") && !strings.HasPrefix(spool.value, "=") {
+		// the spool file is the only mailbox of the account
+		folder = filepath.Dir(spool.value)
+		if u, err := url.Parse(spool.value); err == nil && u.Scheme != "" {
+			u.Path, u.RawPath = "", ""
+			folder = u.String()
+		}
+	}
+	source := imp.source(folder)
+	if source == "" {
+		for _, cmd := range imp.mailboxes {
+			imp.unmapped(cmd, cmd.text(2), "no folder or spoolfile to define the account")
+		}
+		return
+	}
+	imp.setting("source", source)
+	if pass, ok := imp.use("imap_pass"); ok && isCommand(pass) {
+		imp.setting("source-cred-cmd", strings.Trim(pass, "`"))
+	}
+	if outgoing := imp.outgoing(); outgoing != "" {
+		imp.setting("outgoing", outgoing)
+		if pass, ok := imp.use("smtp_pass"); ok && isCommand(pass) {
+			imp.setting("outgoing-cred-cmd", strings.Trim(pass, "`"))
+		}
+	}
+
+	from := imp.from()
+	if from != nil {
+		imp.setting("from", formatAddress(from))
+	}
+	if imp.Name == "" {
+		imp.Name = "mutt"
+		if from != nil {
+			domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+			if i := strings.Index(domain, "."); i > 0 {
+				imp.Name = domain[:i]
+			}
+		}
+	}
+	if alternates, ok := imp.use("alternates"); ok {
+		imp.setting("aliases", alternates)
+	}
+
+	for _, m := range []struct{ variable, key string }{
+		{"spoolfile", "default"},
+		{"record", "copy-to"},
+		{"postponed", "postpone"},
+	} {
+		v, ok := imp.vars[m.variable]
+		if !ok {
+			continue
+		}
+		if name, ok := imp.mailbox(folder, v.value); ok {
+			v.used = true
+			imp.setting(m.key, name)
+		}
+	}
+	imp.folders(folder)
+
+	if sig, ok := imp.use("signature"); ok {
+		if strings.HasSuffix(sig, "|") {
+			imp.setting("signature-cmd", strings.TrimSpace(strings.TrimSuffix(sig, "|")))
+		} else {
+			imp.setting("signature-file", sig)
+		}
+	}
+	key, ok := imp.use("pgp_default_key")
+	if k, ok2 := imp.use("pgp_sign_as"); ok2 {
+		key, ok = k, true
+	}
+	if ok {
+		imp.setting("pgp-key-id", key)
+	}
+	for _, b := range []struct{ variable, key string }{
+		{"crypt_autosign", "pgp-auto-sign"},
+		{"crypt_opportunistic_encrypt", "pgp-opportunistic-encrypt"},
+	} {
+		if v, ok := imp.use(b.variable); ok {
+			imp.setting(b.key, fmt.Sprint(v == "yes"))
+		}
+	}
+}
+
+func (imp *Import) setting(key, value string) {
+	imp.Account = append(imp.Account, Setting{Key: key, Value: value})
+}
+
+func isCommand(value string) bool {
+	return strings.HasPrefix(value, "`") && strings.HasSuffix(value, "`")
+}
+
+// source returns the source URL of the account from the mutt folder.
+func (imp *Import) source(folder string) string {
+	switch {
+	case folder == "":
+		return ""
+	case strings.HasPrefix(folder, "imap://"), strings.HasPrefix(folder, "imaps://"):
+		u, err := url.Parse(folder)
+		if err != nil {
+			imp.unmapped(imp.vars["folder"].cmd, "set folder", err.Error())
+			return ""
+		}
+		u.Path, u.RawPath = "", ""
+		imp.credentials(u, "imap_user", "imap_pass")
+		return u.String()
+	case strings.Contains(folder, "://"):
+		if v, ok := imp.vars["folder"]; ok {
+			imp.unmapped(v.cmd, "set folder", "unsupported mailbox type")
+		}
+		return ""
+	}
+	scheme := "maildir"
+	if t, _ := imp.use("mbox_type"); strings.EqualFold(t, "mbox") {
+		scheme = "mbox"
+	}
+	return scheme + "://" + folder
+}
+
+// credentials sets the user and password of the account in an URL, the
+// passwords given as commands are not.
+func (imp *Import) credentials(u *url.URL, userVar, passVar string) {
+	user, ok := imp.use(userVar)
+	if !ok && u.User != nil {
+		user = u.User.Username()
+	}
+	if user == "" {
+		return
+	}
+	if pass, ok := imp.vars[passVar]; ok && pass.value != "" && !isCommand(pass.value) {
+		pass.used = true
+		u.User = url.UserPassword(user, pass.value)
+	} else {
+		u.User = url.User(user)
+	}
+}
+
+// outgoing returns the outgoing URL or command of the account.
+func (imp *Import) outgoing() string {
+	if smtp, ok := imp.use("smtp_url"); ok {
+		u, err := url.Parse(smtp)
+		if err != nil {
+			imp.unmapped(imp.vars["smtp_url"].cmd, "set smtp_url", err.Error())
+			return ""
+		}
+		if starttls, ok := imp.use("ssl_starttls"); ok && u.Scheme == "smtp" && starttls == "no" {
+			u.Scheme = "smtp+insecure"
+		}
+		imp.credentials(u, "smtp_user", "smtp_pass")
+		return u.String()
+	}
+	sendmail, _ := imp.use("sendmail")
+	return sendmail
+}
+
+// from returns the from address of the account.
+func (imp *Import) from() *mail.Address {
+	value, ok := imp.use("from")
+	if !ok {
+		return nil
+	}
+	from, err := mail.ParseAddress(value)
+	if err != nil {
+		imp.unmapped(imp.vars["from"].cmd, "set from", err.Error())
+		return nil
+	}
+	if name, ok := imp.use("realname"); ok {
+		from.Name = name
+	}
+	return from
+}
+
+func formatAddress(addr *mail.Address) string {
+	switch {
+	case addr.Name == "":
+		return addr.Address
+	case strings.ContainsAny(addr.Name, `,;:"<>@()[]\`):
+		name := strings.ReplaceAll(addr.Name, `\`, `\\`)
+		name = strings.ReplaceAll(name, `"`, `\"`)
+		return fmt.Sprintf(`"%s" <%s>`, name, addr.Address)
+	default:
+		return fmt.Sprintf("%s <%s>", addr.Name, addr.Address)
+	}
+}
+
+// mailbox returns the folder name of a mutt mailbox.
+func (imp *Import) mailbox(folder, mailbox string) (string, bool) {
+	if strings.HasPrefix(mailbox, "+") || strings.HasPrefix(mailbox, "=") {
+		return mailbox[1:], true
+	}
+	if u, err := url.Parse(mailbox); err == nil && u.Scheme != "" {
+		f, err := url.Parse(folder)
+		if err != nil || f.Host != u.Host {
+			return "", false
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(u.Path, f.Path), "/")
+		return name, name != ""
+	}
+	dir, err := homedir.Expand(folder)
+	if err != nil || folder == "" {
+		return "", false
+	}
+	path, err := homedir.Expand(mailbox)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
+
+// folders converts the mailboxes commands.
+func (imp *Import) folders(folder string) {
+	var names []string
+	for _, cmd := range imp.mailboxes {
+		args := cmd.args[1:]
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			if cmd.args[0] == "named-mailboxes" {
+				if i+1 >= len(args) {
+					imp.unmapped(cmd, cmd.args[0]+" "+arg, "missing mailbox")
+					break
+				}
+				i++
+				arg = args[i]
+			}
+			if strings.HasPrefix(arg, "-") {
+				imp.unmapped(cmd, cmd.args[0]+" "+arg, "options are not supported")
+				continue
+			}
+			name, ok := imp.mailbox(folder, imp.expand(arg))
+			if !ok {
+				imp.unmapped(cmd, cmd.args[0]+" "+arg, "not a folder of the account")
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		imp.setting("folders", strings.Join(names, ","))
+	}
+}
@@ -0,0 +1,149 @@
+package mutt
+
+import (
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~rjarry/aerc/config"
+)
+
+// AccountsConf returns the accounts.conf section of the account, or an empty
+// string if the muttrc has no mailbox.
+func (imp *Import) AccountsConf() string {
+	if len(imp.Account) == 0 {
+		return ""
+	}
+	var conf strings.Builder
+	fmt.Fprintf(&conf, "[%s]\n", imp.Name)
+	for _, s := range imp.Account {
+		fmt.Fprintf(&conf, "%s = %s\n", s.Key, s.Value)
+	}
+	return conf.String()
+}
+
+// BindsConf returns the bindings added to a binds.conf template. The template
+// bindings which would shadow or be shadowed by a mutt binding are commented
+// out.
+func (imp *Import) BindsConf(template string) string {
+	if len(imp.Binds) == 0 && len(imp.Unbinds) == 0 {
+		return ""
+	}
+	var conf strings.Builder
+	written := make(map[string]bool)
+	flush := func(section string) {
+		if written[section] || len(imp.Binds[section]) == 0 {
+			written[section] = true
+			return
+		}
+		written[section] = true
+		conf.WriteString("# imported from mutt\n")
+		for _, b := range imp.Binds[section] {
+			fmt.Fprintf(&conf, "%s = %s\n", b.Key, b.Value)
+		}
+		conf.WriteString("\n")
+	}
+
+	section := "default"
+	for _, line := range strings.SplitAfter(template, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			flush(section)
+			section = strings.Trim(trimmed, "[]")
+		case trimmed != "" && trimmed[0] != '#' && trimmed[0] != ';':
+			i := strings.IndexAny(trimmed, "=:")
+			if i > 0 && imp.shadows(section, strings.TrimSpace(trimmed[:i])) {
+				line = "#" + line
+			}
+		}
+		conf.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			conf.WriteString("\n")
+		}
+	}
+	flush(section)
+	for _, section := range []string{"default", "messages", "view", "compose::review"} {
+		if !written[section] && len(imp.Binds[section]) > 0 {
+			if section != "default" || conf.Len() > 0 {
+				fmt.Fprintf(&conf, "[%s]\n", section)
+			}
+			flush(section)
+		}
+	}
+	return conf.String()
+}
+
+// shadows returns true if a key of a section is unbound or if one of the
+// mutt bindings starts with it or is a prefix of it.
+func (imp *Import) shadows(section, key string) bool {
+	for _, unbound := range imp.Unbinds[section] {
+		if unbound == key {
+			return true
+		}
+	}
+	strokes, err := config.ParseKeyStrokes(strings.Trim(key, `"`))
+	if err != nil {
+		return false
+	}
+	for _, b := range imp.Binds[section] {
+		other, err := config.ParseKeyStrokes(strings.Trim(b.Key, `"`))
+		if err != nil {
+			continue
+		}
+		n := len(strokes)
+		if len(other) < n {
+			n = len(other)
+		}
+		same := true
+		for i := 0; i < n; i++ {
+			if strokes[i] != other[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return true
+		}
+	}
+	return false
+}
+
+// StyleSet returns the styles added to a styleset template. The template
+// settings of the objects styled by mutt colors are commented out.
+func (imp *Import) StyleSet(template string) string {
+	if len(imp.Styles) == 0 && len(imp.ViewerStyles) == 0 {
+		return ""
+	}
+	objects := make(map[string]bool)
+	for _, s := range imp.Styles {
+		objects[s.Key[:strings.LastIndex(s.Key, ".")]] = true
+	}
+	var styleset strings.Builder
+	if template == "" {
+		template = "*.default=true\n"
+	}
+	for _, line := range strings.SplitAfter(template, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && trimmed[0] != '#' {
+			key := strings.TrimSpace(strings.SplitN(trimmed, "=", 2)[0])
+			i := strings.LastIndex(key, ".")
+			if i > 0 && objects[key[:i]] && key[i:] != ".default" {
+				line = "#" + line
+			}
+		}
+		styleset.WriteString(line)
+	}
+	styleset.WriteString("\n# imported from mutt\n")
+	for _, s := range imp.Styles {
+		fmt.Fprintf(&styleset, "%s=%s\n", s.Key, s.Value)
+	}
+	if len(imp.ViewerStyles) > 0 {
+		styleset.WriteString("\n[viewer]\n")
+		for _, s := range imp.ViewerStyles {
+			fmt.Fprintf(&styleset, "%s=%s\n", s.Key, s.Value)
+		}
+	}
+	return styleset.String()
+}
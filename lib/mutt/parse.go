@@ -0,0 +1,197 @@
+package mutt
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// command is a muttrc command split into its arguments.
+type command struct {
+	file string
+	line int
+	args []string
+	// raw holds the arguments as written
+	raw []string
+}
+
+// text returns the command as written, without its arguments beyond max.
+func (c *command) text(max int) string {
+	if len(c.args) > max {
+		return strings.Join(c.args[:max], " ") + " ..."
+	}
+	return strings.Join(c.args, " ")
+}
+
+// readFile returns the commands of a muttrc file, including the ones of the
+// files it sources.
+func (imp *Import) readFile(filename string) ([]*command, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	if imp.sourced[abs] {
+		return nil, fmt.Errorf("%s: sourced recursively", filename)
+	}
+	imp.sourced[abs] = true
+	defer delete(imp.sourced, abs)
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cmds []*command
+	var text strings.Builder
+	start := 0
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if text.Len() == 0 {
+			start = n
+		}
+		// a trailing backslash continues the line
+		if strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") {
+			text.WriteString(strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		text.WriteString(line)
+		for _, cmd := range split(text.String()) {
+			cmd.file, cmd.line = filename, start
+			if cmd.args[0] != "source" {
+				cmds = append(cmds, cmd)
+				continue
+			}
+			sourced, err := imp.sourceFiles(cmd)
+			if err != nil {
+				return nil, err
+			}
+			cmds = append(cmds, sourced...)
+		}
+		text.Reset()
+	}
+	return cmds, scanner.Err()
+}
+
+// sourceFiles reads the files of a source command. The paths are relative to
+// the directory of the current file.
+func (imp *Import) sourceFiles(cmd *command) ([]*command, error) {
+	var cmds []*command
+	for _, arg := range cmd.args[1:] {
+		if strings.HasSuffix(arg, "|") {
+			imp.unmapped(cmd, cmd.text(2), "the output of commands cannot be sourced")
+			continue
+		}
+		p, err := homedir.Expand(imp.expand(arg))
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(cmd.file), p)
+		}
+		sourced, err := imp.readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, sourced...)
+	}
+	return cmds, nil
+}
+
+// split parses the arguments of the commands of a line. Quotes are removed.
+// The backslash escapes of double quoted and unquoted arguments are kept,
+// they are interpreted with the keys and macros. Backticks are kept around
+// the commands to run.
+func split(line string) []*command {
+	var cmds []*command
+	cmd := &command{}
+	var arg strings.Builder
+	inArg := false
+	start := 0
+	runes := []rune(line)
+	i := 0
+	end := func() {
+		if inArg {
+			cmd.args = append(cmd.args, arg.String())
+			cmd.raw = append(cmd.raw, string(runes[start:i]))
+			arg.Reset()
+			inArg = false
+		}
+	}
+	endCommand := func() {
+		end()
+		if len(cmd.args) > 0 {
+			cmds = append(cmds, cmd)
+			cmd = &command{}
+		}
+	}
+
+	for ; i < len(runes); i++ {
+		r := runes[i]
+		if !inArg {
+			start = i
+		}
+		switch {
+		case r == ' ' || r == '\t':
+			end()
+		case r == ';':
+			endCommand()
+		case r == '#' && !inArg:
+			endCommand()
+			return cmds
+		case r == '\'' || r == '"' || r == '`':
+			inArg = true
+			if r == '`' {
+				arg.WriteRune(r)
+			}
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if r == '"' && runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					if runes[i] != '"' && runes[i] != '\\' {
+						arg.WriteRune('\\')
+					}
+				}
+				arg.WriteRune(runes[i])
+			}
+			if r == '`' {
+				arg.WriteRune(r)
+			}
+		case r == '\\' && i+1 < len(runes):
+			inArg = true
+			i++
+			if !strings.ContainsRune(" \t;#'\"`", runes[i]) {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(runes[i])
+		default:
+			inArg = true
+			arg.WriteRune(r)
+		}
+	}
+	i = len(runes)
+	endCommand()
+	return cmds
+}
+
+var varRe = regexp.MustCompile(`\$(\{\w+\}|\w+)`)
+
+// expand replaces the references to the variables set so far and to the
+// environment variables.
+func (imp *Import) expand(value string) string {
+	return varRe.ReplaceAllStringFunc(value, func(ref string) string {
+		name := strings.Trim(ref[1:], "{}")
+		if v, ok := imp.vars[name]; ok {
+			return v.value
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return ref
+	})
+}
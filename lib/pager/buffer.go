@@ -0,0 +1,187 @@
+// Package pager holds the text model of the built-in pager: lines with ANSI
+// styles, line wrapping and searching.
+package pager
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"git.sr.ht/~rjarry/aerc/lib/parse"
+)
+
+const tabWidth = 8
+
+// A Line is a line of text with its styles.
+type Line struct {
+	Runes []*parse.StyledRune
+	// Text is the plain text of the line, for searching
+	Text string
+}
+
+// NewLine parses a line of text with ANSI escape sequences. Tabs are expanded
+// and carriage returns removed.
+func NewLine(s string) Line {
+	var line Line
+	var text strings.Builder
+	width := 0
+	for _, r := range parse.ParseANSI(s).Runes() {
+		switch r.Value {
+		case '\r':
+			continue
+		case '\t':
+			n := tabWidth - width%tabWidth
+			for i := 0; i < n; i++ {
+				line.Runes = append(line.Runes,
+					&parse.StyledRune{Value: ' ', Width: 1, Style: r.Style})
+				text.WriteRune(' ')
+			}
+			width += n
+			continue
+		}
+		line.Runes = append(line.Runes, r)
+		text.WriteRune(r.Value)
+		width += r.Width
+	}
+	line.Text = text.String()
+	return line
+}
+
+// A Buffer accumulates text written to it and splits it in lines. It may be
+// written and read concurrently.
+type Buffer struct {
+	mu       sync.Mutex
+	lines    []Line
+	partial  []byte
+	onChange func()
+}
+
+// NewBuffer creates an empty buffer. onChange is called after each write.
+func NewBuffer(onChange func()) *Buffer {
+	return &Buffer{onChange: onChange}
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+		b.lines = append(b.lines, NewLine(string(b.partial[:i])))
+		b.partial = b.partial[i+1:]
+	}
+	b.mu.Unlock()
+	if b.onChange != nil {
+		b.onChange()
+	}
+	return len(p), nil
+}
+
+// Close flushes the last line if it has no trailing newline.
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	if len(b.partial) > 0 {
+		b.lines = append(b.lines, NewLine(string(b.partial)))
+		b.partial = nil
+	}
+	b.mu.Unlock()
+	if b.onChange != nil {
+		b.onChange()
+	}
+	return nil
+}
+
+// Lines returns the complete lines written so far.
+func (b *Buffer) Lines() []Line {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lines[:len(b.lines):len(b.lines)]
+}
+
+// A Row is a part of a line displayed on one screen row: the runes from
+// Start to End (excluded).
+type Row struct {
+	Line  int
+	Start int
+	End   int
+}
+
+// Wrap splits lines in rows of at most width cells. Empty lines are one empty
+// row.
+func Wrap(lines []Line, width int) []Row {
+	var rows []Row
+	if width <= 0 {
+		return rows
+	}
+	for l, line := range lines {
+		start, w := 0, 0
+		for i, r := range line.Runes {
+			if w+r.Width > width && i > start {
+				rows = append(rows, Row{Line: l, Start: start, End: i})
+				start, w = i, 0
+			}
+			w += r.Width
+		}
+		rows = append(rows, Row{Line: l, Start: start, End: len(line.Runes)})
+	}
+	return rows
+}
+
+// A Match is an occurrence of a search pattern: the runes from Start to End
+// (excluded) of a line.
+type Match struct {
+	Line  int
+	Start int
+	End   int
+}
+
+// Search returns all non-empty matches of a pattern in lines.
+func Search(lines []Line, re *regexp.Regexp) []Match {
+	var matches []Match
+	for l, line := range lines {
+		for _, loc := range re.FindAllStringIndex(line.Text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			matches = append(matches, Match{
+				Line:  l,
+				Start: utf8.RuneCountInString(line.Text[:loc[0]]),
+				End:   utf8.RuneCountInString(line.Text[:loc[1]]),
+			})
+		}
+	}
+	return matches
+}
+
+// Text returns the text of the lines from, to (included) and their number.
+// The range is clamped to the existing lines, it is empty when there are none.
+func Text(lines []Line, from, to int) (string, int) {
+	if from < 0 {
+		from = 0
+	}
+	if to >= len(lines) {
+		to = len(lines) - 1
+	}
+	if from > to {
+		return "", 0
+	}
+	var text strings.Builder
+	for _, line := range lines[from : to+1] {
+		text.WriteString(line.Text)
+		text.WriteRune('\n')
+	}
+	return text.String(), to - from + 1
+}
+
+// Compile compiles a search pattern. Like in less, patterns are regular
+// expressions, case insensitive if they have no upper case letters.
+func Compile(pattern string) (*regexp.Regexp, error) {
+	if strings.ToLower(pattern) == pattern {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
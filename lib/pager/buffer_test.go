@@ -0,0 +1,96 @@
+package pager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	changes := 0
+	b := NewBuffer(func() { changes++ })
+	if _, err := b.Write([]byte("first\r\nsec")); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(b.Lines()); n != 1 {
+		t.Fatalf("expected 1 line, got %d", n)
+	}
+	if _, err := b.Write([]byte("ond\n\x1b[1mbold\x1b[0m\ta")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, line := range b.Lines() {
+		texts = append(texts, line.Text)
+	}
+	expected := []string{"first", "second", "bold    a"}
+	if !reflect.DeepEqual(texts, expected) {
+		t.Errorf("expected %q, got %q", expected, texts)
+	}
+	if changes != 3 {
+		t.Errorf("expected 3 changes, got %d", changes)
+	}
+	_, _, attrs := b.Lines()[2].Runes[0].Style.Decompose()
+	if attrs == 0 {
+		t.Errorf("expected bold style")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := []Line{NewLine("abcdefgh"), NewLine(""), NewLine("日本語x")}
+	rows := Wrap(lines, 3)
+	expected := []Row{
+		{0, 0, 3}, {0, 3, 6}, {0, 6, 8},
+		{1, 0, 0},
+		{2, 0, 1}, {2, 1, 2}, {2, 2, 4},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	lines := []Line{NewLine("Héllo hello"), NewLine("nothing"), NewLine("\x1b[31mHELLO\x1b[0m")}
+	re, err := Compile("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Match{{0, 6, 11}, {2, 0, 5}}
+	if matches := Search(lines, re); !reflect.DeepEqual(matches, expected) {
+		t.Errorf("expected %v, got %v", expected, matches)
+	}
+	re, err = Compile("H.llo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Match{{0, 0, 5}}
+	if matches := Search(lines, re); !reflect.DeepEqual(matches, expected) {
+		t.Errorf("expected %v, got %v", expected, matches)
+	}
+}
+
+func TestText(t *testing.T) {
+	lines := []Line{NewLine("one"), NewLine("two"), NewLine("three")}
+	tests := []struct {
+		lines  []Line
+		from   int
+		to     int
+		expect string
+		n      int
+	}{
+		{lines, 0, 1, "one\ntwo\n", 2},
+		{lines, 2, 2, "three\n", 1},
+		{lines, -1, 5, "one\ntwo\nthree\n", 3},
+		{lines, 4, 6, "", 0},
+		{nil, 0, 0, "", 0},
+		{NewBuffer(nil).Lines(), 0, 0, "", 0},
+	}
+	for _, test := range tests {
+		text, n := Text(test.lines, test.from, test.to)
+		if text != test.expect || n != test.n {
+			t.Errorf("%d-%d: expected %q (%d), got %q (%d)",
+				test.from, test.to, test.expect, test.n, text, n)
+		}
+	}
+}
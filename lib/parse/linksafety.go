@@ -0,0 +1,153 @@
+package parse
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// urlShorteners are services which hide the actual destination of links.
+var urlShorteners = map[string]bool{
+	"bit.ly": true, "bitly.com": true, "t.co": true, "tinyurl.com": true,
+	"goo.gl": true, "ow.ly": true, "is.gd": true, "v.gd": true,
+	"buff.ly": true, "rebrand.ly": true, "cutt.ly": true,
+	"shorturl.at": true, "tiny.cc": true, "lnkd.in": true, "rb.gy": true,
+	"t.ly": true, "s.id": true, "bl.ink": true, "short.io": true,
+	"tiny.one": true, "shorte.st": true, "adf.ly": true, "qr.ae": true,
+}
+
+// confusables maps non-latin letters to the latin letters they resemble.
+var confusables = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i',
+	'ј': 'j', 'к': 'k', 'ӏ': 'l', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'ԛ': 'q', 'ѕ': 's', 'т': 't', 'ц': 'u', 'ѵ': 'v', 'ԝ': 'w', 'х': 'x',
+	'у': 'y', 'ү': 'y',
+	// greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// latin lookalikes
+	'ı': 'i', 'ɑ': 'a', 'ɡ': 'g', 'ł': 'l', 'ø': 'o',
+}
+
+var textDomainRe = regexp.MustCompile(
+	`(?i)(?:^|[^\w.@-])((?:[\p{L}\d](?:[\p{L}\d-]*[\p{L}\d])?\.)+[\p{L}]{2,63})(?:$|[^\w-])`)
+
+// CheckLink returns the reasons why a link may be deceptive, or nothing if
+// it looks safe. The texts are the visible texts of the link, if any.
+func CheckLink(link string, texts ...string) []string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return []string{"malformed URL"}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	var reasons []string
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+
+	if u.User != nil {
+		reasons = append(reasons, fmt.Sprintf(
+			"the text before @ is not the destination (%s)", host))
+	}
+	if net.ParseIP(host) != nil {
+		reasons = append(reasons, "destination is an IP address")
+	}
+	if urlShorteners[host] || urlShorteners[strings.TrimPrefix(host, "www.")] {
+		reasons = append(reasons, fmt.Sprintf(
+			"URL shortener hides the destination (%s)", host))
+	}
+	if reason := checkIDN(host); reason != "" {
+		reasons = append(reasons, reason)
+	}
+
+	dest := RegistrableDomain(host)
+	for _, text := range texts {
+		shown := textDomain(text)
+		if shown == "" || dest == "" {
+			continue
+		}
+		if RegistrableDomain(shown) != dest {
+			reasons = append(reasons, fmt.Sprintf(
+				"text shows %s but the link goes to %s", shown, host))
+			break
+		}
+	}
+	return reasons
+}
+
+// checkIDN reports internationalized domain names, and the ascii domain they
+// imitate if they are made of lookalike characters.
+func checkIDN(host string) string {
+	unicodeHost, err := idna.ToUnicode(host)
+	if err != nil || isASCII(unicodeHost) {
+		return ""
+	}
+	if skeleton := Skeleton(unicodeHost); isASCII(skeleton) {
+		return fmt.Sprintf("%s uses lookalike characters to imitate %s",
+			unicodeHost, skeleton)
+	}
+	return fmt.Sprintf("internationalized domain name %s", unicodeHost)
+}
+
+// Skeleton replaces the non-latin letters of s with the latin letters they
+// resemble.
+func Skeleton(s string) string {
+	var skeleton strings.Builder
+	for _, r := range s {
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		skeleton.WriteRune(r)
+	}
+	return skeleton.String()
+}
+
+// textDomain returns the first domain name shown in a text, if any. Only
+// domains with a known public suffix are considered to avoid mistaking file
+// names for domains.
+func textDomain(text string) string {
+	for _, m := range textDomainRe.FindAllStringSubmatch(text, -1) {
+		domain, err := idna.ToASCII(strings.ToLower(m[1]))
+		if err != nil {
+			continue
+		}
+		suffix, icann := publicsuffix.PublicSuffix(domain)
+		if (icann || strings.Contains(suffix, ".")) && suffix != domain {
+			return domain
+		}
+	}
+	return ""
+}
+
+// RegistrableDomain returns the domain registered under a public suffix
+// (example.co.uk for www.example.co.uk), in lower case ASCII. Domains without
+// known public suffix are returned whole.
+func RegistrableDomain(domain string) string {
+	if domain == "" {
+		return ""
+	}
+	ascii, err := idna.ToASCII(strings.ToLower(domain))
+	if err != nil {
+		return domain
+	}
+	if d, err := publicsuffix.EffectiveTLDPlusOne(ascii); err == nil {
+		return d
+	}
+	return ascii
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		link    string
+		text    string
+		warning string
+	}{
+		{"https://www.example.com/login", "Log in", ""},
+		{"https://mail.example.com/login", "www.example.com", ""},
+		{"https://example.com/report", "report.pdf", ""},
+		{"mailto:x@example.org", "paypal.com", ""},
+		{"https://evil.example.net/", "https://www.paypal.com/signin", "text shows www.paypal.com"},
+		{"https://xn--pypal-4ve.com/", "", "imitate paypal.com"},
+		{"https://xn--mnchen-3ya.de/", "", "internationalized domain name münchen.de"},
+		{"https://bit.ly/3abc", "", "URL shortener"},
+		{"http://192.0.2.1/login", "", "IP address"},
+		{"https://www.paypal.com@evil.example.net/", "", "before @"},
+	}
+	for _, test := range tests {
+		var texts []string
+		if test.text != "" {
+			texts = append(texts, test.text)
+		}
+		reasons := CheckLink(test.link, texts...)
+		joined := strings.Join(reasons, "; ")
+		switch {
+		case test.warning == "" && len(reasons) > 0:
+			t.Errorf("%s: unexpected warning: %s", test.link, joined)
+		case test.warning != "" && !strings.Contains(joined, test.warning):
+			t.Errorf("%s: expected %q, got %q", test.link, test.warning, joined)
+		}
+	}
+}
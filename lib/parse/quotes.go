@@ -0,0 +1,57 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A Block is a run of consecutive lines of a plain text message that are
+// either all quoted or all written by the sender.
+type Block struct {
+	Lines  []string
+	Quoted bool
+}
+
+var (
+	quotedRe      = regexp.MustCompile(`^\s*>`)
+	attributionRe = regexp.MustCompile(`(?i)(wrote|writes|said|schrieb|a écrit|escribió)\s*:\s*$`)
+)
+
+// SplitQuotes splits a plain text message into quoted and unquoted blocks.
+// The attribution line introducing a quote ("On ..., X wrote:") and the
+// blank lines between it and the quote are part of the quoted block.
+func SplitQuotes(text string) []Block {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	quoted := make([]bool, len(lines))
+	for i, line := range lines {
+		quoted[i] = quotedRe.MatchString(line)
+	}
+	for i := range lines {
+		if !quoted[i] || i == 0 || quoted[i-1] {
+			continue
+		}
+		j := i - 1
+		for j > 0 && strings.TrimSpace(lines[j]) == "" {
+			j--
+		}
+		if attributionRe.MatchString(lines[j]) {
+			for k := j; k < i; k++ {
+				quoted[k] = true
+			}
+		}
+	}
+
+	var blocks []Block
+	for i, line := range lines {
+		if len(blocks) == 0 || blocks[len(blocks)-1].Quoted != quoted[i] {
+			blocks = append(blocks, Block{Quoted: quoted[i]})
+		}
+		b := &blocks[len(blocks)-1]
+		b.Lines = append(b.Lines, line)
+	}
+	return blocks
+}
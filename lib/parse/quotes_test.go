@@ -0,0 +1,37 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitQuotes(t *testing.T) {
+	tests := []struct {
+		text   string
+		blocks []Block
+	}{
+		{"", nil},
+		{"hello\r\nworld\r\n", []Block{
+			{Lines: []string{"hello", "world"}},
+		}},
+		{"> a\n> b\n\nreply\n", []Block{
+			{Lines: []string{"> a", "> b"}, Quoted: true},
+			{Lines: []string{"", "reply"}},
+		}},
+		{"Hi,\n\nOn Mon, Bob wrote:\n\n> question\n>\n> more\nanswer", []Block{
+			{Lines: []string{"Hi,", ""}},
+			{Lines: []string{"On Mon, Bob wrote:", "", "> question", ">", "> more"}, Quoted: true},
+			{Lines: []string{"answer"}},
+		}},
+		{"text\n  > indented", []Block{
+			{Lines: []string{"text"}},
+			{Lines: []string{"  > indented"}, Quoted: true},
+		}},
+	}
+	for _, test := range tests {
+		blocks := SplitQuotes(test.text)
+		if !reflect.DeepEqual(blocks, test.blocks) {
+			t.Errorf("%q: expected %#v, got %#v", test.text, test.blocks, blocks)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package parse
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// trackingParams are query parameters used to identify recipients or
+// campaigns. Parameters starting with utm_ are also matched.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true,
+	"yclid": true, "igshid": true, "mc_cid": true, "mc_eid": true,
+	"_hsenc": true, "_hsmi": true, "__hssc": true, "__hstc": true,
+	"__hsfp": true, "hsctatracking": true, "mkt_tok": true,
+	"oly_anon_id": true, "oly_enc_id": true, "vero_id": true,
+	"vero_conv": true, "_openstat": true, "wickedid": true,
+	"ss_source": true, "ss_campaign_id": true, "trk": true,
+	"trkcampaign": true, "sc_cid": true, "ck_subscriber_id": true,
+}
+
+// trackerDomains maps the domains of known mail tracking services to their
+// vendor. Subdomains are matched as well.
+var trackerDomains = map[string]string{
+	"list-manage.com":      "Mailchimp",
+	"mailchimp.com":        "Mailchimp",
+	"sendgrid.net":         "SendGrid",
+	"mandrillapp.com":      "Mandrill",
+	"hubspot.com":          "HubSpot",
+	"hubspotlinks.com":     "HubSpot",
+	"hubspotemail.net":     "HubSpot",
+	"doubleclick.net":      "Google",
+	"google-analytics.com": "Google",
+	"exacttarget.com":      "Salesforce Marketing Cloud",
+	"exct.net":             "Salesforce Marketing Cloud",
+	"rs6.net":              "Constant Contact",
+	"klaviyo.com":          "Klaviyo",
+	"klclick.com":          "Klaviyo",
+	"customeriomail.com":   "Customer.io",
+	"sparkpostmail.com":    "SparkPost",
+	"awstrack.me":          "Amazon SES",
+	"mailtrack.io":         "Mailtrack",
+	"mixpanel.com":         "Mixpanel",
+	"iterable.com":         "Iterable",
+	"convertkit-mail.com":  "ConvertKit",
+	"mlsend.com":           "MailerLite",
+	"intercom-mail.com":    "Intercom",
+}
+
+// IsTrackingParam returns true if the query parameter is known to be used
+// for tracking.
+func IsTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || trackingParams[name]
+}
+
+// TrackerVendor returns the vendor of a known tracking service if the host
+// belongs to one, or an empty string.
+func TrackerVendor(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for {
+		if vendor, ok := trackerDomains[host]; ok {
+			return vendor
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return ""
+		}
+		host = host[i+1:]
+	}
+}
+
+// StripTrackingParams removes the tracking query parameters from an http(s)
+// link. The order of the other parameters is preserved. Links that cannot
+// be parsed are returned as is.
+func StripTrackingParams(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+		u.RawQuery == "" {
+		return link
+	}
+	var kept []string
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		name := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			name = param[:i]
+		}
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if !IsTrackingParam(name) {
+			kept = append(kept, param)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	return u.String()
+}
+
+// PrivacyReport summarizes the remote content found in an HTML document.
+type PrivacyReport struct {
+	// RemoteImages is the number of images loaded from remote servers,
+	// including tracking pixels.
+	RemoteImages int
+	// TrackingPixels is the number of tiny or hidden remote images.
+	TrackingPixels int
+	// TrackingLinks is the number of links with tracking parameters or
+	// pointing to a tracking service.
+	TrackingLinks int
+	// Trackers are the vendors of known tracking services, sorted.
+	Trackers []string
+}
+
+// Clean returns true if no remote content nor tracking was found.
+func (p *PrivacyReport) Clean() bool {
+	return p.RemoteImages == 0 && p.TrackingLinks == 0 &&
+		len(p.Trackers) == 0
+}
+
+func (p *PrivacyReport) String() string {
+	if p.Clean() {
+		return "no remote content"
+	}
+	plural := func(n int, what string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, what)
+		}
+		return fmt.Sprintf("%d %ss", n, what)
+	}
+	var parts []string
+	if p.RemoteImages > 0 {
+		parts = append(parts, plural(p.RemoteImages, "remote image"))
+	}
+	if p.TrackingPixels > 0 {
+		parts = append(parts, plural(p.TrackingPixels, "tracking pixel"))
+	}
+	if p.TrackingLinks > 0 {
+		parts = append(parts, plural(p.TrackingLinks, "tracking link"))
+	}
+	s := strings.Join(parts, ", ")
+	if len(p.Trackers) > 0 {
+		if s != "" {
+			s += " "
+		}
+		s += "(" + strings.Join(p.Trackers, ", ") + ")"
+	}
+	return s
+}
+
+var cssURLRe = regexp.MustCompile(`(?i)url\(\s*['"]?(https?://[^'")\s]+)`)
+
+// AnalyzeHTML looks for remote images, tracking pixels, tracking links and
+// known tracking services in an HTML document.
+func AnalyzeHTML(r io.Reader) (*PrivacyReport, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	report := &PrivacyReport{}
+	vendors := make(map[string]bool)
+	remote := func(link string) *url.URL {
+		u, err := url.Parse(strings.TrimSpace(link))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil
+		}
+		if vendor := TrackerVendor(u.Hostname()); vendor != "" {
+			vendors[vendor] = true
+		}
+		return u
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Img:
+				if remote(attrValue(n, "src")) != nil {
+					report.RemoteImages++
+					if isPixel(n) {
+						report.TrackingPixels++
+					}
+				}
+			case atom.A, atom.Area:
+				if u := remote(attrValue(n, "href")); u != nil {
+					if isTrackingLink(u) {
+						report.TrackingLinks++
+					}
+				}
+			}
+			if remote(attrValue(n, "background")) != nil {
+				report.RemoteImages++
+			}
+			for _, m := range cssURLRe.FindAllStringSubmatch(attrValue(n, "style"), -1) {
+				if remote(m[1]) != nil {
+					report.RemoteImages++
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for vendor := range vendors {
+		report.Trackers = append(report.Trackers, vendor)
+	}
+	sort.Strings(report.Trackers)
+	return report, nil
+}
+
+func isTrackingLink(u *url.URL) bool {
+	if TrackerVendor(u.Hostname()) != "" {
+		return true
+	}
+	for name := range u.Query() {
+		if IsTrackingParam(name) {
+			return true
+		}
+	}
+	return false
+}
+
+var cssSizeRe = regexp.MustCompile(`(?i)(?:^|[;\s])(width|height)\s*:\s*([0-9.]+)px`)
+
+// isPixel returns true for images of at most 1x1 pixels or hidden images.
+func isPixel(n *html.Node) bool {
+	small := func(v string) bool {
+		size, err := strconv.ParseFloat(strings.TrimSuffix(
+			strings.TrimSpace(v), "px"), 64)
+		return err == nil && size <= 1
+	}
+	width, height := attrValue(n, "width"), attrValue(n, "height")
+	style := strings.ToLower(attrValue(n, "style"))
+	for _, m := range cssSizeRe.FindAllStringSubmatch(style, -1) {
+		if m[1] == "width" {
+			width = m[2]
+		} else {
+			height = m[2]
+		}
+	}
+	if small(width) && small(height) {
+		return true
+	}
+	style = strings.ReplaceAll(style, " ", "")
+	return strings.Contains(style, "display:none") ||
+		strings.Contains(style, "visibility:hidden")
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
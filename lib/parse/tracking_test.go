@@ -0,0 +1,82 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripTrackingParams(t *testing.T) {
+	tests := []struct {
+		link     string
+		expected string
+	}{
+		{
+			"https://example.com/a?id=3&utm_source=news&UTM_Medium=mail&b=2",
+			"https://example.com/a?id=3&b=2",
+		},
+		{
+			"https://example.com/?fbclid=abc",
+			"https://example.com/",
+		},
+		{
+			"https://example.com/?q=utm_source",
+			"https://example.com/?q=utm_source",
+		},
+		{"mailto:foo@example.com?utm_source=x", "mailto:foo@example.com?utm_source=x"},
+		{"not a url%%", "not a url%%"},
+	}
+	for _, test := range tests {
+		if got := StripTrackingParams(test.link); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.link, test.expected, got)
+		}
+	}
+}
+
+func TestTrackerVendor(t *testing.T) {
+	if v := TrackerVendor("mc.us4.List-Manage.com"); v != "Mailchimp" {
+		t.Errorf("expected Mailchimp, got %q", v)
+	}
+	if v := TrackerVendor("notsendgrid.net"); v != "" {
+		t.Errorf("expected no vendor, got %q", v)
+	}
+}
+
+func TestAnalyzeHTML(t *testing.T) {
+	doc := `<html><body style="background: url('https://cdn.example.com/bg.png')">
+<img src="https://example.com/logo.png" width="200">
+<img src="https://open.sendgrid.net/wf/open?upn=x" width="1" height="1">
+<img src="https://example.com/p.gif" style="width:1px; height:1px">
+<img src="cid:inline@example.com" width="1" height="1">
+<a href="https://example.com/article?utm_campaign=spring">read</a>
+<a href="https://u123.ct.sendgrid.net/ls/click?upn=y">click</a>
+<a href="https://example.com/plain">plain</a>
+</body></html>`
+	report, err := AnalyzeHTML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.RemoteImages != 4 {
+		t.Errorf("expected 4 remote images, got %d", report.RemoteImages)
+	}
+	if report.TrackingPixels != 2 {
+		t.Errorf("expected 2 tracking pixels, got %d", report.TrackingPixels)
+	}
+	if report.TrackingLinks != 2 {
+		t.Errorf("expected 2 tracking links, got %d", report.TrackingLinks)
+	}
+	if len(report.Trackers) != 1 || report.Trackers[0] != "SendGrid" {
+		t.Errorf("unexpected trackers: %v", report.Trackers)
+	}
+	expected := "4 remote images, 2 tracking pixels, 2 tracking links (SendGrid)"
+	if report.String() != expected {
+		t.Errorf("expected %q, got %q", expected, report.String())
+	}
+
+	clean, err := AnalyzeHTML(strings.NewReader(`<p>Hello</p>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !clean.Clean() || clean.String() != "no remote content" {
+		t.Errorf("expected clean report, got %q", clean.String())
+	}
+}
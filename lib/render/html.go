@@ -0,0 +1,714 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~rjarry/aerc/lib/parse"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLOptions control how HTML is rendered to text.
+type HTMLOptions struct {
+	// Width is the maximum number of columns of the output.
+	Width int
+	// Style returns the style of the [viewer] styleset objects (url,
+	// header, quote_1, etc.). When nil, no colors are used.
+	Style func(name string) tcell.Style
+	// StripTracking removes tracking query parameters from links.
+	StripTracking bool
+}
+
+// Link is a link target and the texts it is displayed with.
+type Link struct {
+	URL   string
+	Texts []string
+}
+
+// HTML renders an HTML document as text with ANSI escape sequences. Links are
+// numbered in order of appearance and listed at the end of the text along
+// with warnings about deceptive links. The returned links are in the same
+// order, links[0] being [1].
+func HTML(r io.Reader, opts HTMLOptions) (string, []Link, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", nil, err
+	}
+	if opts.Width <= 0 {
+		opts.Width = 80
+	}
+	if opts.Style == nil {
+		opts.Style = func(string) tcell.Style { return tcell.StyleDefault }
+	}
+	links := &linkSet{index: make(map[string]int)}
+	hr := newHTMLRenderer(opts, links, tcell.StyleDefault)
+	hr.walk(doc)
+	hr.flush()
+
+	var out strings.Builder
+	for _, line := range trimBlankLines(hr.lines) {
+		out.WriteString(line.String())
+		out.WriteString("\n")
+	}
+	if len(links.links) > 0 {
+		header := &parse.RuneBuffer{}
+		writeString(header, "Links:", opts.Style("header"))
+		out.WriteString("\n" + header.String() + "\n")
+		for i, link := range links.links {
+			line := &parse.RuneBuffer{}
+			writeString(line, fmt.Sprintf("[%d] ", i+1), tcell.StyleDefault)
+			writeString(line, link.URL, opts.Style("url"))
+			out.WriteString(line.String() + "\n")
+			for _, reason := range parse.CheckLink(link.URL, link.Texts...) {
+				out.WriteString("    warning: " + reason + "\n")
+			}
+		}
+	}
+	return out.String(), links.links, nil
+}
+
+// linkSet numbers unique link targets.
+type linkSet struct {
+	links []Link
+	index map[string]int
+}
+
+func (l *linkSet) add(u string, text string) int {
+	i, ok := l.index[u]
+	if !ok {
+		l.links = append(l.links, Link{URL: u})
+		i = len(l.links)
+		l.index[u] = i
+	}
+	if text != "" {
+		l.links[i-1].Texts = append(l.links[i-1].Texts, text)
+	}
+	return i
+}
+
+// word is a unit of inline content. An empty word with br set is a forced
+// line break.
+type word struct {
+	text  string
+	style tcell.Style
+	space bool
+	br    bool
+	pre   bool
+}
+
+// block is a level of nesting that prefixes every line it contains, for
+// example a list item bullet or a quote marker. The first prefix is used for
+// the first line only.
+type block struct {
+	parent *block
+	first  string
+	rest   string
+	style  tcell.Style
+	used   bool
+}
+
+func (b *block) prefix(rb *parse.RuneBuffer, blank bool) {
+	if b == nil {
+		return
+	}
+	b.parent.prefix(rb, blank)
+	p := b.first
+	if b.used || blank {
+		p = b.rest
+	}
+	writeString(rb, p, b.style)
+}
+
+func (b *block) width() int {
+	if b == nil {
+		return 0
+	}
+	return b.parent.width() + runewidth.StringWidth(b.rest)
+}
+
+func (b *block) markUsed() {
+	for ; b != nil; b = b.parent {
+		b.used = true
+	}
+}
+
+type list struct {
+	ordered bool
+	next    int
+}
+
+type htmlRenderer struct {
+	opts  HTMLOptions
+	links *linkSet
+	lines []*parse.RuneBuffer
+
+	block *block
+	words []word
+	space bool
+	// pending blank line, prefixed like the block that requested it
+	blank      bool
+	blankBlock *block
+
+	style tcell.Style
+	pre   int
+	quote int
+	lists []*list
+
+	// unwrapped widths of the table cells, shared with the cell renderers
+	// so that nested tables are only measured once
+	widths map[*html.Node]int
+}
+
+func newHTMLRenderer(
+	opts HTMLOptions, links *linkSet, style tcell.Style,
+) *htmlRenderer {
+	return &htmlRenderer{
+		opts: opts, links: links, style: style,
+		widths: make(map[*html.Node]int),
+	}
+}
+
+// blockElements start on a new line. Those with a margin are separated from
+// their surroundings by a blank line.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: false, atom.Article: false, atom.Aside: false,
+	atom.Blockquote: true, atom.Center: false, atom.Details: false,
+	atom.Dd: false, atom.Div: false, atom.Dl: true, atom.Dt: false,
+	atom.Fieldset: false, atom.Figcaption: false, atom.Figure: true,
+	atom.Footer: false, atom.Form: false, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: false, atom.Hr: true, atom.Li: false, atom.Main: false,
+	atom.Nav: false, atom.Ol: true, atom.P: true, atom.Pre: true,
+	atom.Section: false, atom.Summary: false, atom.Table: true,
+	atom.Tr: false, atom.Ul: true,
+}
+
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true,
+	atom.Title: true, atom.Template: true, atom.Noscript: true,
+	atom.Object: true, atom.Iframe: true, atom.Svg: true,
+}
+
+func (r *htmlRenderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.DocumentNode:
+		r.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if skippedElements[n.DataAtom] || hidden(n) {
+		return
+	}
+
+	margin, isBlock := blockElements[n.DataAtom]
+	if (n.DataAtom == atom.Ul || n.DataAtom == atom.Ol) && len(r.lists) > 0 {
+		// no margin around nested lists
+		margin = false
+	}
+	if isBlock {
+		r.breakBlock(margin)
+	}
+
+	oldStyle := r.style
+	switch n.DataAtom {
+	case atom.Br:
+		r.words = append(r.words, word{br: true})
+		r.space = false
+	case atom.Hr:
+		r.flush()
+		rb := &parse.RuneBuffer{}
+		w := r.opts.Width - r.block.width()
+		writeString(rb, strings.Repeat("─", max(w, 1)), r.style.Dim(true))
+		r.emit(rb)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.style = r.opts.Style("header")
+		r.children(n)
+	case atom.B, atom.Strong:
+		r.style = r.style.Bold(true)
+		r.children(n)
+	case atom.I, atom.Em, atom.Cite, atom.Var:
+		r.style = r.style.Italic(true)
+		r.children(n)
+	case atom.U, atom.Ins:
+		r.style = r.style.Underline(true)
+		r.children(n)
+	case atom.S, atom.Strike, atom.Del:
+		r.style = r.style.StrikeThrough(true)
+		r.children(n)
+	case atom.A:
+		r.anchor(n)
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			r.text("[" + alt + "]")
+		}
+	case atom.Pre:
+		r.pre++
+		r.children(n)
+		r.pre--
+	case atom.Blockquote:
+		r.quote++
+		name := "quote_x"
+		if r.quote <= 4 {
+			name = fmt.Sprintf("quote_%d", r.quote)
+		}
+		r.style = r.opts.Style(name)
+		r.push("> ", "> ", r.style)
+		r.children(n)
+		r.pop()
+		r.quote--
+	case atom.Ul, atom.Ol:
+		l := &list{ordered: n.DataAtom == atom.Ol, next: 1}
+		if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+			l.next = start
+		}
+		r.lists = append(r.lists, l)
+		r.children(n)
+		r.lists = r.lists[:len(r.lists)-1]
+	case atom.Li:
+		r.listItem(n)
+	case atom.Dt:
+		r.style = r.style.Bold(true)
+		r.children(n)
+	case atom.Dd:
+		r.push("    ", "    ", r.style)
+		r.children(n)
+		r.pop()
+	case atom.Table:
+		r.table(n)
+	default:
+		r.children(n)
+	}
+	r.style = oldStyle
+
+	if isBlock {
+		r.breakBlock(margin)
+	}
+}
+
+func (r *htmlRenderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+func (r *htmlRenderer) anchor(n *html.Node) {
+	href := strings.TrimSpace(attr(n, "href"))
+	u, err := url.Parse(href)
+	if err != nil || href == "" ||
+		(u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "mailto") {
+		r.children(n)
+		return
+	}
+	if r.opts.StripTracking {
+		href = parse.StripTrackingParams(href)
+	}
+	i := r.links.add(href, textContent(n))
+	r.style = r.opts.Style("url")
+	r.children(n)
+	r.words = append(r.words, word{
+		text:  fmt.Sprintf("[%d]", i),
+		style: r.opts.Style("url"),
+	})
+}
+
+func (r *htmlRenderer) listItem(n *html.Node) {
+	bullet := "• "
+	if len(r.lists) > 0 {
+		l := r.lists[len(r.lists)-1]
+		if l.ordered {
+			if v, err := strconv.Atoi(attr(n, "value")); err == nil {
+				l.next = v
+			}
+			bullet = fmt.Sprintf("%d. ", l.next)
+			l.next++
+		} else {
+			bullets := []string{"• ", "◦ ", "▪ "}
+			bullet = bullets[(len(r.lists)-1)%len(bullets)]
+		}
+	}
+	r.push(bullet, strings.Repeat(" ", runewidth.StringWidth(bullet)),
+		r.style)
+	r.children(n)
+	r.pop()
+}
+
+func (r *htmlRenderer) push(first, rest string, style tcell.Style) {
+	r.flush()
+	r.block = &block{parent: r.block, first: first, rest: rest, style: style}
+}
+
+func (r *htmlRenderer) pop() {
+	r.flush()
+	r.block = r.block.parent
+}
+
+// breakBlock ends the current line. With margin, a blank line is inserted
+// before the next content.
+func (r *htmlRenderer) breakBlock(margin bool) {
+	r.flush()
+	if margin && !r.blank {
+		r.blank = true
+		r.blankBlock = r.block
+	}
+}
+
+func (r *htmlRenderer) text(s string) {
+	if r.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				r.words = append(r.words, word{br: true})
+			}
+			if line != "" {
+				line = strings.ReplaceAll(line, "\t", "        ")
+				r.words = append(r.words, word{
+					text: line, style: r.style, pre: true,
+				})
+			}
+		}
+		return
+	}
+	start := -1
+	for i, c := range s {
+		if isSpace(c) {
+			if start >= 0 {
+				r.addWord(s[start:i])
+				start = -1
+			}
+			r.space = true
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		r.addWord(s[start:])
+	}
+}
+
+func (r *htmlRenderer) addWord(text string) {
+	r.words = append(r.words, word{
+		text:  strings.ReplaceAll(text, "\u00a0", " "),
+		style: r.style,
+		space: r.space && len(r.words) > 0 && !r.words[len(r.words)-1].br,
+	})
+	r.space = false
+}
+
+// flush wraps the pending words into lines.
+func (r *htmlRenderer) flush() {
+	if len(r.words) == 0 {
+		return
+	}
+	avail := r.opts.Width - r.block.width()
+	if avail < 8 {
+		avail = 8
+	}
+	line := &parse.RuneBuffer{}
+	started := false
+	w := 0
+	emit := func() {
+		r.emit(line)
+		line = &parse.RuneBuffer{}
+		started = false
+		w = 0
+	}
+	var prev *word
+	for i := range r.words {
+		wd := &r.words[i]
+		if wd.br {
+			emit()
+			prev = nil
+			continue
+		}
+		ww := runewidth.StringWidth(wd.text)
+		sp := 0
+		if wd.space && started {
+			sp = 1
+		}
+		if !wd.pre && started && w+sp+ww > avail {
+			emit()
+			sp = 0
+		}
+		if sp == 1 {
+			style := tcell.StyleDefault
+			if prev != nil && prev.style == wd.style {
+				style = wd.style
+			}
+			line.Write(' ', style)
+			w++
+		}
+		for _, c := range wd.text {
+			cw := runewidth.RuneWidth(c)
+			if !wd.pre && ww > avail && started && w+cw > avail {
+				// hard break words longer than the line
+				emit()
+			}
+			line.Write(c, wd.style)
+			started = true
+			w += cw
+		}
+		prev = wd
+	}
+	if started {
+		emit()
+	}
+	r.words = nil
+	r.space = false
+}
+
+func (r *htmlRenderer) emit(content *parse.RuneBuffer) {
+	if r.blank && len(r.lines) > 0 {
+		blank := &parse.RuneBuffer{}
+		r.blankBlock.prefix(blank, true)
+		r.lines = append(r.lines, trimRight(blank))
+	}
+	r.blank = false
+	line := &parse.RuneBuffer{}
+	r.block.prefix(line, false)
+	r.block.markUsed()
+	for _, c := range content.Runes() {
+		line.Write(c.Value, c.Style)
+	}
+	r.lines = append(r.lines, line)
+}
+
+// table renders tables with more than one column as a grid. Single column
+// tables are mostly used for layout and are rendered as simple blocks.
+func (r *htmlRenderer) table(n *html.Node) {
+	rows := tableRows(n)
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	if cols <= 1 {
+		for _, row := range rows {
+			for _, cell := range row {
+				r.breakBlock(false)
+				r.children(cell)
+				r.breakBlock(false)
+			}
+		}
+		return
+	}
+
+	const sep = 2
+	avail := r.opts.Width - r.block.width() - sep*(cols-1)
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], r.cellWidth(cell))
+		}
+	}
+	fitColumns(widths, avail)
+
+	r.flush()
+	for _, row := range rows {
+		cells := make([][]*parse.RuneBuffer, len(row))
+		height := 0
+		for i, cell := range row {
+			cells[i] = r.cell(cell, widths[i])
+			height = max(height, len(cells[i]))
+		}
+		for l := 0; l < height; l++ {
+			line := &parse.RuneBuffer{}
+			for i := range widths {
+				if i > 0 {
+					line.PadRight(line.Len()+sep, ' ', tcell.StyleDefault)
+				}
+				start := line.Len()
+				if i < len(cells) && l < len(cells[i]) {
+					for _, c := range cells[i][l].Runes() {
+						line.Write(c.Value, c.Style)
+					}
+				}
+				line.PadRight(start+widths[i], ' ', tcell.StyleDefault)
+			}
+			r.emit(trimRight(line))
+		}
+	}
+}
+
+// cell renders the contents of a table cell in a separate renderer.
+func (r *htmlRenderer) cell(n *html.Node, width int) []*parse.RuneBuffer {
+	opts := r.opts
+	opts.Width = width
+	style := r.style
+	if n.DataAtom == atom.Th {
+		style = style.Bold(true)
+	}
+	sub := newHTMLRenderer(opts, r.links, style)
+	sub.quote = r.quote
+	sub.widths = r.widths
+	sub.children(n)
+	sub.flush()
+	return trimBlankLines(sub.lines)
+}
+
+// cellWidth returns the width of the widest line of a table cell rendered
+// without wrapping.
+func (r *htmlRenderer) cellWidth(n *html.Node) int {
+	if w, ok := r.widths[n]; ok {
+		return w
+	}
+	w := 0
+	for _, line := range r.cell(n, 1<<16) {
+		w = max(w, line.Len())
+	}
+	r.widths[n] = w
+	return w
+}
+
+// fitColumns shrinks the widest columns until they fit the available width.
+func fitColumns(widths []int, avail int) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	for total > avail {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 1 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+func tableRows(table *html.Node) [][]*html.Node {
+	var rows [][]*html.Node
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || hidden(c) {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				visit(c)
+			case atom.Tr:
+				var cells []*html.Node
+				for td := c.FirstChild; td != nil; td = td.NextSibling {
+					if td.DataAtom == atom.Td || td.DataAtom == atom.Th {
+						cells = append(cells, td)
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			}
+		}
+	}
+	visit(table)
+	return rows
+}
+
+// textContent returns the whitespace normalized text of a node.
+func textContent(n *html.Node) string {
+	var text strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+			text.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hidden returns true for elements that are not meant to be displayed, like
+// the preview text of newsletters.
+func hidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "hidden":
+			return true
+		case "style":
+			style := strings.ToLower(strings.ReplaceAll(a.Val, " ", ""))
+			if strings.Contains(style, "display:none") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isSpace(c rune) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f':
+		return true
+	}
+	return false
+}
+
+func writeString(rb *parse.RuneBuffer, s string, style tcell.Style) {
+	for _, c := range s {
+		rb.Write(c, style)
+	}
+}
+
+func trimRight(rb *parse.RuneBuffer) *parse.RuneBuffer {
+	runes := rb.Runes()
+	end := len(runes)
+	for end > 0 && runes[end-1].Value == ' ' {
+		end--
+	}
+	trimmed := &parse.RuneBuffer{}
+	for _, c := range runes[:end] {
+		trimmed.Write(c.Value, c.Style)
+	}
+	return trimmed
+}
+
+func isBlank(rb *parse.RuneBuffer) bool {
+	for _, c := range rb.Runes() {
+		if c.Value != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+func trimBlankLines(lines []*parse.RuneBuffer) []*parse.RuneBuffer {
+	for len(lines) > 0 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
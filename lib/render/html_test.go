@@ -0,0 +1,118 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ansiRe = regexp.MustCompile(`\x1b(\[[0-9;]*m|\(B)`)
+
+func renderPlain(t *testing.T, input string, width int) (string, []Link) {
+	t.Helper()
+	text, links, err := HTML(strings.NewReader(input), HTMLOptions{Width: width})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ansiRe.ReplaceAllString(text, ""), links
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected string
+	}{
+		{
+			name: "blocks",
+			input: `<html><head><title>x</title><style>p{}</style></head>
+<body><h1>Title</h1><p>Hello   <b>world</b>!</p>
+<div style="display: none">preview</div><p>a<br>b</p></body></html>`,
+			expected: "Title\n\nHello world!\n\na\nb\n",
+		},
+		{
+			name:     "wrap",
+			input:    `<p>the quick brown fox jumps over the lazy dog</p>`,
+			width:    15,
+			expected: "the quick brown\nfox jumps over\nthe lazy dog\n",
+		},
+		{
+			name: "lists",
+			input: `<ul><li>one</li><li>two<ol start="3"><li>three</li>` +
+				`<li>four</li></ol></li></ul>`,
+			expected: "• one\n• two\n  3. three\n  4. four\n",
+		},
+		{
+			name:     "quote",
+			input:    `<p>hi</p><blockquote><p>foo</p><p>bar</p></blockquote>`,
+			expected: "hi\n\n> foo\n>\n> bar\n",
+		},
+		{
+			name:     "pre",
+			input:    "<pre>if x:\n    return\n</pre>",
+			expected: "if x:\n    return\n",
+		},
+		{
+			name: "table",
+			input: `<table><tr><th>Name</th><th>Qty</th></tr>` +
+				`<tr><td>apples</td><td>3</td></tr></table>`,
+			expected: "Name    Qty\napples  3\n",
+		},
+		{
+			name: "layout table",
+			input: `<table><tr><td><p>first</p></td></tr>` +
+				`<tr><td>second</td></tr></table>`,
+			expected: "first\n\nsecond\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, _ := renderPlain(t, test.input, test.width)
+			if out != test.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.expected, out)
+			}
+		})
+	}
+}
+
+func TestHTMLLinks(t *testing.T) {
+	out, links := renderPlain(t, `<p><a href="https://example.com/a">A</a>, `+
+		`<a href="javascript:void(0)">js</a>, `+
+		`<a href="mailto:x@example.com">mail</a> and `+
+		`<a href="https://example.com/a">A again</a></p>`, 80)
+	expected := "A[1], js, mail[2] and A again[1]\n\nLinks:\n" +
+		"[1] https://example.com/a\n[2] mailto:x@example.com\n"
+	if out != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, out)
+	}
+	if len(links) != 2 || links[1].URL != "mailto:x@example.com" {
+		t.Errorf("unexpected links: %v", links)
+	}
+	if len(links[0].Texts) != 2 || links[0].Texts[1] != "A again" {
+		t.Errorf("unexpected link texts: %v", links[0].Texts)
+	}
+}
+
+func TestHTMLLinkWarnings(t *testing.T) {
+	out, _ := renderPlain(t, `<a href="https://evil.example.net/">`+
+		`https://www.paypal.com</a>`, 80)
+	expected := "https://www.paypal.com[1]\n\nLinks:\n" +
+		"[1] https://evil.example.net/\n" +
+		"    warning: text shows www.paypal.com but the link goes to " +
+		"evil.example.net\n"
+	if out != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, out)
+	}
+}
+
+func TestHTMLNestedTables(t *testing.T) {
+	input := "x"
+	for i := 0; i < 30; i++ {
+		input = "<table><tr><td>a</td><td>" + input + "</td></tr></table>"
+	}
+	out, _ := renderPlain(t, input, 80)
+	if !strings.HasPrefix(out, "a  a  a") || !strings.HasSuffix(out, "a  x\n") {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
@@ -0,0 +1,349 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"git.sr.ht/~sircmpwn/getopt"
+	"github.com/google/shlex"
+
+	"git.sr.ht/~rjarry/aerc/lib/format"
+	"git.sr.ht/~rjarry/aerc/models"
+)
+
+// A Rule applies a list of actions to the messages matching its criteria.
+type Rule struct {
+	// Source is the criteria as written in the configuration.
+	Source  string
+	Match   *Criteria
+	Actions []Action
+}
+
+// Criteria use the same options as :filter. Every condition must hold for a
+// message to match.
+type Criteria struct {
+	Headers      []Header
+	Subject      []string
+	WithFlags    models.Flags
+	WithoutFlags models.Flags
+	MinSize      uint32
+	MaxSize      uint32
+}
+
+// A Header condition matches when the header value contains Value. An
+// empty Value only requires the header to be present.
+type Header struct {
+	Name  string
+	Value string
+}
+
+const (
+	Move = "move"
+	Copy = "copy"
+	Flag = "flag"
+	Read = "read"
+	Tag  = "tag"
+	Pipe = "pipe"
+)
+
+// An Action is one of Move, Copy, Flag, Read, Tag or Pipe with its
+// arguments: the destination folder for Move and Copy, the +tag and -tag
+// changes for Tag and the command line for Pipe.
+type Action struct {
+	Name string
+	Args []string
+}
+
+func (a Action) String() string {
+	return strings.TrimSpace(a.Name + " " + format.ShellQuote(a.Args))
+}
+
+// Parse creates a rule from its criteria and actions, both in shell syntax.
+func Parse(criteria, actions string) (*Rule, error) {
+	args, err := shlex.Split(criteria)
+	if err != nil {
+		return nil, err
+	}
+	match, err := ParseCriteria(args)
+	if err != nil {
+		return nil, err
+	}
+	args, err = shlex.Split(actions)
+	if err != nil {
+		return nil, err
+	}
+	acts, err := ParseActions(args)
+	if err != nil {
+		return nil, err
+	}
+	return &Rule{Source: criteria, Match: match, Actions: acts}, nil
+}
+
+// ParseCriteria parses the header, flag and size options of :filter.
+// Options that need the message body or that only make sense interactively
+// are rejected.
+func ParseCriteria(args []string) (*Criteria, error) {
+	c := &Criteria{}
+	// prepend a dummy command name for getopt
+	opts, optind, err := getopt.Getopts(append([]string{"rule"}, args...),
+		"rux:X:H:f:t:c:s:")
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		switch opt.Option {
+		case 'r':
+			c.WithFlags |= models.SeenFlag
+		case 'u':
+			c.WithoutFlags |= models.SeenFlag
+		case 'x', 'X':
+			f, err := parseFlag(opt.Value)
+			if err != nil {
+				return nil, err
+			}
+			if opt.Option == 'x' {
+				c.WithFlags |= f
+			} else {
+				c.WithoutFlags |= f
+			}
+		case 'H':
+			h := strings.SplitN(opt.Value, ":", 2)
+			c.Headers = append(c.Headers, Header{
+				Name: strings.TrimSpace(h[0]),
+			})
+			if len(h) == 2 {
+				c.Headers[len(c.Headers)-1].Value = strings.TrimSpace(h[1])
+			}
+		case 'f':
+			c.Headers = append(c.Headers, Header{"From", opt.Value})
+		case 't':
+			c.Headers = append(c.Headers, Header{"To", opt.Value})
+		case 'c':
+			c.Headers = append(c.Headers, Header{"Cc", opt.Value})
+		case 's':
+			c.MinSize, c.MaxSize, err = ParseSizeRange(opt.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	c.Subject = args[optind-1:]
+	return c, nil
+}
+
+func parseFlag(name string) (models.Flags, error) {
+	switch strings.ToLower(name) {
+	case "seen":
+		return models.SeenFlag, nil
+	case "answered":
+		return models.AnsweredFlag, nil
+	case "flagged":
+		return models.FlaggedFlag, nil
+	}
+	return 0, fmt.Errorf("unknown flag: %s", name)
+}
+
+// ParseSizeRange parses a "min..max" size range where both bounds are
+// optional. A single size is a lower bound. Sizes are in bytes unless
+// followed by K, M or G.
+func ParseSizeRange(s string) (min, max uint32, err error) {
+	bounds := strings.SplitN(s, "..", 2)
+	if bounds[0] != "" {
+		if min, err = parseSize(bounds[0]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(bounds) == 2 && bounds[1] != "" {
+		if max, err = parseSize(bounds[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if min == 0 && max == 0 {
+		return 0, 0, fmt.Errorf("invalid size range: %q", s)
+	}
+	return min, max, nil
+}
+
+func parseSize(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	unit := uint64(1)
+	if s != "" {
+		switch unicode.ToUpper(rune(s[len(s)-1])) {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		}
+		if unit > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n*unit > 1<<32-1 {
+		return 0, fmt.Errorf("invalid size: %q", s)
+	}
+	return uint32(n * unit), nil
+}
+
+// ParseActions parses a list of actions. Move, Copy and Pipe take
+// arguments, Tag takes all the following +tag and -tag arguments. Move must
+// be the last action since the message is no longer in the folder after it.
+func ParseActions(args []string) ([]Action, error) {
+	var actions []Action
+	for len(args) > 0 {
+		a := Action{Name: strings.ToLower(args[0])}
+		args = args[1:]
+		switch a.Name {
+		case Move, Copy:
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%s: missing folder", a.Name)
+			}
+			a.Args, args = args[:1], args[1:]
+			if a.Name == Move && len(args) > 0 {
+				return nil, errors.New("move must be the last action")
+			}
+		case Flag, Read:
+		case Tag, "label":
+			a.Name = Tag
+			for len(args) > 0 && len(args[0]) > 1 &&
+				(args[0][0] == '+' || args[0][0] == '-') {
+				a.Args, args = append(a.Args, args[0]), args[1:]
+			}
+			if len(a.Args) == 0 {
+				return nil, errors.New("tag: expected +<tag> or -<tag>")
+			}
+		case Pipe:
+			if len(args) == 0 {
+				return nil, errors.New("pipe: missing command")
+			}
+			a.Args, args = args, nil
+		default:
+			return nil, fmt.Errorf("unknown action: %s", a.Name)
+		}
+		actions = append(actions, a)
+	}
+	if len(actions) == 0 {
+		return nil, errors.New("no action")
+	}
+	return actions, nil
+}
+
+// Matches returns true if the message meets all the criteria.
+func (c *Criteria) Matches(msg *models.MessageInfo) bool {
+	if msg == nil || msg.Envelope == nil {
+		return false
+	}
+	if !msg.Flags.Has(c.WithFlags) {
+		return false
+	}
+	if c.WithoutFlags != 0 && msg.Flags.Has(c.WithoutFlags) {
+		return false
+	}
+	if c.MinSize != 0 || c.MaxSize != 0 {
+		// the size is unknown with some backends
+		if msg.Size == 0 || msg.Size < c.MinSize ||
+			(c.MaxSize != 0 && msg.Size >= c.MaxSize) {
+			return false
+		}
+	}
+	for _, h := range c.Headers {
+		value, ok := header(msg, h.Name)
+		if !ok || !containsSmartCase(value, h.Value) {
+			return false
+		}
+	}
+	for _, term := range c.Subject {
+		if !containsSmartCase(msg.Envelope.Subject, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func header(msg *models.MessageInfo, name string) (string, bool) {
+	if msg.RFC822Headers != nil && msg.RFC822Headers.Has(name) {
+		return msg.RFC822Headers.Get(name), true
+	}
+	// some backends only provide the envelope
+	env := msg.Envelope
+	switch strings.ToLower(name) {
+	case "from":
+		return format.FormatAddresses(env.From), len(env.From) > 0
+	case "to":
+		return format.FormatAddresses(env.To), len(env.To) > 0
+	case "cc":
+		return format.FormatAddresses(env.Cc), len(env.Cc) > 0
+	case "subject":
+		return env.Subject, true
+	}
+	return "", false
+}
+
+// containsSmartCase is case-insensitive unless substr contains an upper case
+// character, like the searches of the maildir and mbox backends.
+func containsSmartCase(s string, substr string) bool {
+	for _, r := range substr {
+		if unicode.IsUpper(r) {
+			return strings.Contains(s, substr)
+		}
+	}
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// A Batch is a list of actions to apply to a group of messages.
+type Batch struct {
+	Uids    []uint32
+	Actions []Action
+}
+
+// Plan evaluates the rules in order against the messages and groups the
+// messages by the actions to apply to them. A matching rule that moves a
+// message to another folder ends its evaluation. Moving or copying a message
+// to the folder it is already in does nothing.
+func Plan(rules []*Rule, folder string, msgs []*models.MessageInfo) []Batch {
+	var batches []Batch
+	index := make(map[string]int)
+	for _, msg := range msgs {
+		var actions []Action
+		var key strings.Builder
+	rules:
+		for _, rule := range rules {
+			if !rule.Match.Matches(msg) {
+				continue
+			}
+			for _, a := range rule.Actions {
+				if (a.Name == Move || a.Name == Copy) && a.Args[0] == folder {
+					continue
+				}
+				actions = append(actions, a)
+				key.WriteString(a.String())
+				key.WriteByte(0)
+				if a.Name == Move {
+					break rules
+				}
+			}
+		}
+		if len(actions) == 0 {
+			continue
+		}
+		if i, ok := index[key.String()]; ok {
+			batches[i].Uids = append(batches[i].Uids, msg.Uid)
+		} else {
+			index[key.String()] = len(batches)
+			batches = append(batches, Batch{
+				Uids:    []uint32{msg.Uid},
+				Actions: actions,
+			})
+		}
+	}
+	for _, b := range batches {
+		sort.Slice(b.Uids, func(i, j int) bool { return b.Uids[i] < b.Uids[j] })
+	}
+	return batches
+}
@@ -0,0 +1,192 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-message/mail"
+
+	"git.sr.ht/~rjarry/aerc/models"
+)
+
+func TestParseSizeRange(t *testing.T) {
+	tests := []struct {
+		s        string
+		min, max uint32
+		err      bool
+	}{
+		{"100", 100, 0, false},
+		{"1K..", 1024, 0, false},
+		{"..2m", 0, 2 << 20, false},
+		{"10K..1M", 10 << 10, 1 << 20, false},
+		{"..", 0, 0, true},
+		{"5X", 0, 0, true},
+		{"8G", 0, 0, true},
+	}
+	for _, test := range tests {
+		min, max, err := ParseSizeRange(test.s)
+		if (err != nil) != test.err {
+			t.Errorf("%q: unexpected error: %v", test.s, err)
+			continue
+		}
+		if min != test.min || max != test.max {
+			t.Errorf("%q: expected %d..%d, got %d..%d",
+				test.s, test.min, test.max, min, max)
+		}
+	}
+}
+
+func TestParseActions(t *testing.T) {
+	tests := []struct {
+		actions string
+		expect  []Action
+	}{
+		{"read", []Action{{Name: Read}}},
+		{"flag move 'Lists/aerc devel'", []Action{
+			{Name: Flag},
+			{Name: Move, Args: []string{"Lists/aerc devel"}},
+		}},
+		{"label +aerc -inbox read", []Action{
+			{Name: Tag, Args: []string{"+aerc", "-inbox"}},
+			{Name: Read},
+		}},
+		{"copy Archive pipe spamc -L spam", []Action{
+			{Name: Copy, Args: []string{"Archive"}},
+			{Name: Pipe, Args: []string{"spamc", "-L", "spam"}},
+		}},
+		{"", nil},
+		{"move", nil},
+		{"move Lists read", nil},
+		{"tag inbox", nil},
+		{"delete", nil},
+	}
+	for _, test := range tests {
+		rule, err := Parse("-r", test.actions)
+		if test.expect == nil {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.actions)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.actions, err)
+			continue
+		}
+		if !reflect.DeepEqual(rule.Actions, test.expect) {
+			t.Errorf("%q: expected %#v, got %#v",
+				test.actions, test.expect, rule.Actions)
+		}
+	}
+}
+
+func newMessage(uid uint32, from, subject string, flags models.Flags,
+	size uint32, headers ...string,
+) *models.MessageInfo {
+	h := &mail.Header{}
+	h.SetAddressList("From", []*mail.Address{{Address: from}})
+	for i := 0; i < len(headers); i += 2 {
+		h.Set(headers[i], headers[i+1])
+	}
+	return &models.MessageInfo{
+		Uid:           uid,
+		Flags:         flags,
+		Size:          size,
+		RFC822Headers: h,
+		Envelope: &models.Envelope{
+			Subject: subject,
+			From:    []*mail.Address{{Address: from}},
+		},
+	}
+}
+
+func TestCriteriaMatches(t *testing.T) {
+	msg := newMessage(1, "bob@example.org", "[PATCH] Fix the thing",
+		models.FlaggedFlag, 4096,
+		"List-Id", "aerc development <~rjarry/aerc-devel.lists.sr.ht>")
+	tests := []struct {
+		criteria string
+		match    bool
+	}{
+		{"", true},
+		{"-f bob@", true},
+		{"-f alice@", false},
+		{"-t bob@", false},
+		{"patch", true},
+		{"PATCH thing", true},
+		{"Patch", false},
+		{"-H List-Id:aerc-devel", true},
+		{"-H List-Id", true},
+		{"-H List-Unsubscribe", false},
+		{"-x flagged -u", true},
+		{"-x flagged -r", false},
+		{"-X flagged", false},
+		{"-s 4K", true},
+		{"-s 1K..4K", false},
+		{"-s ..1M", true},
+	}
+	for _, test := range tests {
+		rule, err := Parse(test.criteria, "read")
+		if err != nil {
+			t.Errorf("%q: %v", test.criteria, err)
+			continue
+		}
+		if rule.Match.Matches(msg) != test.match {
+			t.Errorf("%q: expected match=%v", test.criteria, test.match)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	var rules []*Rule
+	for _, r := range [][2]string{
+		{"-H List-Id:aerc-devel", "tag +aerc move Lists/aerc"},
+		{"-f notifications@github.com", "read"},
+		{"-f notifications@github.com", "move GitHub"},
+		{"ci", "flag"},
+	} {
+		rule, err := Parse(r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+	msgs := []*models.MessageInfo{
+		newMessage(4, "notifications@github.com", "ci failed", 0, 0),
+		newMessage(1, "bob@example.org", "[PATCH] ci: fix", 0, 0,
+			"List-Id", "<~rjarry/aerc-devel.lists.sr.ht>"),
+		newMessage(2, "notifications@github.com", "issue opened", 0, 0),
+		newMessage(3, "alice@example.org", "hello", 0, 0),
+		newMessage(5, "notifications@github.com", "ci passed", 0, 0),
+	}
+	expect := []Batch{
+		{Uids: []uint32{2, 4, 5}, Actions: []Action{
+			{Name: Read},
+			{Name: Move, Args: []string{"GitHub"}},
+		}},
+		{Uids: []uint32{1}, Actions: []Action{
+			{Name: Tag, Args: []string{"+aerc"}},
+			{Name: Move, Args: []string{"Lists/aerc"}},
+		}},
+	}
+	batches := Plan(rules, "INBOX", msgs)
+	if !reflect.DeepEqual(batches, expect) {
+		t.Errorf("expected %#v, got %#v", expect, batches)
+	}
+
+	// already in the destination folder: the next rules still apply
+	expect = []Batch{
+		{Uids: []uint32{4, 5}, Actions: []Action{
+			{Name: Read},
+			{Name: Flag},
+		}},
+		{Uids: []uint32{1}, Actions: []Action{
+			{Name: Tag, Args: []string{"+aerc"}},
+			{Name: Move, Args: []string{"Lists/aerc"}},
+		}},
+		{Uids: []uint32{2}, Actions: []Action{{Name: Read}}},
+	}
+	batches = Plan(rules, "GitHub", msgs)
+	if !reflect.DeepEqual(batches, expect) {
+		t.Errorf("expected %#v, got %#v", expect, batches)
+	}
+}
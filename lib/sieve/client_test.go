@@ -0,0 +1,206 @@
+package sieve
+
+import (
+	"bufio"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-sasl"
+)
+
+func dial(t *testing.T, s *testServer) *Client {
+	u, err := url.Parse(s.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Dial(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestReadLine(t *testing.T) {
+	input := "\"a \\\"quoted\\\" \\\\ string\" {5}\r\nab\r\nc ACTIVE\r\n" +
+		"NO (QUOTA/MAXSIZE) {3+}\r\nbig\r\n"
+	r := &reader{bufio.NewReader(strings.NewReader(input))}
+	tokens, err := r.readLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []token{
+		{str, `a "quoted" \ string`},
+		{str, "ab\r\nc"},
+		{atom, "ACTIVE"},
+	}
+	if !reflect.DeepEqual(tokens, expect) {
+		t.Errorf("expected %#v, got %#v", expect, tokens)
+	}
+	tokens, err = r.readLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := parseResponse(tokens)
+	if resp == nil || resp.status != "NO" || resp.code != "QUOTA/MAXSIZE" ||
+		resp.text != "big" {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"vacation":     `"vacation"`,
+		`my "script"`:  `"my \"script\""`,
+		"line\r\nline": "{10+}\r\nline\r\nline",
+	}
+	for s, expect := range tests {
+		if q := quote(s); q != expect {
+			t.Errorf("%q: expected %q, got %q", s, expect, q)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	s := newTestServer(t)
+	c := dial(t, s)
+
+	if caps := c.Capabilities(); caps["SIEVE"] != "fileinto vacation" {
+		t.Errorf("unexpected capabilities: %#v", caps)
+	}
+
+	script := "require \"fileinto\";\r\nif header :contains \"List-Id\" " +
+		"\"aerc\" {\r\n  fileinto \"aerc\";\r\n}\r\n"
+	if err := c.PutScript("lists", script); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PutScript("vacation", "keep;"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetActive("lists"); err != nil {
+		t.Fatal(err)
+	}
+	scripts, err := c.ListScripts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Script{{"lists", true}, {"vacation", false}}
+	if !reflect.DeepEqual(scripts, expect) {
+		t.Errorf("expected %#v, got %#v", expect, scripts)
+	}
+	content, err := c.GetScript("lists")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != script {
+		t.Errorf("expected %q, got %q", script, content)
+	}
+
+	warnings, err := c.CheckScript("warn;")
+	if err != nil || warnings != "line 1: deprecated" {
+		t.Errorf("unexpected check result: %q %v", warnings, err)
+	}
+	version := c.caps["VERSION"]
+	delete(c.caps, "VERSION")
+	if _, err := c.CheckScript("warn;"); !errors.Is(err, ErrCheckUnsupported) {
+		t.Errorf("expected ErrCheckUnsupported, got %v", err)
+	}
+	c.caps["VERSION"] = version
+	err = c.PutScript("broken", "error;")
+	var serr *Error
+	if !errors.As(err, &serr) || serr.Text != "line 1: syntax error" {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+
+	err = c.DeleteScript("lists")
+	if !errors.As(err, &serr) || serr.Code != "ACTIVE" {
+		t.Errorf("expected an ACTIVE error, got %v", err)
+	}
+	if err := c.SetActive(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteScript("lists"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetScript("lists"); err == nil {
+		t.Error("expected an error for a deleted script")
+	}
+	if err := c.Logout(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newTestServer(t)
+
+	u, _ := url.Parse(s.url())
+	u.User = url.UserPassword("tim", "wrong")
+	if _, err := Dial(u); err == nil {
+		t.Error("expected an authentication error")
+	}
+
+	// without initial response, the server sends an empty challenge
+	u.User = nil
+	c, err := Dial(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.ListScripts(); err == nil {
+		t.Error("expected an error before authentication")
+	}
+	// a client failing to answer the challenge cancels the authentication
+	err = c.Authenticate(&abortClient{})
+	var serr *Error
+	if !errors.As(err, &serr) || serr.Text != "authentication aborted" {
+		t.Fatalf("expected an aborted authentication, got %v", err)
+	}
+	err = c.Authenticate(&noInitialResponse{
+		sasl.NewPlainClient("", s.user, s.password),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListScripts(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDialStartTLS(t *testing.T) {
+	s := newTestServer(t)
+	u, _ := url.Parse(strings.Replace(s.url(), "+insecure", "", 1))
+	_, err := Dial(u)
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected a STARTTLS error, got %v", err)
+	}
+}
+
+// abortClient fails to answer the server challenge.
+type abortClient struct{}
+
+func (c *abortClient) Start() (string, []byte, error) {
+	return "PLAIN", nil, nil
+}
+
+func (c *abortClient) Next(challenge []byte) ([]byte, error) {
+	return nil, errors.New("no answer")
+}
+
+// noInitialResponse sends the initial response of a client after an empty
+// server challenge.
+type noInitialResponse struct {
+	sasl.Client
+}
+
+func (c *noInitialResponse) Start() (string, []byte, error) {
+	mech, _, err := c.Client.Start()
+	return mech, nil, err
+}
+
+func (c *noInitialResponse) Next(challenge []byte) ([]byte, error) {
+	_, ir, err := c.Client.Start()
+	return ir, err
+}
@@ -0,0 +1,198 @@
+package sieve
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	atom tokenKind = iota
+	str
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// maxLiteral protects against servers announcing absurd literal sizes.
+const maxLiteral = 16 << 20
+
+// reader splits the lines sent by a ManageSieve peer into atoms and strings.
+// Quoted strings and literals ({n} or {n+}) are both returned as strings.
+type reader struct {
+	*bufio.Reader
+}
+
+func (r *reader) readLine() ([]token, error) {
+	var tokens []token
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch b {
+		case ' ':
+		case '\r':
+			if b, err = r.ReadByte(); err != nil {
+				return nil, err
+			}
+			if b != '\n' {
+				return nil, errors.New("expected LF after CR")
+			}
+			return tokens, nil
+		case '\n':
+			return tokens, nil
+		case '(', ')':
+			tokens = append(tokens, token{atom, string(b)})
+		case '"':
+			s, err := r.readQuoted()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{str, s})
+		case '{':
+			s, err := r.readLiteral()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{str, s})
+		default:
+			var sb strings.Builder
+			sb.WriteByte(b)
+			for {
+				b, err = r.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				if b == ' ' || b == '\r' || b == '\n' || b == '(' || b == ')' {
+					if err := r.UnreadByte(); err != nil {
+						return nil, err
+					}
+					break
+				}
+				sb.WriteByte(b)
+			}
+			tokens = append(tokens, token{atom, sb.String()})
+		}
+	}
+}
+
+func (r *reader) readQuoted() (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if b, err = r.ReadByte(); err != nil {
+				return "", err
+			}
+		case '\r', '\n':
+			return "", errors.New("line break in quoted string")
+		}
+		sb.WriteByte(b)
+	}
+}
+
+func (r *reader) readLiteral() (string, error) {
+	spec, err := r.ReadString('}')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(spec[:len(spec)-1], "+"))
+	if err != nil || n < 0 || n > maxLiteral {
+		return "", fmt.Errorf("invalid literal: {%s", spec)
+	}
+	crlf := make([]byte, 2)
+	if _, err := io.ReadFull(r, crlf); err != nil {
+		return "", err
+	}
+	if string(crlf) != "\r\n" {
+		return "", errors.New("expected CRLF after literal size")
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// quote formats s as a quoted string, or as a literal when it contains line
+// breaks.
+func quote(s string) string {
+	if strings.ContainsAny(s, "\r\n") || len(s) > 1024 {
+		return literal(s)
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// literal formats s as a non-synchronizing literal.
+func literal(s string) string {
+	return fmt.Sprintf("{%d+}\r\n%s", len(s), s)
+}
+
+// A response is the final OK, NO or BYE line of a command.
+type response struct {
+	status string
+	code   string
+	text   string
+}
+
+// parseResponse returns nil if the tokens are not a final response.
+func parseResponse(tokens []token) *response {
+	if len(tokens) == 0 || tokens[0].kind != atom {
+		return nil
+	}
+	status := strings.ToUpper(tokens[0].value)
+	if status != "OK" && status != "NO" && status != "BYE" {
+		return nil
+	}
+	resp := &response{status: status}
+	tokens = tokens[1:]
+	if len(tokens) > 0 && tokens[0].kind == atom && tokens[0].value == "(" {
+		var code []string
+		for tokens = tokens[1:]; len(tokens) > 0; tokens = tokens[1:] {
+			if tokens[0].kind == atom && tokens[0].value == ")" {
+				tokens = tokens[1:]
+				break
+			}
+			code = append(code, tokens[0].value)
+		}
+		resp.code = strings.Join(code, " ")
+	}
+	if len(tokens) > 0 {
+		resp.text = tokens[0].value
+	}
+	return resp
+}
+
+// Error is returned when the server answers a command with NO or BYE.
+type Error struct {
+	Command string
+	Code    string
+	Text    string
+}
+
+func (e *Error) Error() string {
+	msg := e.Text
+	if msg == "" {
+		msg = "command failed"
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Code)
+	}
+	return fmt.Sprintf("%s: %s", e.Command, msg)
+}
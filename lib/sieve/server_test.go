@@ -0,0 +1,177 @@
+package sieve
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testServer is a minimal in-memory ManageSieve server. Scripts containing
+// "error" are invalid and scripts containing "warn" produce a warning.
+type testServer struct {
+	t        *testing.T
+	listener net.Listener
+	user     string
+	password string
+
+	mu      sync.Mutex
+	scripts map[string]string
+	active  string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{
+		t:        t,
+		listener: l,
+		user:     "tim",
+		password: "tanstaaftanstaaf",
+		scripts:  make(map[string]string),
+	}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *testServer) url() string {
+	return fmt.Sprintf("sieve+insecure://%s:%s@%s",
+		s.user, s.password, s.listener.Addr())
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := &reader{bufio.NewReader(conn)}
+	w := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+	w(`"IMPLEMENTATION" "aerc test server"`)
+	w(`"SASL" "PLAIN"`)
+	w(`"SIEVE" "fileinto vacation"`)
+	w(`"VERSION" "1.0"`)
+	w(`OK "ready"`)
+
+	authenticated := false
+	for {
+		tokens, err := r.readLine()
+		if err != nil || len(tokens) == 0 {
+			return
+		}
+		cmd := strings.ToUpper(tokens[0].value)
+		var args []string
+		for _, tok := range tokens[1:] {
+			args = append(args, tok.value)
+		}
+		if cmd != "AUTHENTICATE" && cmd != "LOGOUT" && !authenticated {
+			w(`NO "authenticate first"`)
+			continue
+		}
+		s.mu.Lock()
+		switch cmd {
+		case "AUTHENTICATE":
+			var ir string
+			if len(args) > 1 {
+				ir = args[1]
+			} else {
+				w(`""`)
+				resp, err := r.readLine()
+				if err != nil || len(resp) != 1 || resp[0].kind != str {
+					w(`BYE "expected a string"`)
+					s.mu.Unlock()
+					return
+				}
+				if resp[0].value == "*" {
+					w(`NO "authentication aborted"`)
+					s.mu.Unlock()
+					continue
+				}
+				ir = resp[0].value
+			}
+			creds, _ := base64.StdEncoding.DecodeString(ir)
+			parts := bytes.Split(creds, []byte{0})
+			if len(parts) == 3 && string(parts[1]) == s.user &&
+				string(parts[2]) == s.password {
+				authenticated = true
+				w(`OK "logged in"`)
+			} else {
+				w(`NO "authentication failed"`)
+			}
+		case "LISTSCRIPTS":
+			var names []string
+			for name := range s.scripts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if name == s.active {
+					w("%s ACTIVE", quote(name))
+				} else {
+					w("%s", quote(name))
+				}
+			}
+			w("OK")
+		case "GETSCRIPT":
+			if content, ok := s.scripts[args[0]]; ok {
+				w("%s", literal(content))
+				w("OK")
+			} else {
+				w(`NO (NONEXISTENT) "no such script"`)
+			}
+		case "PUTSCRIPT", "CHECKSCRIPT":
+			content := args[len(args)-1]
+			switch {
+			case strings.Contains(content, "error"):
+				w(`NO "line 1: syntax error"`)
+			case cmd == "PUTSCRIPT":
+				s.scripts[args[0]] = content
+				w("OK")
+			case strings.Contains(content, "warn"):
+				w(`OK (WARNINGS) "line 1: deprecated"`)
+			default:
+				w("OK")
+			}
+		case "SETACTIVE":
+			if _, ok := s.scripts[args[0]]; ok || args[0] == "" {
+				s.active = args[0]
+				w("OK")
+			} else {
+				w(`NO (NONEXISTENT) "no such script"`)
+			}
+		case "DELETESCRIPT":
+			switch {
+			case args[0] == s.active:
+				w(`NO (ACTIVE) "script is active"`)
+			case s.scripts[args[0]] == "":
+				w(`NO (NONEXISTENT) "no such script"`)
+			default:
+				delete(s.scripts, args[0])
+				w("OK")
+			}
+		case "LOGOUT":
+			w(`OK "bye"`)
+			s.mu.Unlock()
+			return
+		default:
+			w(`NO "unknown command"`)
+		}
+		s.mu.Unlock()
+	}
+}
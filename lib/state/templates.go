@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/auth"
 	"git.sr.ht/~rjarry/aerc/lib/parse"
 	"git.sr.ht/~rjarry/aerc/models"
 	sortthread "github.com/emersion/go-imap-sortthread"
@@ -25,6 +26,8 @@ type TemplateData struct {
 	// message list threading
 	threadSameSubject bool
 	threadPrefix      string
+	threadCount       int
+	threadUnread      int
 
 	// selected account
 	account     *config.AccountConfig
@@ -32,9 +35,13 @@ type TemplateData struct {
 	folder      string // selected folder name
 	folders     []string
 	getRUEcount func(string) (int, int, int)
+	senders     *auth.SenderChecker
 
 	state       *AccountState
 	pendingKeys []config.KeyStroke
+
+	// only available for triggers
+	errorText string
 }
 
 // only used for compose/reply/forward
@@ -55,6 +62,13 @@ func (d *TemplateData) SetThreading(prefix string, same bool) {
 	d.threadSameSubject = same
 }
 
+// SetFolded sets the number of messages and unread messages of a folded
+// thread. count must be zero for the other messages.
+func (d *TemplateData) SetFolded(count, unread int) {
+	d.threadCount = count
+	d.threadUnread = unread
+}
+
 func (d *TemplateData) SetAccount(acct *config.AccountConfig) {
 	d.account = acct
 	d.myAddresses = make(map[string]bool)
@@ -66,6 +80,11 @@ func (d *TemplateData) SetAccount(acct *config.AccountConfig) {
 	}
 }
 
+// only used for message list and viewer templates
+func (d *TemplateData) SetSenderChecker(senders *auth.SenderChecker) {
+	d.senders = senders
+}
+
 func (d *TemplateData) SetFolder(folder string) {
 	d.folder = folder
 }
@@ -83,6 +102,13 @@ func (d *TemplateData) SetPendingKeys(keys []config.KeyStroke) {
 	d.pendingKeys = keys
 }
 
+// only used for triggers
+func (d *TemplateData) SetError(err error) {
+	if err != nil {
+		d.errorText = err.Error()
+	}
+}
+
 func (d *TemplateData) Account() string {
 	if d.account != nil {
 		return d.account.Name
@@ -226,9 +252,28 @@ func (d *TemplateData) Header(name string) string {
 }
 
 func (d *TemplateData) ThreadPrefix() string {
+	switch {
+	case d.threadCount > 0 && d.threadUnread > 0:
+		return fmt.Sprintf("%s(%d/%d) ",
+			d.threadPrefix, d.threadUnread, d.threadCount)
+	case d.threadCount > 0:
+		return fmt.Sprintf("%s(%d) ", d.threadPrefix, d.threadCount)
+	}
 	return d.threadPrefix
 }
 
+func (d *TemplateData) ThreadFolded() bool {
+	return d.threadCount > 0
+}
+
+func (d *TemplateData) ThreadCount() int {
+	return d.threadCount
+}
+
+func (d *TemplateData) ThreadUnread() int {
+	return d.threadUnread
+}
+
 func (d *TemplateData) Subject() string {
 	var subject string
 	switch {
@@ -293,6 +338,9 @@ func (d *TemplateData) Flags() []string {
 			}
 		}
 	}
+	if icon := d.ui().IconSenderWarning; icon != "" && len(d.SenderWarnings()) > 0 {
+		flags = append(flags, icon)
+	}
 	if d.info.Flags.Has(models.FlaggedFlag) {
 		flags = append(flags, "!")
 	}
@@ -309,6 +357,37 @@ func (d *TemplateData) MessageId() string {
 	return d.info.Envelope.MessageId
 }
 
+// ListId returns the mailing list identifier from the List-Id header, without
+// the description. See RFC 2919.
+func (d *TemplateData) ListId() string {
+	id := d.Header("List-Id")
+	if start := strings.LastIndex(id, "<"); start >= 0 {
+		if end := strings.Index(id[start:], ">"); end > 0 {
+			return id[start+1 : start+end]
+		}
+	}
+	return strings.TrimSpace(id)
+}
+
+// SenderWarnings returns the reasons why the sender of the message may be
+// spoofed.
+func (d *TemplateData) SenderWarnings() []string {
+	if d.senders == nil {
+		return nil
+	}
+	return d.senders.CheckMessage(d.info)
+}
+
+// SenderTrust returns the sender warning icon if the sender of the message
+// may be spoofed.
+func (d *TemplateData) SenderTrust() string {
+	icon := d.ui().IconSenderWarning
+	if icon == "" || len(d.SenderWarnings()) == 0 {
+		return ""
+	}
+	return icon
+}
+
 func (d *TemplateData) Size() int {
 	if d.info == nil || d.info.Envelope == nil {
 		return 0
@@ -467,6 +546,10 @@ func (d *TemplateData) PendingKeys() string {
 	return config.FormatKeyStrokes(d.pendingKeys)
 }
 
+func (d *TemplateData) ErrorText() string {
+	return d.errorText
+}
+
 func (d *TemplateData) Style(content, name string) string {
 	cfg := config.Ui.ForAccount(d.Account())
 	style := cfg.GetUserStyle(name)
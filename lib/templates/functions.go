@@ -91,6 +91,12 @@ func quote(text string) string {
 	return quoted.String()
 }
 
+// shellquote wraps text in single quotes so that it is passed verbatim as one
+// argument of a shell command
+func shellquote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
 // cmd allow to parse reply by shell command
 // text have to be passed by cmd param
 // if there is error, original string is returned
@@ -281,6 +287,7 @@ func default_(value string) models.Case {
 
 var templateFuncs = template.FuncMap{
 	"quote":         quote,
+	"shellquote":    shellquote,
 	"wrapText":      wrapText,
 	"wrap":          wrap,
 	"now":           time.Now,
@@ -188,6 +188,9 @@ func (builder *ThreadBuilder) RebuildUids(threads []*types.Thread, inverse bool)
 					return nil
 				}
 				threaduids = append(threaduids, t.Uid)
+				if t.Folded {
+					return types.ErrSkipThread
+				}
 				return nil
 			})
 		if inverse {
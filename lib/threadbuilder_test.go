@@ -0,0 +1,46 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+
+	"git.sr.ht/~rjarry/aerc/lib/iterator"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+)
+
+func TestRebuildUidsFolded(t *testing.T) {
+	// 1 ── 2 ── 3
+	//  └── 4
+	// 5 ── 6
+	root1 := &types.Thread{Uid: 1}
+	two := &types.Thread{Uid: 2}
+	root1.AddChild(two)
+	two.AddChild(&types.Thread{Uid: 3})
+	root1.AddChild(&types.Thread{Uid: 4})
+	root2 := &types.Thread{Uid: 5}
+	root2.AddChild(&types.Thread{Uid: 6})
+	threads := []*types.Thread{root1, root2}
+
+	builder := NewThreadBuilder(iterator.NewFactory(true))
+	tests := []struct {
+		folded []*types.Thread
+		uids   []uint32
+	}{
+		{nil, []uint32{1, 2, 3, 4, 5, 6}},
+		{[]*types.Thread{root1}, []uint32{1, 5, 6}},
+		{[]*types.Thread{two}, []uint32{1, 2, 4, 5, 6}},
+		{[]*types.Thread{root1, root2}, []uint32{1, 5}},
+	}
+	for _, test := range tests {
+		for _, thread := range []*types.Thread{root1, two, root2} {
+			thread.Folded = false
+		}
+		for _, thread := range test.folded {
+			thread.Folded = true
+		}
+		builder.RebuildUids(threads, false)
+		if uids := builder.Uids(); !reflect.DeepEqual(uids, test.uids) {
+			t.Errorf("%v: expected %v, got %v", test.folded, test.uids, uids)
+		}
+	}
+}
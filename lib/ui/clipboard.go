@@ -0,0 +1,14 @@
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// SetClipboard copies text to the system clipboard with the OSC 52 escape
+// sequence. It requires a terminal supporting it.
+func SetClipboard(text string) error {
+	_, err := fmt.Fprintf(RawOutput, "\x1b]52;c;%s\x07",
+		base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
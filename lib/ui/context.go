@@ -14,6 +14,7 @@ type Context struct {
 	viewport  *views.ViewPort
 	x, y      int
 	onPopover func(*Popover)
+	onGraphic func(*Graphic)
 }
 
 func (ctx *Context) X() int {
@@ -36,9 +37,11 @@ func (ctx *Context) Height() int {
 	return height
 }
 
-func NewContext(width, height int, screen tcell.Screen, p func(*Popover)) *Context {
+func NewContext(
+	width, height int, screen tcell.Screen, p func(*Popover), g func(*Graphic),
+) *Context {
 	vp := views.NewViewPort(screen, 0, 0, width, height)
-	return &Context{screen, vp, 0, 0, p}
+	return &Context{screen, vp, 0, 0, p, g}
 }
 
 func (ctx *Context) Subcontext(x, y, width, height int) *Context {
@@ -50,7 +53,7 @@ func (ctx *Context) Subcontext(x, y, width, height int) *Context {
 		panic(fmt.Errorf("Attempted to create context larger than parent"))
 	}
 	vp := views.NewViewPort(ctx.viewport, x, y, width, height)
-	return &Context{ctx.screen, vp, ctx.x + x, ctx.y + y, ctx.onPopover}
+	return &Context{ctx.screen, vp, ctx.x + x, ctx.y + y, ctx.onPopover, ctx.onGraphic}
 }
 
 func (ctx *Context) SetCell(x, y int, ch rune, style tcell.Style) {
@@ -135,6 +138,19 @@ func (ctx *Context) Popover(x, y, width, height int, d Drawable) {
 	})
 }
 
+// Graphic displays raw terminal graphics data (e.g. a sixel image) at the
+// given position once the screen has been shown. The cells covered by the
+// image must be drawn as blanks. clear is an optional escape sequence which
+// removes the image from the terminal.
+func (ctx *Context) Graphic(x, y int, data []byte, clear string) {
+	ctx.onGraphic(&Graphic{
+		x:     ctx.x + x,
+		y:     ctx.y + y,
+		data:  data,
+		clear: clear,
+	})
+}
+
 func (ctx *Context) View() *views.ViewPort {
 	return ctx.viewport
 }
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"git.sr.ht/~rjarry/aerc/log"
+)
+
+// RawOutput is where escape sequences unknown to tcell are written, bypassing
+// it.
+var RawOutput io.Writer = os.Stdout
+
+// A Graphic is raw terminal graphics data displayed at a screen position.
+type Graphic struct {
+	x, y  int
+	data  []byte
+	clear string
+}
+
+func (g *Graphic) equal(other *Graphic) bool {
+	return g.x == other.x && g.y == other.y && g.clear == other.clear &&
+		bytes.Equal(g.data, other.data)
+}
+
+// showGraphics writes the graphics of the last Draw to the terminal if they
+// changed since the previous one. Terminals keep images until the cells
+// beneath are redrawn, the whole screen is synchronized to erase the
+// previous ones.
+func (state *UI) showGraphics() {
+	if len(state.graphics) == len(state.shown) {
+		same := true
+		for i, g := range state.graphics {
+			same = same && g.equal(state.shown[i])
+		}
+		if same {
+			return
+		}
+	}
+	var buf bytes.Buffer
+	if len(state.shown) > 0 {
+		for _, g := range state.shown {
+			buf.WriteString(g.clear)
+		}
+		state.writeGraphics(&buf)
+		state.screen.Sync()
+	}
+	for _, g := range state.graphics {
+		// save and restore the cursor position tracked by tcell
+		fmt.Fprintf(&buf, "\x1b7\x1b[%d;%dH", g.y+1, g.x+1)
+		buf.Write(g.data)
+		buf.WriteString("\x1b8")
+	}
+	state.writeGraphics(&buf)
+	state.shown = state.graphics
+}
+
+func (state *UI) writeGraphics(buf *bytes.Buffer) {
+	if buf.Len() == 0 {
+		return
+	}
+	if _, err := RawOutput.Write(buf.Bytes()); err != nil {
+		log.Errorf("failed to write graphics: %v", err)
+	}
+	buf.Reset()
+}
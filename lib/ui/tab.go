@@ -37,6 +37,10 @@ func (t *Tab) SetTitle(s string) {
 	t.title = s
 }
 
+func (t *Tab) SetUiConfig(uiConf *config.UIConfig) {
+	t.uiConf = uiConf
+}
+
 type (
 	TabStrip   Tabs
 	TabContent Tabs
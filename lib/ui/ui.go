@@ -48,6 +48,10 @@ type UI struct {
 	ctx     *Context
 	screen  tcell.Screen
 	popover *Popover
+
+	// graphics drawn during the current and the previous render
+	graphics []*Graphic
+	shown    []*Graphic
 }
 
 func Initialize(content DrawableInteractive) (*UI, error) {
@@ -70,7 +74,7 @@ func Initialize(content DrawableInteractive) (*UI, error) {
 		Content: content,
 		screen:  screen,
 	}
-	state.ctx = NewContext(width, height, screen, state.onPopover)
+	state.ctx = NewContext(width, height, screen, state.onPopover, state.onGraphic)
 
 	state.exit.Store(false)
 
@@ -91,6 +95,10 @@ func (state *UI) onPopover(p *Popover) {
 	state.popover = p
 }
 
+func (state *UI) onGraphic(g *Graphic) {
+	state.graphics = append(state.graphics, g)
+}
+
 func (state *UI) ShouldExit() bool {
 	return state.exit.Load().(bool)
 }
@@ -106,14 +114,16 @@ func (state *UI) Close() {
 func (state *UI) Render() {
 	dirtyState := atomic.SwapInt32(&dirty, NOT_DIRTY)
 	if dirtyState == DIRTY {
-		// reset popover for the next Draw
+		// reset popover and graphics for the next Draw
 		state.popover = nil
+		state.graphics = nil
 		state.Content.Draw(state.ctx)
 		if state.popover != nil {
 			// if the Draw resulted in a popover, draw it
 			state.popover.Draw(state.ctx)
 		}
 		state.screen.Show()
+		state.showGraphics()
 	}
 }
 
@@ -134,7 +144,9 @@ func (state *UI) HandleEvent(event tcell.Event) {
 	if event, ok := event.(*tcell.EventResize); ok {
 		state.screen.Clear()
 		width, height := event.Size()
-		state.ctx = NewContext(width, height, state.screen, state.onPopover)
+		state.ctx = NewContext(width, height, state.screen,
+			state.onPopover, state.onGraphic)
+		state.shown = nil
 		Invalidate()
 	}
 	// if we have a popover, and it can handle the event, it does so
@@ -20,12 +20,18 @@ type TemplateData interface {
 	DateAutoFormat(date time.Time) string
 	Header(name string) string
 	ThreadPrefix() string
+	ThreadFolded() bool
+	ThreadCount() int
+	ThreadUnread() int
 	Subject() string
 	SubjectBase() string
 	Number() int
 	Labels() []string
 	Flags() []string
 	MessageId() string
+	ListId() string
+	SenderWarnings() []string
+	SenderTrust() string
 	Size() int
 	OriginalText() string
 	OriginalDate() time.Time
@@ -42,6 +48,7 @@ type TemplateData interface {
 	StatusInfo() string
 	TrayInfo() string
 	PendingKeys() string
+	ErrorText() string
 	Style(string, string) string
 	StyleSwitch(string, ...Case) string
 }
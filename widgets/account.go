@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"os/exec"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 
 	"git.sr.ht/~rjarry/aerc/config"
 	"git.sr.ht/~rjarry/aerc/lib"
+	"git.sr.ht/~rjarry/aerc/lib/auth"
+	"git.sr.ht/~rjarry/aerc/lib/contacts"
+	"git.sr.ht/~rjarry/aerc/lib/ipc"
 	"git.sr.ht/~rjarry/aerc/lib/marker"
+	"git.sr.ht/~rjarry/aerc/lib/mute"
+	"git.sr.ht/~rjarry/aerc/lib/rules"
 	"git.sr.ht/~rjarry/aerc/lib/sort"
 	"git.sr.ht/~rjarry/aerc/lib/state"
 	"git.sr.ht/~rjarry/aerc/lib/templates"
@@ -26,18 +33,20 @@ var _ ProvidesMessages = (*AccountView)(nil)
 
 type AccountView struct {
 	sync.Mutex
-	acct    *config.AccountConfig
-	aerc    *Aerc
-	dirlist DirectoryLister
-	labels  []string
-	grid    *ui.Grid
-	host    TabHost
-	tab     *ui.Tab
-	msglist *MessageList
-	worker  *types.Worker
-	state   state.AccountState
-	newConn bool // True if this is a first run after a new connection/reconnection
-	uiConf  *config.UIConfig
+	acct     *config.AccountConfig
+	aerc     *Aerc
+	dirlist  DirectoryLister
+	labels   []string
+	grid     *ui.Grid
+	host     TabHost
+	tab      *ui.Tab
+	msglist  *MessageList
+	worker   *types.Worker
+	state    state.AccountState
+	newConn  bool                  // True if this is a first run after a new connection/reconnection
+	connLost bool                  // True after a connection error until reconnected
+	counts   map[string]ipc.Counts // last message counts sent to IPC clients
+	uiConf   *config.UIConfig
 
 	split         *MessageViewer
 	splitSize     int
@@ -47,6 +56,13 @@ type AccountView struct {
 	// Check-mail ticker
 	ticker       *time.Ticker
 	checkingMail bool
+
+	// Sender trust checks, loaded on first use
+	sendersOnce sync.Once
+	senders     atomic.Value
+
+	muted          *mute.List
+	saveMutedTimer *time.Timer
 }
 
 func (acct *AccountView) UiConfig() *config.UIConfig {
@@ -67,6 +83,10 @@ func NewAccountView(
 		aerc:   aerc,
 		host:   host,
 		uiConf: acctUiConf,
+		muted:  mute.NewList(mute.DefaultPath(acct.Name)),
+	}
+	if err := view.muted.Load(); err != nil {
+		log.Errorf("%s: could not load muted threads: %v", acct.Name, err)
 	}
 
 	view.grid = ui.NewGrid().Rows([]ui.GridSpec{
@@ -114,6 +134,179 @@ func NewAccountView(
 	return view, nil
 }
 
+// SenderChecker returns the checker used to detect spoofed senders. It trusts
+// the saved contacts and the entries of the address book command, which is
+// run in the background the first time.
+func (acct *AccountView) SenderChecker() *auth.SenderChecker {
+	acct.sendersOnce.Do(func() {
+		book := contacts.NewBook(contacts.DefaultPath())
+		if err := book.Load(); err != nil {
+			log.Errorf("could not load contacts: %v", err)
+		}
+		acct.senders.Store(acct.newSenderChecker(book))
+		go func() {
+			defer log.PanicHandler()
+			if err := importAddressBook(acct.acct, book); err != nil {
+				log.Errorf("could not read address book: %v", err)
+				return
+			}
+			acct.senders.Store(acct.newSenderChecker(book))
+			ui.Invalidate()
+		}()
+	})
+	return acct.senders.Load().(*auth.SenderChecker)
+}
+
+func (acct *AccountView) newSenderChecker(book *contacts.Book) *auth.SenderChecker {
+	var own []string
+	if acct.acct.From != nil {
+		own = append(own, acct.acct.From.Address)
+	}
+	for _, alias := range acct.acct.Aliases {
+		own = append(own, alias.Address)
+	}
+	return auth.NewSenderChecker(book.Contacts(), own, acct.acct.TrustedAuthRes)
+}
+
+// onMuted archives the messages of muted threads if enabled and saves the
+// muted threads, which grow with each message found in them.
+func (acct *AccountView) onMuted(store *lib.MessageStore, msg *models.MessageInfo) {
+	if acct.acct.ArchiveMuted && acct.acct.Archive != "" &&
+		store.DirInfo.Name != acct.acct.Archive {
+		store.Move([]uint32{msg.Uid}, acct.acct.Archive, true,
+			func(msg types.WorkerMessage) {
+				if msg, ok := msg.(*types.Error); ok {
+					acct.aerc.PushError(msg.Error.Error())
+				}
+			})
+	}
+	acct.SaveMuted()
+}
+
+// MutedThreads returns the list of muted threads of the account.
+func (acct *AccountView) MutedThreads() *mute.List {
+	return acct.muted
+}
+
+// SaveMuted saves the muted threads after a short delay, so that many
+// changes only cause one write.
+func (acct *AccountView) SaveMuted() {
+	acct.Lock()
+	defer acct.Unlock()
+	if acct.saveMutedTimer != nil {
+		acct.saveMutedTimer.Stop()
+	}
+	acct.saveMutedTimer = time.AfterFunc(time.Second, func() {
+		defer log.PanicHandler()
+		if err := acct.muted.Save(); err != nil {
+			log.Errorf("%s: could not save muted threads: %v",
+				acct.acct.Name, err)
+		}
+	})
+}
+
+// ApplyRules runs the [rules] of aerc.conf on the given messages of the
+// store and returns how many of them matched at least one rule.
+func (acct *AccountView) ApplyRules(store *lib.MessageStore, uids []uint32) int {
+	if len(config.Rules) == 0 {
+		return 0
+	}
+	var msgs []*models.MessageInfo
+	for _, uid := range uids {
+		if msg, ok := store.Messages[uid]; ok && msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+	n := 0
+	for _, batch := range rules.Plan(config.Rules, store.DirInfo.Name, msgs) {
+		n += len(batch.Uids)
+		acct.runRuleActions(store, batch.Uids, batch.Actions)
+	}
+	return n
+}
+
+// runRuleActions applies the actions one after the other, stopping at the
+// first error.
+func (acct *AccountView) runRuleActions(store *lib.MessageStore,
+	uids []uint32, actions []rules.Action,
+) {
+	if len(actions) == 0 {
+		return
+	}
+	action := actions[0]
+	next := func() {
+		acct.runRuleActions(store, uids, actions[1:])
+	}
+	done := func(msg types.WorkerMessage) {
+		switch msg := msg.(type) {
+		case *types.Done:
+			next()
+		case *types.Unsupported:
+			acct.aerc.PushError(fmt.Sprintf(
+				"rules: %s: not supported by the backend", action))
+		case *types.Error:
+			acct.aerc.PushError(fmt.Sprintf(
+				"rules: %s: %v", action, msg.Error))
+		}
+	}
+	switch action.Name {
+	case rules.Move:
+		store.Move(uids, action.Args[0], false, done)
+	case rules.Copy:
+		store.Copy(uids, action.Args[0], false, done)
+	case rules.Flag:
+		store.Flag(uids, models.FlaggedFlag, true, done)
+	case rules.Read:
+		store.Flag(uids, models.SeenFlag, true, done)
+	case rules.Tag:
+		var add, remove []string
+		for _, tag := range action.Args {
+			if tag[0] == '+' {
+				add = append(add, tag[1:])
+			} else {
+				remove = append(remove, tag[1:])
+			}
+		}
+		store.ModifyLabels(uids, add, remove, done)
+	case rules.Pipe:
+		acct.pipeRuleMessages(store, uids, action.Args, next)
+	}
+}
+
+// pipeRuleMessages runs the command once per message with the full message
+// on its standard input, then calls next from the main loop if all messages
+// were fetched and all commands succeeded.
+func (acct *AccountView) pipeRuleMessages(store *lib.MessageStore,
+	uids []uint32, cmd []string, next func(),
+) {
+	var messages []*types.FullMessage
+	store.FetchFullDone(uids, func(msg *types.FullMessage) {
+		messages = append(messages, msg)
+	}, func(missing []uint32) {
+		if len(missing) > 0 {
+			acct.aerc.PushError(fmt.Sprintf(
+				"rules: pipe %s: failed to fetch %d of %d messages",
+				cmd[0], len(missing), len(uids)))
+			return
+		}
+		go func() {
+			defer log.PanicHandler()
+			for _, msg := range messages {
+				ecmd := exec.Command(cmd[0], cmd[1:]...)
+				ecmd.Stdin = msg.Content.Reader
+				out, err := ecmd.CombinedOutput()
+				if err != nil {
+					log.Errorf("rules: %s: %s", cmd[0], out)
+					acct.aerc.PushError(fmt.Sprintf(
+						"rules: pipe %s: %v", cmd[0], err))
+					return
+				}
+			}
+			ui.QueueFunc(next)
+		}()
+	})
+}
+
 func (acct *AccountView) SetStatus(setters ...state.SetStateFunc) {
 	for _, fn := range setters {
 		fn(&acct.state, acct.SelectedDirectory())
@@ -147,6 +340,10 @@ func (acct *AccountView) Worker() *types.Worker {
 	return acct.worker
 }
 
+func (acct *AccountView) Connected() bool {
+	return acct.state.Connected
+}
+
 func (acct *AccountView) Name() string {
 	return acct.acct.Name
 }
@@ -249,13 +446,27 @@ func (acct *AccountView) onMessage(msg types.WorkerMessage) {
 				log.Infof("[%s] connected.", acct.acct.Name)
 				acct.SetStatus(state.SetConnected(true))
 				acct.newConn = true
+				acct.publishConnection(true, nil)
+				if acct.connLost {
+					acct.connLost = false
+					acct.runTrigger(config.Triggers.ConnectionRestored,
+						acct.dirlist.Selected())
+				}
 			})
 		case *types.Disconnect:
 			acct.dirlist.ClearList()
 			acct.msglist.SetStore(nil)
 			log.Infof("[%s] disconnected.", acct.acct.Name)
 			acct.SetStatus(state.SetConnected(false))
+			acct.connLost = false
+			acct.publishConnection(false, nil)
 		case *types.OpenDirectory:
+			acct.runTrigger(config.Triggers.FolderOpened,
+				acct.dirlist.Selected())
+			acct.publish(&ipc.Event{
+				Event:  ipc.EventFolder,
+				Folder: acct.dirlist.Selected(),
+			})
 			if store, ok := acct.dirlist.SelectedMsgStore(); ok {
 				// If we've opened this dir before, we can re-render it from
 				// memory while we wait for the update and the UI feels
@@ -288,15 +499,14 @@ func (acct *AccountView) onMessage(msg types.WorkerMessage) {
 				acct.dirlist.UiConfig(name).ReverseThreadOrder,
 				acct.dirlist.UiConfig(name).SortThreadSiblings,
 				func(msg *models.MessageInfo) {
-					if len(config.Triggers.NewEmail) == 0 {
-						return
-					}
-					err := acct.aerc.cmd(
-						config.Triggers.NewEmail,
-						acct.acct, msg)
-					if err != nil {
-						acct.aerc.PushError(err.Error())
-					}
+					acct.ApplyRules(store, []uint32{msg.Uid})
+					acct.runTrigger(config.Triggers.NewEmail, name, msg)
+					m := apiMessage(store, msg)
+					acct.publish(&ipc.Event{
+						Event:   ipc.EventNewMail,
+						Folder:  name,
+						Message: &m,
+					})
 				}, func() {
 					if acct.dirlist.UiConfig(name).NewMessageBell {
 						acct.host.Beep()
@@ -305,6 +515,20 @@ func (acct *AccountView) onMessage(msg types.WorkerMessage) {
 				acct.updateSplitView,
 			)
 			store.SetMarker(marker.New(store))
+			store.SetMuted(acct.muted, func(msg *models.MessageInfo) {
+				acct.onMuted(store, msg)
+			})
+			store.SetEvents(lib.StoreEvents{
+				Deleted: func(msgs []*models.MessageInfo) {
+					acct.runTrigger(config.Triggers.MailDeleted, name, msgs...)
+				},
+				Moved: func(dest string, msgs []*models.MessageInfo) {
+					acct.runTrigger(config.Triggers.MailMoved, dest, msgs...)
+				},
+				FlagChanged: func(msgs []*models.MessageInfo) {
+					acct.runTrigger(config.Triggers.FlagChanged, name, msgs...)
+				},
+			})
 			acct.dirlist.SetMsgStore(msg.Info.Name, store)
 		}
 	case *types.DirectoryContents:
@@ -352,7 +576,16 @@ func (acct *AccountView) onMessage(msg types.WorkerMessage) {
 		acct.labels = msg.Labels
 	case *types.ConnError:
 		log.Errorf("[%s] connection error: %v", acct.acct.Name, msg.Error)
+		wasConnected := acct.state.Connected
 		acct.SetStatus(state.SetConnected(false))
+		if wasConnected {
+			// only once until the connection is restored
+			acct.connLost = true
+			data := acct.triggerData(acct.dirlist.Selected(), nil)
+			data.SetError(msg.Error)
+			acct.aerc.RunTrigger(config.Triggers.ConnectionLost, data)
+			acct.publishConnection(false, msg.Error)
+		}
 		acct.PushError(msg.Error)
 		acct.msglist.SetStore(nil)
 		acct.worker.PostAction(&types.Reconnect{}, nil)
@@ -360,6 +593,10 @@ func (acct *AccountView) onMessage(msg types.WorkerMessage) {
 		log.Errorf("[%s] unexpected error: %v", acct.acct.Name, msg.Error)
 		acct.PushError(msg.Error)
 	}
+	if info, ok := msg.(*types.DirectoryInfo); ok {
+		acct.publishCounts(info.Info.Name)
+	}
+	acct.publishCounts(acct.dirlist.Selected())
 	acct.UpdateStatus()
 	acct.setTitle()
 }
@@ -484,6 +721,34 @@ func (acct *AccountView) CheckMailTimer(d time.Duration) {
 	}()
 }
 
+// reloadUi applies the ui settings of the reloaded configuration.
+func (acct *AccountView) reloadUi() {
+	acct.uiConf = config.Ui.ForAccount(acct.Name())
+	if acct.tab != nil {
+		acct.tab.SetUiConfig(acct.uiConf)
+	}
+	// rebuild the layout with the new borders and sizes
+	size, dir := acct.splitSize, acct.splitDir
+	acct.closeSplit()
+	switch dir {
+	case "split":
+		_ = acct.Split(size)
+	case "vsplit":
+		_ = acct.Vsplit(size)
+	}
+	acct.setTitle()
+}
+
+// close stops the check-mail timer and disconnects the worker of an account
+// removed or replaced by a reload.
+func (acct *AccountView) close() {
+	if acct.ticker != nil {
+		acct.ticker.Stop()
+	}
+	acct.closeSplit()
+	acct.worker.PostAction(&types.Disconnect{}, nil)
+}
+
 func (acct *AccountView) closeSplit() {
 	if acct.split != nil {
 		acct.split.Close()
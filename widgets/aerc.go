@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
@@ -26,7 +27,7 @@ import (
 
 type Aerc struct {
 	accounts    map[string]*AccountView
-	cmd         func([]string, *config.AccountConfig, *models.MessageInfo) error
+	cmd         func([]string, models.TemplateData) error
 	cmdHistory  lib.History
 	complete    func(cmd string) []string
 	focused     ui.Interactive
@@ -41,6 +42,12 @@ type Aerc struct {
 	ui          *ui.UI
 	beep        func() error
 	dialog      ui.DrawableInteractive
+	watching    bool
+
+	// status messages pushed while executing IPC commands
+	captureLock sync.Mutex
+	captured    *[]string
+	onStatus    func(text string, isError bool)
 
 	Crypto crypto.Provider
 }
@@ -53,7 +60,7 @@ type Choice struct {
 
 func NewAerc(
 	crypto crypto.Provider,
-	cmd func([]string, *config.AccountConfig, *models.MessageInfo) error,
+	cmd func([]string, models.TemplateData) error,
 	complete func(cmd string) []string, cmdHistory lib.History,
 	deferLoop chan struct{},
 ) *Aerc {
@@ -124,6 +131,15 @@ func NewAerc(
 			content.Close(nil)
 		case *MessageViewer:
 			aerc.RemoveTab(content)
+		case *ThreadViewer:
+			aerc.RemoveTab(content)
+		case *SieveView:
+			aerc.RemoveTab(content)
+		case *ContactsView:
+			aerc.RemoveTab(content)
+			if err := content.Save(); err != nil {
+				aerc.PushError(err.Error())
+			}
 		}
 	}
 
@@ -170,7 +186,9 @@ func (aerc *Aerc) Beep() {
 }
 
 func (aerc *Aerc) HandleMessage(msg types.WorkerMessage) {
-	if acct, ok := aerc.accounts[msg.Account()]; ok {
+	acct, ok := aerc.accounts[msg.Account()]
+	// ignore the workers of accounts replaced by a reload
+	if ok && msg.Worker() == acct.worker {
 		acct.onMessage(msg)
 	}
 }
@@ -278,6 +296,12 @@ func (aerc *Aerc) getBindings() *config.KeyBindings {
 		}
 	case *Terminal:
 		return config.Binds.Terminal
+	case *ContactsView:
+		return config.Binds.Contacts
+	case *ThreadViewer:
+		return config.Binds.ThreadView.ForAccount(selectedAccountName)
+	case *SieveView:
+		return config.Binds.Sieve
 	default:
 		return config.Binds.Global
 	}
@@ -451,6 +475,12 @@ func (aerc *Aerc) account(d ui.Drawable) *AccountView {
 		return tab.SelectedAccount()
 	case *Composer:
 		return tab.Account()
+	case *ContactsView:
+		return tab.SelectedAccount()
+	case *ThreadViewer:
+		return tab.SelectedAccount()
+	case *SieveView:
+		return tab.SelectedAccount()
 	}
 	return nil
 }
@@ -482,6 +512,9 @@ func (aerc *Aerc) NewTab(clickable ui.Drawable, name string) *ui.Tab {
 	}
 	tab := aerc.tabs.Add(clickable, name, uiConf)
 	aerc.UpdateStatus()
+	if composer, ok := clickable.(*Composer); ok {
+		composer.triggerOpened()
+	}
 	return tab
 }
 
@@ -550,19 +583,51 @@ func (aerc *Aerc) SetError(err string) {
 	aerc.statusline.SetError(err)
 }
 
+// captureStatus appends the status messages pushed from the main loop to
+// messages until it is called with nil.
+func (aerc *Aerc) captureStatus(messages *[]string) {
+	aerc.captureLock.Lock()
+	aerc.captured = messages
+	aerc.captureLock.Unlock()
+}
+
+func (aerc *Aerc) capture(text string, isError bool) {
+	aerc.captureLock.Lock()
+	if aerc.captured != nil {
+		*aerc.captured = append(*aerc.captured, text)
+	}
+	onStatus := aerc.onStatus
+	aerc.captureLock.Unlock()
+	if onStatus != nil {
+		onStatus(text, isError)
+	}
+}
+
+// OnStatus registers a function called with every status message, e.g. to
+// print them when running without user interface.
+func (aerc *Aerc) OnStatus(fn func(text string, isError bool)) {
+	aerc.captureLock.Lock()
+	aerc.onStatus = fn
+	aerc.captureLock.Unlock()
+}
+
 func (aerc *Aerc) PushStatus(text string, expiry time.Duration) *StatusMessage {
+	aerc.capture(text, false)
 	return aerc.statusline.Push(text, expiry)
 }
 
 func (aerc *Aerc) PushError(text string) *StatusMessage {
+	aerc.capture(text, true)
 	return aerc.statusline.PushError(text)
 }
 
 func (aerc *Aerc) PushWarning(text string) *StatusMessage {
+	aerc.capture(text, false)
 	return aerc.statusline.PushWarning(text)
 }
 
 func (aerc *Aerc) PushSuccess(text string) *StatusMessage {
+	aerc.capture(text, false)
 	return aerc.statusline.PushSuccess(text)
 }
 
@@ -601,7 +666,7 @@ func (aerc *Aerc) BeginExCommand(cmd string) {
 		if err != nil {
 			aerc.PushError(err.Error())
 		}
-		err = aerc.cmd(parts, nil, nil)
+		err = aerc.cmd(parts, nil)
 		if err != nil {
 			aerc.PushError(err.Error())
 		}
@@ -627,7 +692,7 @@ func (aerc *Aerc) RegisterPrompt(prompt string, cmd []string) {
 		if text != "" {
 			cmd = append(cmd, text)
 		}
-		err := aerc.cmd(cmd, nil, nil)
+		err := aerc.cmd(cmd, nil)
 		if err != nil {
 			aerc.PushError(err.Error())
 		}
@@ -654,7 +719,7 @@ func (aerc *Aerc) RegisterChoices(choices []Choice) {
 		if !ok {
 			return
 		}
-		err := aerc.cmd(cmd, nil, nil)
+		err := aerc.cmd(cmd, nil)
 		if err != nil {
 			aerc.PushError(err.Error())
 		}
@@ -785,7 +850,7 @@ func (aerc *Aerc) Mbox(source string) error {
 func (aerc *Aerc) Command(args []string) error {
 	defer ui.QueueRedraw()
 	defer ui.Invalidate()
-	return aerc.cmd(args, nil, nil)
+	return aerc.cmd(args, nil)
 }
 
 func (aerc *Aerc) CloseBackends() error {
@@ -0,0 +1,404 @@
+package widgets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message"
+	"github.com/emersion/go-message/mail"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib"
+	"git.sr.ht/~rjarry/aerc/lib/ipc"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/models"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+)
+
+var _ ipc.API = (*Aerc)(nil)
+
+// apiTimeout limits how long JSON API requests wait for the backends.
+const apiTimeout = 30 * time.Second
+
+// onMainLoop runs fn from the main loop and waits for its completion. fn
+// calls done with its result, possibly later from a worker callback. The
+// result must only be built by fn since it may still run after a timeout.
+func onMainLoop(
+	fn func(done func(interface{}, error)),
+) (interface{}, error) {
+	type response struct {
+		value interface{}
+		err   error
+	}
+	result := make(chan response, 1)
+	ui.QueueFunc(func() {
+		fn(func(value interface{}, err error) {
+			select {
+			case result <- response{value, err}:
+			default:
+			}
+		})
+	})
+	select {
+	case r := <-result:
+		return r.value, r.err
+	case <-time.After(apiTimeout):
+		return nil, errors.New("timeout")
+	}
+}
+
+// apiAccount returns the named account or the selected one.
+func (aerc *Aerc) apiAccount(name string) (*AccountView, error) {
+	if name != "" {
+		return aerc.Account(name)
+	}
+	if acct := aerc.SelectedAccount(); acct != nil {
+		return acct, nil
+	}
+	return nil, errors.New("no account selected")
+}
+
+// apiStore returns the message store of the selected folder of the account.
+// Other folders cannot be queried since the backends work on the selected
+// folder only.
+func (aerc *Aerc) apiStore(params *ipc.Params) (*AccountView, *lib.MessageStore, error) {
+	acct, err := aerc.apiAccount(params.Account)
+	if err != nil {
+		return nil, nil, err
+	}
+	if params.Folder != "" && params.Folder != acct.SelectedDirectory() {
+		return nil, nil, fmt.Errorf("folder %s is not selected", params.Folder)
+	}
+	store := acct.Store()
+	if store == nil {
+		return nil, nil, errors.New("folder not loaded")
+	}
+	return acct, store, nil
+}
+
+func (aerc *Aerc) Accounts() ([]ipc.Account, error) {
+	value, err := onMainLoop(func(done func(interface{}, error)) {
+		accounts := make([]ipc.Account, 0, len(aerc.accounts))
+		selected := aerc.SelectedAccount()
+		for _, conf := range config.Accounts {
+			acct, ok := aerc.accounts[conf.Name]
+			if !ok {
+				continue
+			}
+			accounts = append(accounts, ipc.Account{
+				Name:      acct.Name(),
+				Selected:  acct == selected,
+				Connected: acct.state.Connected,
+				Folder:    acct.SelectedDirectory(),
+			})
+		}
+		done(accounts, nil)
+	})
+	accounts, _ := value.([]ipc.Account)
+	return accounts, err
+}
+
+func (aerc *Aerc) Folders(params *ipc.Params) ([]ipc.Folder, error) {
+	value, err := onMainLoop(func(done func(interface{}, error)) {
+		acct, err := aerc.apiAccount(params.Account)
+		if err != nil {
+			done(nil, err)
+			return
+		}
+		dirs := acct.Directories()
+		folders := make([]ipc.Folder, 0, len(dirs.List()))
+		for _, name := range dirs.List() {
+			recent, unread, exists := dirs.GetRUECount(name)
+			folders = append(folders, ipc.Folder{
+				Name:     name,
+				Selected: name == dirs.Selected(),
+				Recent:   recent,
+				Unread:   unread,
+				Exists:   exists,
+			})
+		}
+		done(folders, nil)
+	})
+	folders, _ := value.([]ipc.Folder)
+	return folders, err
+}
+
+func (aerc *Aerc) Messages(params *ipc.Params) ([]ipc.Message, error) {
+	value, err := onMainLoop(func(done func(interface{}, error)) {
+		_, store, err := aerc.apiStore(params)
+		if err != nil {
+			done(nil, err)
+			return
+		}
+		uids := store.Uids()
+		if params.Limit > 0 && len(uids) > params.Limit {
+			uids = uids[:params.Limit]
+		}
+		list := func() {
+			messages := make([]ipc.Message, 0, len(uids))
+			for _, uid := range uids {
+				if msg := store.Messages[uid]; msg != nil {
+					messages = append(messages, apiMessage(store, msg))
+				}
+			}
+			done(messages, nil)
+		}
+		var missing []uint32
+		for _, uid := range uids {
+			if store.Messages[uid] == nil {
+				missing = append(missing, uid)
+			}
+		}
+		if len(missing) == 0 {
+			list()
+			return
+		}
+		store.FetchHeaders(missing, func(msg types.WorkerMessage) {
+			switch msg := msg.(type) {
+			case *types.Done:
+				list()
+			case *types.Error:
+				done(nil, msg.Error)
+			}
+		})
+	})
+	messages, _ := value.([]ipc.Message)
+	return messages, err
+}
+
+func apiMessage(store *lib.MessageStore, msg *models.MessageInfo) ipc.Message {
+	m := ipc.Message{
+		Uid:      msg.Uid,
+		Flags:    apiFlags(msg.Flags),
+		Labels:   msg.Labels,
+		Size:     msg.Size,
+		Selected: store.Selected() == msg,
+		Marked:   store.Marker().IsMarked(msg.Uid),
+	}
+	if env := msg.Envelope; env != nil {
+		m.Date = env.Date
+		m.Subject = env.Subject
+		m.From = apiAddresses(env.From)
+		m.To = apiAddresses(env.To)
+		m.Cc = apiAddresses(env.Cc)
+		m.MessageId = env.MessageId
+		m.InReplyTo = env.InReplyTo
+	}
+	return m
+}
+
+func apiAddresses(addrs []*mail.Address) []ipc.Address {
+	list := make([]ipc.Address, 0, len(addrs))
+	for _, addr := range addrs {
+		list = append(list, ipc.Address{Name: addr.Name, Address: addr.Address})
+	}
+	return list
+}
+
+func apiFlags(flags models.Flags) []string {
+	names := []string{}
+	for _, f := range []struct {
+		flag models.Flags
+		name string
+	}{
+		{models.SeenFlag, "seen"},
+		{models.RecentFlag, "recent"},
+		{models.AnsweredFlag, "answered"},
+		{models.DeletedFlag, "deleted"},
+		{models.FlaggedFlag, "flagged"},
+	} {
+		if flags.Has(f.flag) {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+func (aerc *Aerc) Body(params *ipc.Params) (*ipc.Body, error) {
+	uid := *params.Uid
+	value, err := onMainLoop(func(done func(interface{}, error)) {
+		_, store, err := aerc.apiStore(params)
+		if err != nil {
+			done(nil, err)
+			return
+		}
+		if _, ok := store.Messages[uid]; !ok {
+			done(nil, fmt.Errorf("message %d not found", uid))
+			return
+		}
+		store.FetchFullDone([]uint32{uid}, func(msg *types.FullMessage) {
+			done(io.ReadAll(msg.Content.Reader))
+		}, func(missing []uint32) {
+			if len(missing) > 0 {
+				done(nil, fmt.Errorf("message %d could not be fetched", uid))
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := value.([]byte)
+	if params.Raw {
+		return &ipc.Body{Uid: uid, MimeType: "message/rfc822", Text: string(raw)}, nil
+	}
+	mimeType, text, err := textPart(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &ipc.Body{Uid: uid, MimeType: mimeType, Text: text}, nil
+}
+
+// textPart returns the first text/plain part of a message, or its first text
+// part if there is no text/plain part.
+func textPart(raw []byte) (string, string, error) {
+	entity, err := message.Read(bytes.NewReader(raw))
+	if err != nil && !message.IsUnknownCharset(err) &&
+		!message.IsUnknownEncoding(err) {
+		return "", "", err
+	}
+	var mimeType, text string
+	errFound := errors.New("found")
+	err = entity.Walk(func(_ []int, part *message.Entity, _ error) error {
+		t, _, _ := part.Header.ContentType()
+		if !strings.HasPrefix(t, "text/") || (mimeType != "" && t != "text/plain") {
+			return nil
+		}
+		body, err := io.ReadAll(part.Body)
+		if err != nil {
+			return err
+		}
+		mimeType, text = t, string(body)
+		if t == "text/plain" {
+			return errFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return "", "", err
+	}
+	if mimeType == "" {
+		return "", "", errors.New("no text part found")
+	}
+	return mimeType, text, nil
+}
+
+func (aerc *Aerc) Execute(params *ipc.Params) (*ipc.CommandResult, error) {
+	args := append([]string{}, params.Command...)
+	args[0] = strings.TrimPrefix(args[0], ":")
+	value, err := onMainLoop(func(done func(interface{}, error)) {
+		result := &ipc.CommandResult{Messages: []string{}}
+		aerc.captureStatus(&result.Messages)
+		err := aerc.cmd(args, nil)
+		aerc.captureStatus(nil)
+		ui.Invalidate()
+		done(result, err)
+	})
+	result, ok := value.(*ipc.CommandResult)
+	if !ok {
+		result = &ipc.CommandResult{Messages: []string{}}
+	}
+	return result, err
+}
+
+func (aerc *Aerc) Select(params *ipc.Params) error {
+	_, err := onMainLoop(func(done func(interface{}, error)) {
+		acct, err := aerc.apiAccount(params.Account)
+		if err != nil {
+			done(nil, err)
+			return
+		}
+		aerc.SelectTab(acct.Name())
+		folder := params.Folder
+		if folder == "" {
+			folder = acct.SelectedDirectory()
+		} else if folder != acct.SelectedDirectory() {
+			acct.Directories().Select(folder)
+		}
+		if params.Uid == nil {
+			done(nil, nil)
+			return
+		}
+		// wait for the folder to be loaded
+		deadline := time.Now().Add(10 * time.Second)
+		var selectUid func()
+		selectUid = func() {
+			store := acct.Store()
+			if acct.SelectedDirectory() == folder && store != nil {
+				if _, ok := store.Messages[*params.Uid]; ok {
+					store.Select(*params.Uid)
+					ui.Invalidate()
+					done(nil, nil)
+					return
+				}
+			}
+			if time.Now().After(deadline) {
+				done(nil, fmt.Errorf("message %d not found in %s",
+					*params.Uid, folder))
+				return
+			}
+			time.AfterFunc(100*time.Millisecond, func() {
+				ui.QueueFunc(selectUid)
+			})
+		}
+		selectUid()
+	})
+	return err
+}
+
+// publish sends an IPC event of the account to the subscribed clients.
+func (acct *AccountView) publish(ev *ipc.Event) {
+	ev.Account = acct.Name()
+	ipc.Publish(ev)
+}
+
+func (acct *AccountView) publishConnection(connected bool, err error) {
+	ev := &ipc.Event{Event: ipc.EventConnection, Connected: &connected}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	acct.publish(ev)
+}
+
+// publishCounts sends the message counts of a folder when they changed since
+// they were last sent.
+func (acct *AccountView) publishCounts(folder string) {
+	if folder == "" || !ipc.Subscribed(ipc.EventUnread) {
+		return
+	}
+	if _, ok := acct.dirlist.MsgStore(folder); !ok {
+		return
+	}
+	var counts ipc.Counts
+	counts.Recent, counts.Unread, counts.Exists = acct.dirlist.GetRUECount(folder)
+	if acct.counts == nil {
+		acct.counts = make(map[string]ipc.Counts)
+	}
+	if last, ok := acct.counts[folder]; ok && last == counts {
+		return
+	}
+	acct.counts[folder] = counts
+	acct.publish(&ipc.Event{
+		Event:  ipc.EventUnread,
+		Folder: folder,
+		Counts: &counts,
+	})
+}
+
+// headerMessage returns the envelope data of a message being sent.
+func headerMessage(h *mail.Header) *ipc.Message {
+	m := &ipc.Message{Flags: []string{}}
+	m.Subject, _ = h.Subject()
+	m.Date, _ = h.Date()
+	m.MessageId, _ = h.MessageID()
+	from, _ := h.AddressList("from")
+	m.From = apiAddresses(from)
+	to, _ := h.AddressList("to")
+	m.To = apiAddresses(to)
+	cc, _ := h.AddressList("cc")
+	m.Cc = apiAddresses(cc)
+	return m
+}
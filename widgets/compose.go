@@ -433,6 +433,10 @@ func (c *Composer) SetContents(reader io.Reader) *Composer {
 	if err != nil {
 		log.Warnf("failed to seek beginning of mail: %v", err)
 	}
+	err = c.email.Truncate(0)
+	if err != nil {
+		log.Warnf("failed to truncate mail: %v", err)
+	}
 	_, err = io.Copy(c.email, reader)
 	if err != nil {
 		log.Warnf("failed to copy mail: %v", err)
@@ -463,6 +467,20 @@ func (c *Composer) AppendContents(reader io.Reader) {
 	}
 }
 
+// PrependContents inserts text at the beginning of the message body.
+func (c *Composer) PrependContents(reader io.Reader) {
+	_, err := c.email.Seek(0, io.SeekStart)
+	if err != nil {
+		log.Warnf("failed to seek beginning of mail: %v", err)
+	}
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, c.email)
+	if err != nil {
+		log.Warnf("failed to read mail: %v", err)
+	}
+	c.SetContents(io.MultiReader(reader, &buf))
+}
+
 func (c *Composer) AppendPart(mimetype string, params map[string]string, body io.Reader) error {
 	if !strings.HasPrefix(mimetype, "text") {
 		return fmt.Errorf("can only append text mimetypes")
@@ -967,6 +985,14 @@ func (c *Composer) AddPartAttachment(name string, mimetype string,
 	return nil
 }
 
+// AddDigestAttachment attaches the given raw messages as a single
+// multipart/digest part.
+func (c *Composer) AddDigestAttachment(name string, messages [][]byte) {
+	c.attachments = append(c.attachments,
+		lib.NewDigestAttachment(name, messages))
+	c.resetReview()
+}
+
 func (c *Composer) DeleteAttachment(name string) error {
 	for i, a := range c.attachments {
 		if a.Name() == name {
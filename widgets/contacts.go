@@ -0,0 +1,449 @@
+package widgets
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/shlex"
+	"github.com/mattn/go-runewidth"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/contacts"
+	"git.sr.ht/~rjarry/aerc/lib/format"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/models"
+	"git.sr.ht/~rjarry/aerc/worker/types"
+)
+
+const contactListWidth = 30
+
+// contactSearchDelay is how long the selection must stay on a contact before
+// its messages are searched.
+const contactSearchDelay = 500 * time.Millisecond
+
+// A contactMessage is a message exchanged with a contact, found by searching
+// all folders of the account.
+type contactMessage struct {
+	Directory string
+	Info      *models.MessageInfo
+}
+
+// ContactsView lists the known contacts of an account: entries from the
+// address book command and addresses harvested from the loaded messages.
+// Selecting a contact shows its addresses, PGP key status and the messages
+// exchanged with it across all folders.
+type ContactsView struct {
+	aerc     *Aerc
+	acct     *AccountView
+	book     *contacts.Book
+	uiConfig *config.UIConfig
+
+	mu       sync.Mutex
+	contacts []*contacts.Contact
+	selected int
+	scroll   int
+	keys     map[string]string
+	messages map[*contacts.Contact][]contactMessage
+	loading  map[*contacts.Contact]bool
+	search   *time.Timer
+}
+
+func NewContactsView(aerc *Aerc, acct *AccountView) *ContactsView {
+	cv := &ContactsView{
+		aerc:     aerc,
+		acct:     acct,
+		book:     contacts.NewBook(contacts.DefaultPath()),
+		uiConfig: acct.UiConfig(),
+		keys:     make(map[string]string),
+		messages: make(map[*contacts.Contact][]contactMessage),
+		loading:  make(map[*contacts.Contact]bool),
+	}
+	if err := cv.book.Load(); err != nil {
+		log.Errorf("could not load contacts: %v", err)
+	}
+	cv.harvest()
+	cv.refresh()
+	go cv.importAddressBook()
+	return cv
+}
+
+// harvest collects the addresses of all messages loaded in the account
+// stores.
+func (cv *ContactsView) harvest() {
+	for _, name := range cv.acct.Directories().List() {
+		store, ok := cv.acct.Directories().MsgStore(name)
+		if !ok {
+			continue
+		}
+		for _, msg := range store.Messages {
+			if msg == nil || msg.Envelope == nil {
+				continue
+			}
+			cv.book.Harvest(msg.Envelope.From)
+			cv.book.Harvest(msg.Envelope.To)
+			cv.book.Harvest(msg.Envelope.Cc)
+		}
+	}
+}
+
+// importAddressBook runs the address-book-cmd with an empty query and adds
+// all returned entries.
+func (cv *ContactsView) importAddressBook() {
+	defer log.PanicHandler()
+
+	if err := importAddressBook(cv.acct.AccountConfig(), cv.book); err != nil {
+		log.Errorf("could not read address book: %v", err)
+		return
+	}
+	ui.QueueFunc(cv.refresh)
+}
+
+// importAddressBook runs the address-book-cmd of an account with an empty
+// query and adds all returned entries to the book.
+func importAddressBook(acct *config.AccountConfig, book *contacts.Book) error {
+	cmd := acct.AddressBookCmd
+	if cmd == "" {
+		cmd = config.Compose.AddressBookCmd
+	}
+	if strings.TrimSpace(cmd) == "" {
+		return nil
+	}
+	args, err := shlex.Split(strings.ReplaceAll(cmd, "%s", ""))
+	if err != nil || len(args) == 0 {
+		return fmt.Errorf("could not parse address-book-cmd %q: %w", cmd, err)
+	}
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		// address book commands may exit with an error status when
+		// there are no matches
+		log.Debugf("address-book-cmd: %v", err)
+	}
+	return book.ReadAddressBook(bytes.NewReader(out))
+}
+
+// refresh reloads the contact list from the book, keeping the selection.
+func (cv *ContactsView) refresh() {
+	cv.mu.Lock()
+	var current *contacts.Contact
+	if cv.selected < len(cv.contacts) {
+		current = cv.contacts[cv.selected]
+	}
+	cv.contacts = cv.book.Contacts()
+	cv.selected = 0
+	for i, c := range cv.contacts {
+		if c == current {
+			cv.selected = i
+		}
+	}
+	cv.mu.Unlock()
+	cv.onSelect()
+	cv.Invalidate()
+}
+
+// Save writes the contacts to disk.
+func (cv *ContactsView) Save() error {
+	return cv.book.Save()
+}
+
+func (cv *ContactsView) Book() *contacts.Book {
+	return cv.book
+}
+
+func (cv *ContactsView) SelectedAccount() *AccountView {
+	return cv.acct
+}
+
+// Selected returns the currently selected contact or nil.
+func (cv *ContactsView) Selected() *contacts.Contact {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if cv.selected < 0 || cv.selected >= len(cv.contacts) {
+		return nil
+	}
+	return cv.contacts[cv.selected]
+}
+
+// Next moves the selection by n contacts, n may be negative.
+func (cv *ContactsView) Next(n int) {
+	cv.mu.Lock()
+	cv.selected += n
+	if cv.selected >= len(cv.contacts) {
+		cv.selected = len(cv.contacts) - 1
+	}
+	if cv.selected < 0 {
+		cv.selected = 0
+	}
+	cv.mu.Unlock()
+	cv.onSelect()
+	cv.Invalidate()
+}
+
+// Merge moves all addresses of src into the selected contact.
+func (cv *ContactsView) Merge(src *contacts.Contact) error {
+	dst := cv.Selected()
+	if dst == nil {
+		return fmt.Errorf("no contact selected")
+	}
+	if dst == src {
+		return fmt.Errorf("cannot merge a contact with itself")
+	}
+	cv.book.Merge(dst, src)
+	cv.mu.Lock()
+	delete(cv.messages, dst)
+	delete(cv.messages, src)
+	cv.mu.Unlock()
+	cv.refresh()
+	return cv.Save()
+}
+
+// Edit changes the name of the selected contact and adds or removes
+// addresses.
+func (cv *ContactsView) Edit(name string, add, remove []string) error {
+	c := cv.Selected()
+	if c == nil {
+		return fmt.Errorf("no contact selected")
+	}
+	if err := cv.book.Edit(c, name, add, remove); err != nil {
+		return err
+	}
+	cv.mu.Lock()
+	delete(cv.messages, c)
+	cv.mu.Unlock()
+	cv.refresh()
+	return cv.Save()
+}
+
+func (cv *ContactsView) onSelect() {
+	c := cv.Selected()
+	if c == nil {
+		return
+	}
+	cv.fetchKeys(c)
+	cv.mu.Lock()
+	if cv.search != nil {
+		cv.search.Stop()
+	}
+	cv.search = time.AfterFunc(contactSearchDelay, func() {
+		ui.QueueFunc(func() {
+			if cv.Selected() == c {
+				cv.fetchMessages(c)
+			}
+		})
+	})
+	cv.mu.Unlock()
+}
+
+// fetchKeys looks up the PGP keys of the contact addresses in the background.
+func (cv *ContactsView) fetchKeys(c *contacts.Contact) {
+	if cv.aerc.Crypto == nil {
+		return
+	}
+	cv.mu.Lock()
+	var missing []string
+	for _, addr := range c.Addresses {
+		if _, ok := cv.keys[addr]; !ok {
+			cv.keys[addr] = "checking..."
+			missing = append(missing, addr)
+		}
+	}
+	cv.mu.Unlock()
+	if len(missing) == 0 {
+		return
+	}
+	go func() {
+		defer log.PanicHandler()
+		for _, addr := range missing {
+			status := "no key"
+			if id, err := cv.aerc.Crypto.GetKeyId(addr); err == nil && id != "" {
+				status = "key " + id
+			}
+			cv.mu.Lock()
+			cv.keys[addr] = status
+			cv.mu.Unlock()
+		}
+		cv.Invalidate()
+	}()
+}
+
+// fetchMessages searches all folders of the account for messages from, to or
+// copied to any address of the contact, with a single search.
+func (cv *ContactsView) fetchMessages(c *contacts.Contact) {
+	cv.mu.Lock()
+	if _, ok := cv.messages[c]; ok || cv.loading[c] {
+		cv.mu.Unlock()
+		return
+	}
+	cv.loading[c] = true
+	cv.messages[c] = nil
+	addrs := make([]string, len(c.Addresses))
+	copy(addrs, c.Addresses)
+	cv.mu.Unlock()
+
+	var searches [][]string
+	for _, addr := range addrs {
+		for _, flag := range []string{"-f", "-t", "-c"} {
+			searches = append(searches, []string{"search", flag, addr})
+		}
+	}
+	seen := make(map[string]bool)
+	cv.acct.Worker().PostAction(&types.SearchDirectories{
+		Directories: cv.acct.Directories().List(),
+		Searches:    searches,
+	}, func(msg types.WorkerMessage) {
+		switch msg := msg.(type) {
+		case *types.DirectorySearchResults:
+			cv.addMessages(c, msg, seen)
+		case *types.Unsupported:
+			cv.acct.PushWarning("backend does not support searching all folders")
+		case *types.Error:
+			log.Errorf("contact search: %v", msg.Error)
+		}
+		switch msg.(type) {
+		case *types.Done, *types.Unsupported, *types.Error:
+			cv.mu.Lock()
+			delete(cv.loading, c)
+			cv.mu.Unlock()
+			cv.Invalidate()
+		}
+	})
+}
+
+func (cv *ContactsView) addMessages(c *contacts.Contact,
+	msg *types.DirectorySearchResults, seen map[string]bool,
+) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	list := cv.messages[c]
+	for _, info := range msg.Infos {
+		key := fmt.Sprintf("%s/%d", msg.Directory, info.Uid)
+		if seen[key] || info.Envelope == nil {
+			continue
+		}
+		seen[key] = true
+		list = append(list, contactMessage{
+			Directory: msg.Directory,
+			Info:      info,
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Info.Envelope.Date.After(list[j].Info.Envelope.Date)
+	})
+	cv.messages[c] = list
+	cv.Invalidate()
+}
+
+func (cv *ContactsView) Invalidate() {
+	ui.Invalidate()
+}
+
+func (cv *ContactsView) Focus(focus bool) {
+}
+
+func (cv *ContactsView) Event(event tcell.Event) bool {
+	return false
+}
+
+func (cv *ContactsView) Draw(ctx *ui.Context) {
+	defaultStyle := cv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', defaultStyle)
+
+	listWidth := contactListWidth
+	if listWidth > ctx.Width()/2 {
+		listWidth = ctx.Width() / 2
+	}
+	cv.drawList(ctx.Subcontext(0, 0, listWidth, ctx.Height()))
+	ctx.Fill(listWidth, 0, 1, ctx.Height(), cv.uiConfig.BorderCharVertical,
+		cv.uiConfig.GetStyle(config.STYLE_BORDER))
+	cv.drawDetails(ctx.Subcontext(listWidth+1, 0,
+		ctx.Width()-listWidth-1, ctx.Height()))
+}
+
+func (cv *ContactsView) drawList(ctx *ui.Context) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if len(cv.contacts) == 0 {
+		ctx.Printf(0, 0, cv.uiConfig.GetStyle(config.STYLE_DIRLIST_DEFAULT),
+			"(no contacts)")
+		return
+	}
+	if cv.selected < cv.scroll {
+		cv.scroll = cv.selected
+	}
+	if cv.selected >= cv.scroll+ctx.Height() {
+		cv.scroll = cv.selected - ctx.Height() + 1
+	}
+	for y, i := 0, cv.scroll; i < len(cv.contacts) && y < ctx.Height(); i++ {
+		style := cv.uiConfig.GetStyle(config.STYLE_DIRLIST_DEFAULT)
+		if i == cv.selected {
+			style = cv.uiConfig.GetStyleSelected(config.STYLE_DIRLIST_DEFAULT)
+		}
+		ctx.Fill(0, y, ctx.Width(), 1, ' ', style)
+		title := runewidth.Truncate(cv.contacts[i].Title(), ctx.Width()-1, "…")
+		ctx.Printf(1, y, style, "%s", title)
+		y++
+	}
+}
+
+func (cv *ContactsView) drawDetails(ctx *ui.Context) {
+	c := cv.Selected()
+	if c == nil {
+		return
+	}
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	defaultStyle := cv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	titleStyle := cv.uiConfig.GetStyle(config.STYLE_TITLE)
+	headerStyle := cv.uiConfig.GetStyle(config.STYLE_HEADER)
+
+	y := 0
+	ctx.Printf(1, y, titleStyle, "%s", c.Title())
+	y += 2
+	for _, addr := range c.Addresses {
+		x := ctx.Printf(1, y, headerStyle, "%s", addr)
+		if key, ok := cv.keys[addr]; ok {
+			ctx.Printf(x+2, y, defaultStyle, "(%s)", key)
+		}
+		y++
+	}
+	y++
+
+	messages := cv.messages[c]
+	status := fmt.Sprintf("Messages (%d)", len(messages))
+	if cv.loading[c] {
+		status += " searching..."
+	}
+	ctx.Printf(1, y, titleStyle, "%s", status)
+	y++
+	for _, m := range messages {
+		if y >= ctx.Height() {
+			break
+		}
+		env := m.Info.Envelope
+		style := cv.uiConfig.GetStyle(config.STYLE_MSGLIST_READ)
+		if !m.Info.Flags.Has(models.SeenFlag) {
+			style = cv.uiConfig.GetStyle(config.STYLE_MSGLIST_UNREAD)
+		}
+		from := ""
+		if len(env.From) > 0 {
+			from = format.AddressForHumans(env.From[0])
+		}
+		date := format.DummyIfZeroDate(env.Date.Local(),
+			cv.uiConfig.TimestampFormat, cv.uiConfig.ThisDayTimeFormat,
+			cv.uiConfig.ThisWeekTimeFormat, cv.uiConfig.ThisYearTimeFormat)
+		line := fmt.Sprintf("%s  %-12s  %-20s  %s", date,
+			runewidth.Truncate(m.Directory, 12, "…"),
+			runewidth.Truncate(from, 20, "…"),
+			env.Subject)
+		ctx.Printf(1, y, style, "%s",
+			runewidth.Truncate(line, ctx.Width()-1, "…"))
+		y++
+	}
+}
@@ -0,0 +1,157 @@
+package widgets
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/graphics"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+)
+
+// ImageViewer displays an image part in the message viewer with the sixel
+// or kitty graphics protocols, or with half-block characters.
+type ImageViewer struct {
+	protocol graphics.Protocol
+	uiConfig *config.UIConfig
+
+	mu  sync.Mutex
+	img image.Image
+	err error
+	// image encoded for the last drawn size
+	width, height int
+	encoding      bool
+	data          []byte
+	cells         [][]graphics.Cell
+}
+
+func NewImageViewer(protocol graphics.Protocol, uiConfig *config.UIConfig) *ImageViewer {
+	return &ImageViewer{protocol: protocol, uiConfig: uiConfig}
+}
+
+// SetSource decodes the image. It is meant to be used as a FetchBodyPart
+// callback.
+func (iv *ImageViewer) SetSource(r io.Reader) {
+	img, err := graphics.Decode(r)
+	if err != nil {
+		log.Warnf("failed to decode image: %v", err)
+	}
+	iv.mu.Lock()
+	iv.img, iv.err = img, err
+	iv.mu.Unlock()
+	iv.Invalidate()
+}
+
+func (iv *ImageViewer) Draw(ctx *ui.Context) {
+	style := iv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', style)
+
+	iv.mu.Lock()
+	defer iv.mu.Unlock()
+	switch {
+	case iv.err != nil:
+		ctx.Printf(0, 0, iv.uiConfig.GetStyle(config.STYLE_ERROR),
+			"failed to decode image: %v", iv.err)
+		return
+	case iv.img == nil:
+		ctx.Printf(0, 0, style, "Loading...")
+		return
+	}
+	if iv.width != ctx.Width() || iv.height != ctx.Height() {
+		iv.width, iv.height = ctx.Width(), ctx.Height()
+		iv.data, iv.cells = nil, nil
+		if !iv.encoding {
+			iv.encoding = true
+			go iv.encode(iv.img, iv.width, iv.height, background(style))
+		}
+	}
+	switch {
+	case iv.data != nil:
+		clear := ""
+		if iv.protocol == graphics.Kitty {
+			clear = graphics.KittyClear
+		}
+		ctx.Graphic(0, 0, iv.data, clear)
+	case iv.cells != nil:
+		for y, row := range iv.cells {
+			for x, cell := range row {
+				ctx.SetCell(x, y, graphics.UpperHalfBlock, tcell.StyleDefault.
+					Foreground(rgb(cell.Top)).Background(rgb(cell.Bottom)))
+			}
+		}
+	default:
+		ctx.Printf(0, 0, style, "Loading...")
+	}
+}
+
+// encode scales the image to fit in width x height cells and encodes it with
+// the viewer protocol. It is encoded again if the size changed meanwhile.
+func (iv *ImageViewer) encode(img image.Image, width, height int, bg color.Color) {
+	defer log.PanicHandler()
+
+	cellWidth, cellHeight := graphics.CellSize()
+	w, h := graphics.Fit(img.Bounds().Dx(), img.Bounds().Dy(),
+		width*cellWidth, height*cellHeight)
+	cols := (w + cellWidth - 1) / cellWidth
+	rows := (h + cellHeight - 1) / cellHeight
+
+	var data []byte
+	var cells [][]graphics.Cell
+	if w > 0 && h > 0 {
+		var buf bytes.Buffer
+		var err error
+		switch iv.protocol {
+		case graphics.Sixel:
+			err = graphics.EncodeSixel(&buf, graphics.Scale(img, w, h, bg))
+			data = buf.Bytes()
+		case graphics.Kitty:
+			err = graphics.EncodeKitty(&buf, graphics.Scale(img, w, h, bg), cols, rows)
+			data = buf.Bytes()
+		default:
+			cells = graphics.EncodeHalfBlocks(img, cols, rows, bg)
+		}
+		if err != nil {
+			log.Errorf("failed to encode image: %v", err)
+		}
+	}
+
+	iv.mu.Lock()
+	if iv.width != width || iv.height != height {
+		width, height = iv.width, iv.height
+		iv.mu.Unlock()
+		iv.encode(img, width, height, bg)
+		return
+	}
+	iv.data, iv.cells = data, cells
+	if data == nil && cells == nil {
+		// nothing fits, do not try again until resized
+		iv.cells = [][]graphics.Cell{}
+	}
+	iv.encoding = false
+	iv.mu.Unlock()
+	iv.Invalidate()
+}
+
+func (iv *ImageViewer) Invalidate() {
+	ui.Invalidate()
+}
+
+// background returns the background color of a style, or black when it is
+// the terminal default.
+func background(style tcell.Style) color.Color {
+	_, bg, _ := style.Decompose()
+	if r, g, b := bg.RGB(); r >= 0 {
+		return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+	}
+	return color.Black
+}
+
+func rgb(c color.RGBA) tcell.Color {
+	return tcell.NewRGBColor(int32(c.R), int32(c.G), int32(c.B))
+}
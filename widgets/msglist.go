@@ -8,6 +8,7 @@ import (
 
 	sortthread "github.com/emersion/go-imap-sortthread"
 	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 
 	"git.sr.ht/~rjarry/aerc/config"
 	"git.sr.ht/~rjarry/aerc/lib"
@@ -28,6 +29,14 @@ type MessageList struct {
 	store         *lib.MessageStore
 	isInitalizing bool
 	aerc          *Aerc
+	prefixes      []prefixArea
+}
+
+// A prefixArea is the position of the thread prefix of a message on screen,
+// to fold and unfold threads with the mouse.
+type prefixArea struct {
+	y, x0, x1 int
+	uid       uint32
 }
 
 func NewMessageList(aerc *Aerc, account *AccountView) *MessageList {
@@ -50,6 +59,7 @@ type messageRowParams struct {
 	needsHeaders bool
 	uiConfig     *config.UIConfig
 	styles       []config.StyleObject
+	prefix       string
 }
 
 func (ml *MessageList) Draw(ctx *ui.Context) {
@@ -93,6 +103,7 @@ func (ml *MessageList) Draw(ctx *ui.Context) {
 
 	data.SetAccount(acct.acct)
 	data.SetFolder(acct.Directories().Selected())
+	data.SetSenderChecker(acct.SenderChecker())
 
 	customDraw := func(t *ui.Table, r int, c *ui.Context) bool {
 		row := &t.Rows[r]
@@ -144,6 +155,9 @@ func (ml *MessageList) Draw(ctx *ui.Context) {
 						return nil
 					}
 					cur = append(cur, t)
+					if t.Folded {
+						return types.ErrSkipThread
+					}
 					return nil
 				})
 			if err != nil {
@@ -166,6 +180,11 @@ func (ml *MessageList) Draw(ctx *ui.Context) {
 				)
 				lastSubject = baseSubject
 				prevThread = thread
+				if thread.Folded {
+					data.SetFolded(threadCounts(store, thread))
+				} else {
+					data.SetFolded(0, 0)
+				}
 
 				if addMessage(store, thread.Uid, &table, &data, uiConfig) {
 					break threadLoop
@@ -186,6 +205,7 @@ func (ml *MessageList) Draw(ctx *ui.Context) {
 	}
 
 	table.Draw(ctx.Subcontext(0, 0, textWidth, ctx.Height()))
+	ml.updatePrefixes(&table)
 
 	if ml.NeedScrollbar() {
 		scrollbarCtx := ctx.Subcontext(textWidth, 0, 1, ctx.Height())
@@ -251,6 +271,7 @@ func addMessage(
 	}
 
 	data.SetInfo(msg, len(table.Rows), marked)
+	params.prefix = data.ThreadPrefix()
 
 	for c, col := range table.Columns {
 		var buf bytes.Buffer
@@ -270,6 +291,59 @@ func addMessage(
 	return table.AddRow(cells, params)
 }
 
+// threadCounts returns the number of messages and unread messages of a
+// thread.
+func threadCounts(store *lib.MessageStore, thread *types.Thread) (int, int) {
+	count, unread := 0, 0
+	_ = thread.Walk(func(t *types.Thread, _ int, _ error) error {
+		if t.Hidden || t.Deleted {
+			return nil
+		}
+		count++
+		if msg := store.Messages[t.Uid]; msg != nil &&
+			!msg.Flags.Has(models.SeenFlag) {
+			unread++
+		}
+		return nil
+	})
+	return count, unread
+}
+
+// updatePrefixes records where the thread prefixes were drawn.
+func (ml *MessageList) updatePrefixes(table *ui.Table) {
+	ml.prefixes = ml.prefixes[:0]
+	for r, row := range table.Rows {
+		params, _ := row.Priv.(messageRowParams)
+		if params.prefix == "" {
+			continue
+		}
+		for c, col := range table.Columns {
+			if col.Width <= 0 || !col.Def.Flags.Has(config.ALIGN_LEFT) ||
+				!strings.HasPrefix(row.Cells[c], params.prefix) {
+				continue
+			}
+			ml.prefixes = append(ml.prefixes, prefixArea{
+				y:   r,
+				x0:  col.Offset,
+				x1:  col.Offset + runewidth.StringWidth(params.prefix),
+				uid: params.uid,
+			})
+			break
+		}
+	}
+}
+
+// clickedPrefix returns the uid of the message whose thread prefix is at the
+// given position.
+func (ml *MessageList) clickedPrefix(x, y int) (uint32, bool) {
+	for _, area := range ml.prefixes {
+		if area.y == y && x >= area.x0 && x < area.x1 {
+			return area.uid, true
+		}
+	}
+	return 0, false
+}
+
 func (ml *MessageList) drawScrollbar(ctx *ui.Context) {
 	gutterStyle := tcell.StyleDefault
 	pillStyle := tcell.StyleDefault.Reverse(true)
@@ -290,6 +364,13 @@ func (ml *MessageList) MouseEvent(localX int, localY int, event tcell.Event) {
 			if ml.aerc == nil {
 				return
 			}
+			if uid, ok := ml.clickedPrefix(localX, localY); ok && ml.store != nil {
+				err := ml.store.Fold(uid, !ml.store.IsFolded(uid))
+				if err != nil {
+					ml.aerc.PushError(err.Error())
+				}
+				return
+			}
 			selectedMsg, ok := ml.Clicked(localX, localY)
 			if ok {
 				ml.Select(selectedMsg)
@@ -7,6 +7,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
@@ -20,7 +23,9 @@ import (
 	"git.sr.ht/~rjarry/aerc/lib"
 	"git.sr.ht/~rjarry/aerc/lib/auth"
 	"git.sr.ht/~rjarry/aerc/lib/format"
+	"git.sr.ht/~rjarry/aerc/lib/graphics"
 	"git.sr.ht/~rjarry/aerc/lib/parse"
+	"git.sr.ht/~rjarry/aerc/lib/render"
 	"git.sr.ht/~rjarry/aerc/lib/ui"
 	"git.sr.ht/~rjarry/aerc/log"
 	"git.sr.ht/~rjarry/aerc/models"
@@ -107,6 +112,23 @@ func NewMessageViewer(
 		rows = append(rows, ui.GridSpec{Strategy: ui.SIZE_EXACT, Size: ui.Const(height)})
 	}
 
+	var sender *SenderInfo
+	warnings := acct.SenderChecker().CheckMessage(msg.MessageInfo())
+	if len(warnings) > 0 {
+		sender = NewSenderInfo(warnings, acct.UiConfig())
+		rows = append(rows, ui.GridSpec{
+			Strategy: ui.SIZE_EXACT, Size: ui.Const(len(warnings)),
+		})
+	}
+
+	var privacy *PrivacyInfo
+	if config.Viewer.PrivacySummary {
+		if index, ok := findHTMLPart(msg.BodyStructure(), nil); ok {
+			privacy = NewPrivacyInfo(msg, index, acct.UiConfig())
+			rows = append(rows, ui.GridSpec{Strategy: ui.SIZE_EXACT, Size: ui.Const(1)})
+		}
+	}
+
 	rows = append(rows, []ui.GridSpec{
 		{Strategy: ui.SIZE_EXACT, Size: ui.Const(1)},
 		{Strategy: ui.SIZE_WEIGHT, Size: ui.Const(1)},
@@ -132,14 +154,21 @@ func NewMessageViewer(
 	borderChar := acct.UiConfig().BorderCharHorizontal
 
 	grid.AddChild(header).At(0, 0)
+	row := 1
 	if msg.MessageDetails() != nil || acct.UiConfig().IconUnencrypted != "" {
-		grid.AddChild(NewPGPInfo(msg.MessageDetails(), acct.UiConfig())).At(1, 0)
-		grid.AddChild(ui.NewFill(borderChar, borderStyle)).At(2, 0)
-		grid.AddChild(switcher).At(3, 0)
-	} else {
-		grid.AddChild(ui.NewFill(borderChar, borderStyle)).At(1, 0)
-		grid.AddChild(switcher).At(2, 0)
+		grid.AddChild(NewPGPInfo(msg.MessageDetails(), acct.UiConfig())).At(row, 0)
+		row++
+	}
+	if sender != nil {
+		grid.AddChild(sender).At(row, 0)
+		row++
+	}
+	if privacy != nil {
+		grid.AddChild(privacy).At(row, 0)
+		row++
 	}
+	grid.AddChild(ui.NewFill(borderChar, borderStyle)).At(row, 0)
+	grid.AddChild(switcher).At(row+1, 0)
 
 	mv := &MessageViewer{
 		acct:     acct,
@@ -322,18 +351,33 @@ func (mv *MessageViewer) ToggleHeaders() {
 
 func (mv *MessageViewer) ToggleKeyPassthrough() bool {
 	config.Viewer.KeyPassthrough = !config.Viewer.KeyPassthrough
+	if p := mv.builtinPager(); p != nil && !config.Viewer.KeyPassthrough {
+		// keys no longer reach the search prompt
+		p.CancelSearch()
+	}
 	return config.Viewer.KeyPassthrough
 }
 
+// builtinPager returns the built-in pager of the selected part, if any.
+func (mv *MessageViewer) builtinPager() *Pager {
+	if mv.switcher == nil || len(mv.switcher.parts) == 0 {
+		return nil
+	}
+	return mv.switcher.parts[mv.switcher.selected].builtinPager
+}
+
 func (mv *MessageViewer) SelectedMessagePart() *PartInfo {
 	switcher := mv.switcher
 	part := switcher.parts[switcher.selected]
 
 	return &PartInfo{
-		Index: part.index,
-		Msg:   part.msg.MessageInfo(),
-		Part:  part.part,
-		Links: part.links,
+		Index:     part.index,
+		Msg:       part.msg.MessageInfo(),
+		Part:      part.part,
+		Links:     part.links,
+		LinkTexts: part.linkTexts,
+
+		VisibleLinks: part.visibleLinks(),
 	}
 }
 
@@ -439,6 +483,11 @@ func (ps *PartSwitcher) Draw(ctx *ui.Context) {
 
 func (ps *PartSwitcher) MouseEvent(localX int, localY int, event tcell.Event) {
 	if event, ok := event.(*tcell.EventMouse); ok {
+		if p := ps.parts[ps.selected].builtinPager; p != nil &&
+			localY < ps.height-len(ps.parts) {
+			p.MouseEvent(localX, localY, event)
+			return
+		}
 		switch event.Buttons() {
 		case tcell.Button1:
 			height := len(ps.parts)
@@ -521,8 +570,18 @@ type PartViewer struct {
 	grid       *ui.Grid
 	uiConfig   *config.UIConfig
 	copying    int32
-
-	links []string
+	width      int
+
+	// render text/html parts with the built-in renderer when no filter
+	// is configured for them
+	renderHTML bool
+	// display image parts inline when no filter is configured for them
+	image *ImageViewer
+	// used instead of term when the built-in pager is enabled
+	builtinPager *Pager
+
+	links     []string
+	linkTexts map[string][]string
 }
 
 const copying int32 = 1
@@ -532,28 +591,33 @@ func NewPartViewer(
 	curindex []int,
 ) (*PartViewer, error) {
 	var (
-		filter  *exec.Cmd
-		pager   *exec.Cmd
-		pagerin io.WriteCloser
-		term    *Terminal
+		filter     *exec.Cmd
+		pager      *exec.Cmd
+		pagerin    io.WriteCloser
+		term       *Terminal
+		renderHTML bool
+		image      *ImageViewer
+
+		builtinPager *Pager
 	)
-	cmds := []string{
-		config.Viewer.Pager,
-		os.Getenv("PAGER"),
-		"less -R",
-	}
-	pagerCmd, err := acct.aerc.CmdFallbackSearch(cmds)
-	if err != nil {
-		acct.PushError(fmt.Errorf("could not start pager: %w", err))
-		return nil, err
-	}
-	cmd, err := shlex.Split(pagerCmd)
-	if err != nil {
-		return nil, err
+	if !config.Viewer.BuiltinPager {
+		cmds := []string{
+			config.Viewer.Pager,
+			os.Getenv("PAGER"),
+			"less -R",
+		}
+		pagerCmd, err := acct.aerc.CmdFallbackSearch(cmds)
+		if err != nil {
+			acct.PushError(fmt.Errorf("could not start pager: %w", err))
+			return nil, err
+		}
+		cmd, err := shlex.Split(pagerCmd)
+		if err != nil {
+			return nil, err
+		}
+		pager = exec.Command(cmd[0], cmd[1:]...)
 	}
 
-	pager = exec.Command(cmd[0], cmd[1:]...)
-
 	info := msg.MessageInfo()
 	mime := part.FullMIMEType()
 
@@ -608,6 +672,25 @@ func NewPartViewer(
 			acct.UiConfig().StyleSetPath()))
 		log.Debugf("<%s> part=%v %s: %v | %v",
 			info.Envelope.MessageId, curindex, mime, filter, pager)
+	} else if strings.EqualFold(mime, "text/html") {
+		log.Debugf("<%s> part=%v %s: built-in renderer | %v",
+			info.Envelope.MessageId, curindex, mime, pager)
+		renderHTML = true
+	} else if graphics.Supported(mime) && config.Viewer.ImageProtocol != "none" {
+		protocol := graphics.Protocol(config.Viewer.ImageProtocol)
+		if protocol == "auto" {
+			protocol = graphics.Detect()
+		}
+		log.Debugf("<%s> part=%v %s: %s image",
+			info.Envelope.MessageId, curindex, mime, protocol)
+		image = NewImageViewer(protocol, acct.UiConfig())
+	}
+	switch {
+	case (filter != nil || renderHTML) && config.Viewer.BuiltinPager:
+		builtinPager = NewPager(acct)
+		pagerin = builtinPager.Writer()
+	case filter != nil || renderHTML:
+		var err error
 		if pagerin, err = pager.StdinPipe(); err != nil {
 			return nil, err
 		}
@@ -637,6 +720,10 @@ func NewPartViewer(
 		term:       term,
 		grid:       grid,
 		uiConfig:   acct.UiConfig(),
+		renderHTML: renderHTML,
+		image:      image,
+
+		builtinPager: builtinPager,
 	}
 
 	if term != nil {
@@ -655,12 +742,16 @@ func (pv *PartViewer) SetSource(reader io.Reader) {
 
 func (pv *PartViewer) attemptCopy() {
 	if pv.source == nil ||
-		pv.filter == nil ||
+		(pv.filter == nil && !pv.renderHTML) ||
 		atomic.LoadInt32(&pv.copying) == copying {
 		return
 	}
 	atomic.StoreInt32(&pv.copying, copying)
 	pv.writeMailHeaders()
+	if pv.renderHTML {
+		pv.copyHTML()
+		return
+	}
 	if strings.EqualFold(pv.part.MIMEType, "text") {
 		pv.source = parse.StripAnsi(pv.hyperlinks(pv.source))
 	}
@@ -688,6 +779,41 @@ func (pv *PartViewer) attemptCopy() {
 	}()
 }
 
+// copyHTML renders the HTML part in the background, like the filters, since
+// large messages may take a while.
+func (pv *PartViewer) copyHTML() {
+	source, width := pv.source, pv.width
+	go func() {
+		defer log.PanicHandler()
+		defer atomic.StoreInt32(&pv.copying, 0)
+		text, links, err := render.HTML(source, render.HTMLOptions{
+			Width:         width,
+			Style:         pv.uiConfig.GetViewerStyle,
+			StripTracking: config.Viewer.StripTracking,
+		})
+		if err != nil {
+			log.Errorf("failed to render html: %v", err)
+			text = fmt.Sprintf("failed to render html: %v\n", err)
+		}
+		if config.Viewer.ParseHttpLinks {
+			ui.QueueFunc(func() {
+				pv.links = make([]string, 0, len(links))
+				pv.linkTexts = make(map[string][]string)
+				for _, link := range links {
+					pv.links = append(pv.links, link.URL)
+					pv.linkTexts[link.URL] = link.Texts
+				}
+			})
+		}
+		if _, err := io.WriteString(pv.pagerin, text); err != nil {
+			log.Errorf("error writing to pager: %v", err)
+		}
+		if err := pv.pagerin.Close(); err != nil {
+			log.Errorf("error closing pager pipe: %v", err)
+		}
+	}()
+}
+
 func (pv *PartViewer) writeMailHeaders() {
 	info := pv.msg.MessageInfo()
 	if config.Viewer.ShowHeaders && info.RFC822Headers != nil {
@@ -755,11 +881,54 @@ func (pv *PartViewer) writeMailHeaders() {
 	}
 }
 
+var linkRefRe = regexp.MustCompile(`\[([0-9]+)\]`)
+
+// visibleLinks returns the links displayed by the built-in pager, in order of
+// appearance. Links of HTML parts are also found by their [n] reference.
+func (pv *PartViewer) visibleLinks() []string {
+	if pv.builtinPager == nil || len(pv.links) == 0 {
+		return nil
+	}
+	text := pv.builtinPager.VisibleText()
+	positions := make(map[string]int)
+	found := func(link string, pos int) {
+		if p, ok := positions[link]; !ok || pos < p {
+			positions[link] = pos
+		}
+	}
+	for _, link := range pv.links {
+		if i := strings.Index(text, link); i >= 0 {
+			found(link, i)
+		}
+	}
+	if pv.renderHTML {
+		for _, m := range linkRefRe.FindAllStringSubmatchIndex(text, -1) {
+			n, _ := strconv.Atoi(text[m[2]:m[3]])
+			if n >= 1 && n <= len(pv.links) {
+				found(pv.links[n-1], m[0])
+			}
+		}
+	}
+	visible := make([]string, 0, len(positions))
+	for link := range positions {
+		visible = append(visible, link)
+	}
+	sort.Slice(visible, func(i, j int) bool {
+		return positions[visible[i]] < positions[visible[j]]
+	})
+	return visible
+}
+
 func (pv *PartViewer) hyperlinks(r io.Reader) (reader io.Reader) {
 	if !config.Viewer.ParseHttpLinks {
 		return r
 	}
 	reader, pv.links = parse.HttpLinks(r)
+	if config.Viewer.StripTracking {
+		for i, link := range pv.links {
+			pv.links[i] = parse.StripTrackingParams(link)
+		}
+	}
 	return reader
 }
 
@@ -826,12 +995,21 @@ func (pv *PartViewer) Invalidate() {
 
 func (pv *PartViewer) Draw(ctx *ui.Context) {
 	style := pv.uiConfig.GetStyle(config.STYLE_DEFAULT)
-	if pv.filter == nil {
+	if pv.image != nil {
+		if !pv.fetched {
+			pv.fetched = true
+			pv.msg.FetchBodyPart(pv.index, pv.image.SetSource)
+		}
+		pv.image.Draw(ctx)
+		return
+	}
+	if pv.filter == nil && !pv.renderHTML {
 		ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', style)
 		newNoFilterConfigured(pv).Draw(ctx)
 		return
 	}
 	if !pv.fetched {
+		pv.width = ctx.Width()
 		pv.msg.FetchBodyPart(pv.index, pv.SetSource)
 		pv.fetched = true
 	}
@@ -843,6 +1021,9 @@ func (pv *PartViewer) Draw(ctx *ui.Context) {
 	if pv.term != nil {
 		pv.term.Draw(ctx)
 	}
+	if pv.builtinPager != nil {
+		pv.builtinPager.Draw(ctx)
+	}
 }
 
 func (pv *PartViewer) Cleanup() {
@@ -855,6 +1036,9 @@ func (pv *PartViewer) Event(event tcell.Event) bool {
 	if pv.term != nil {
 		return pv.term.Event(event)
 	}
+	if pv.builtinPager != nil {
+		return pv.builtinPager.Event(event)
+	}
 	return false
 }
 
@@ -0,0 +1,454 @@
+package widgets
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/pager"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+)
+
+// Pager is the built-in pager of the message viewer. It displays the output
+// of filters with the styleset colors and supports searching, mouse
+// scrolling and yanking lines to the clipboard.
+type Pager struct {
+	acct     *AccountView
+	buf      *pager.Buffer
+	uiConfig *config.UIConfig
+
+	lines  []pager.Line
+	rows   []pager.Row
+	width  int
+	height int
+	scroll int
+
+	prompt  *ui.TextInput
+	pattern *regexp.Regexp
+	matches []pager.Match
+	current int
+
+	// selected lines, from anchor to cursor, both included
+	selecting bool
+	dragging  bool
+	anchor    int
+	cursor    int
+}
+
+func NewPager(acct *AccountView) *Pager {
+	return &Pager{
+		acct:     acct,
+		buf:      pager.NewBuffer(ui.Invalidate),
+		uiConfig: acct.UiConfig(),
+		current:  -1,
+	}
+}
+
+// Writer returns the input of the pager.
+func (p *Pager) Writer() *pager.Buffer {
+	return p.buf
+}
+
+func (p *Pager) Invalidate() {
+	ui.Invalidate()
+}
+
+// update reads the new lines and wraps them for the current width.
+func (p *Pager) update(width int) {
+	lines := p.buf.Lines()
+	if len(lines) == len(p.lines) && width == p.width {
+		return
+	}
+	first := 0
+	if p.scroll < len(p.rows) {
+		first = p.rows[p.scroll].Line
+	}
+	p.lines = lines
+	p.width = width
+	p.rows = pager.Wrap(p.lines, width)
+	if p.pattern != nil {
+		p.matches = pager.Search(p.lines, p.pattern)
+	}
+	p.scroll = p.rowOf(first, 0)
+}
+
+// rowOf returns the row displaying a rune of a line.
+func (p *Pager) rowOf(line, offset int) int {
+	for i, row := range p.rows {
+		if row.Line == line && (offset < row.End ||
+			i+1 == len(p.rows) || p.rows[i+1].Line != line) {
+			return i
+		}
+	}
+	return 0
+}
+
+func (p *Pager) bodyHeight() int {
+	if p.prompt != nil || p.pattern != nil || p.selecting {
+		return p.height - 1
+	}
+	return p.height
+}
+
+func (p *Pager) scrollTo(row int) {
+	if max := len(p.rows) - p.bodyHeight(); row > max {
+		row = max
+	}
+	if row < 0 {
+		row = 0
+	}
+	p.scroll = row
+	p.Invalidate()
+}
+
+// ensureVisible scrolls to show a row, with some context above it.
+func (p *Pager) ensureVisible(row int) {
+	if row < p.scroll || row >= p.scroll+p.bodyHeight() {
+		p.scrollTo(row - p.bodyHeight()/3)
+	}
+}
+
+func (p *Pager) Draw(ctx *ui.Context) {
+	p.height = ctx.Height()
+	p.update(ctx.Width())
+
+	defaultStyle := p.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	matchStyle := p.uiConfig.GetViewerStyle("search")
+	currentStyle := p.uiConfig.GetViewerStyle("search_current")
+	selectionStyle := p.uiConfig.GetViewerStyle("selection")
+	ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', defaultStyle)
+
+	// matches are sorted by line, skip those above the screen
+	m := 0
+	height := p.bodyHeight()
+	for y := 0; y < height && p.scroll+y < len(p.rows); y++ {
+		row := p.rows[p.scroll+y]
+		line := p.lines[row.Line]
+		for m < len(p.matches) && p.matches[m].Line < row.Line {
+			m++
+		}
+		selected := p.selecting && p.isSelected(row.Line)
+		if selected {
+			ctx.Fill(0, y, ctx.Width(), 1, ' ', selectionStyle)
+		}
+		x := 0
+		for i := row.Start; i < row.End; i++ {
+			r := line.Runes[i]
+			style := r.Style
+			if style == tcell.StyleDefault {
+				style = defaultStyle
+			}
+			if selected {
+				style = selectionStyle
+			}
+			for j := m; j < len(p.matches) && p.matches[j].Line == row.Line; j++ {
+				if i >= p.matches[j].Start && i < p.matches[j].End {
+					style = matchStyle
+					if j == p.current {
+						style = currentStyle
+					}
+					break
+				}
+			}
+			ctx.SetCell(x, y, r.Value, style)
+			x += r.Width
+		}
+	}
+
+	if height < p.height {
+		p.drawStatus(ctx.Subcontext(0, height, ctx.Width(), 1))
+	}
+}
+
+func (p *Pager) drawStatus(ctx *ui.Context) {
+	style := p.uiConfig.GetStyle(config.STYLE_STATUSLINE_DEFAULT)
+	ctx.Fill(0, 0, ctx.Width(), 1, ' ', style)
+	switch {
+	case p.prompt != nil:
+		p.prompt.Draw(ctx)
+	case p.selecting:
+		from, to := p.selection()
+		ctx.Printf(0, 0, style, "-- SELECT -- lines %d-%d, y to yank",
+			from+1, to+1)
+	case len(p.matches) == 0:
+		ctx.Printf(0, 0, p.uiConfig.GetStyle(config.STYLE_STATUSLINE_ERROR),
+			"/%s: pattern not found",
+			strings.TrimPrefix(p.pattern.String(), "(?i)"))
+	default:
+		ctx.Printf(0, 0, style, "/%s: match %d of %d",
+			strings.TrimPrefix(p.pattern.String(), "(?i)"),
+			p.current+1, len(p.matches))
+	}
+}
+
+func (p *Pager) selection() (int, int) {
+	if p.anchor <= p.cursor {
+		return p.anchor, p.cursor
+	}
+	return p.cursor, p.anchor
+}
+
+func (p *Pager) isSelected(line int) bool {
+	from, to := p.selection()
+	return line >= from && line <= to
+}
+
+func (p *Pager) Event(event tcell.Event) bool {
+	if p.prompt != nil {
+		return p.promptEvent(event)
+	}
+	key, ok := event.(*tcell.EventKey)
+	if !ok {
+		return false
+	}
+	page := p.bodyHeight()
+	switch key.Key() {
+	case tcell.KeyDown, tcell.KeyEnter, tcell.KeyCtrlN, tcell.KeyCtrlE:
+		p.down(1)
+	case tcell.KeyUp, tcell.KeyCtrlP, tcell.KeyCtrlY:
+		p.down(-1)
+	case tcell.KeyPgDn, tcell.KeyCtrlF:
+		p.down(page)
+	case tcell.KeyPgUp, tcell.KeyCtrlB:
+		p.down(-page)
+	case tcell.KeyCtrlD:
+		p.down(page / 2)
+	case tcell.KeyCtrlU:
+		p.down(-page / 2)
+	case tcell.KeyHome:
+		p.scrollTo(0)
+	case tcell.KeyEnd:
+		p.scrollTo(len(p.rows))
+	case tcell.KeyRune:
+		switch key.Rune() {
+		case 'j':
+			p.down(1)
+		case 'k':
+			p.down(-1)
+		case ' ', 'f':
+			p.down(page)
+		case 'b':
+			p.down(-page)
+		case 'd':
+			p.down(page / 2)
+		case 'u':
+			p.down(-page / 2)
+		case 'g', '<':
+			p.scrollTo(0)
+		case 'G', '>':
+			p.scrollTo(len(p.rows))
+		case '/':
+			p.StartSearch()
+		case 'n':
+			p.NextMatch(1)
+		case 'N':
+			p.NextMatch(-1)
+		case 'v', 'V':
+			p.ToggleSelection()
+		case 'y':
+			p.Yank()
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// down scrolls down n rows, or moves the selection cursor by n lines when
+// selecting.
+func (p *Pager) down(n int) {
+	if !p.selecting {
+		p.scrollTo(p.scroll + n)
+		return
+	}
+	p.cursor += n
+	if p.cursor >= len(p.lines) {
+		p.cursor = len(p.lines) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	row := p.rowOf(p.cursor, 0)
+	if row < p.scroll {
+		p.scrollTo(row)
+	} else if row >= p.scroll+p.bodyHeight() {
+		p.scrollTo(row - p.bodyHeight() + 1)
+	}
+	p.Invalidate()
+}
+
+func (p *Pager) promptEvent(event tcell.Event) bool {
+	if key, ok := event.(*tcell.EventKey); ok {
+		switch key.Key() {
+		case tcell.KeyEnter:
+			pattern := p.prompt.String()
+			p.CancelSearch()
+			if pattern != "" {
+				p.Search(pattern)
+			}
+			return true
+		case tcell.KeyEsc, tcell.KeyCtrlC:
+			p.CancelSearch()
+			return true
+		}
+	}
+	return p.prompt.Event(event)
+}
+
+// StartSearch opens the search prompt.
+func (p *Pager) StartSearch() {
+	p.prompt = ui.NewTextInput("", p.uiConfig).Prompt("/")
+	p.prompt.Focus(true)
+	p.Invalidate()
+}
+
+// CancelSearch closes the search prompt, if opened.
+func (p *Pager) CancelSearch() {
+	if p.prompt != nil {
+		p.prompt.Focus(false)
+		p.prompt = nil
+		p.Invalidate()
+	}
+}
+
+// Search highlights all matches of a pattern and shows the first one below
+// the top of the screen.
+func (p *Pager) Search(pattern string) {
+	re, err := pager.Compile(pattern)
+	if err != nil {
+		p.acct.PushError(fmt.Errorf("invalid pattern: %w", err))
+		return
+	}
+	p.pattern = re
+	p.matches = pager.Search(p.lines, re)
+	p.current = -1
+	top := 0
+	if p.scroll < len(p.rows) {
+		top = p.rows[p.scroll].Line
+	}
+	for i, m := range p.matches {
+		if m.Line >= top {
+			p.current = i
+			break
+		}
+	}
+	if p.current < 0 && len(p.matches) > 0 {
+		p.current = 0
+	}
+	p.showCurrent()
+}
+
+// NextMatch moves to the next match, or the previous one if dir is negative,
+// wrapping around.
+func (p *Pager) NextMatch(dir int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.current = (p.current + dir + len(p.matches)) % len(p.matches)
+	p.showCurrent()
+}
+
+func (p *Pager) showCurrent() {
+	if p.current >= 0 && p.current < len(p.matches) {
+		m := p.matches[p.current]
+		p.ensureVisible(p.rowOf(m.Line, m.Start))
+	}
+	p.Invalidate()
+}
+
+// ToggleSelection starts selecting lines from the current match or the top of
+// the screen, or stops selecting.
+func (p *Pager) ToggleSelection() {
+	p.selecting = !p.selecting
+	if p.selecting {
+		p.anchor = 0
+		if p.current >= 0 && p.current < len(p.matches) {
+			p.anchor = p.matches[p.current].Line
+		} else if p.scroll < len(p.rows) {
+			p.anchor = p.rows[p.scroll].Line
+		}
+		p.cursor = p.anchor
+	}
+	p.Invalidate()
+}
+
+// Yank copies the selected lines, or the line of the current match, to the
+// clipboard.
+func (p *Pager) Yank() {
+	var from, to int
+	switch {
+	case p.selecting:
+		from, to = p.selection()
+	case p.current >= 0 && p.current < len(p.matches):
+		from = p.matches[p.current].Line
+		to = from
+	default:
+		p.acct.PushWarning("Nothing to yank: select lines with v first")
+		return
+	}
+	text, n := pager.Text(p.lines, from, to)
+	if n == 0 {
+		p.selecting = false
+		p.acct.PushWarning("Nothing to yank")
+		p.Invalidate()
+		return
+	}
+	if err := ui.SetClipboard(text); err != nil {
+		p.acct.PushError(err)
+		return
+	}
+	p.selecting = false
+	p.acct.PushStatus(fmt.Sprintf("Yanked %d lines", n), 10*time.Second)
+	p.Invalidate()
+}
+
+// VisibleText returns the text displayed on screen. Wrapped lines are joined
+// back.
+func (p *Pager) VisibleText() string {
+	var text strings.Builder
+	for y := 0; y < p.bodyHeight() && p.scroll+y < len(p.rows); y++ {
+		row := p.rows[p.scroll+y]
+		if y > 0 && row.Start == 0 {
+			text.WriteRune('\n')
+		}
+		runes := []rune(p.lines[row.Line].Text)
+		if row.End <= len(runes) {
+			text.WriteString(string(runes[row.Start:row.End]))
+		}
+	}
+	return text.String()
+}
+
+func (p *Pager) MouseEvent(localX int, localY int, event tcell.Event) {
+	mouse, ok := event.(*tcell.EventMouse)
+	if !ok {
+		return
+	}
+	switch mouse.Buttons() {
+	case tcell.WheelDown:
+		p.scrollTo(p.scroll + 3)
+	case tcell.WheelUp:
+		p.scrollTo(p.scroll - 3)
+	case tcell.Button1:
+		row := p.scroll + localY
+		if localY >= p.bodyHeight() || row >= len(p.rows) {
+			return
+		}
+		line := p.rows[row].Line
+		if !p.dragging {
+			p.dragging = true
+			p.anchor = line
+		}
+		p.cursor = line
+		p.selecting = p.anchor != p.cursor
+		p.Invalidate()
+	case tcell.ButtonNone:
+		p.dragging = false
+	}
+}
@@ -0,0 +1,99 @@
+package widgets
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib"
+	"git.sr.ht/~rjarry/aerc/lib/parse"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/models"
+)
+
+// PrivacyInfo displays a summary of the remote content and trackers found in
+// the HTML part of a message.
+type PrivacyInfo struct {
+	mu       sync.Mutex
+	msg      lib.MessageView
+	index    []int
+	uiConfig *config.UIConfig
+	fetched  bool
+	report   *parse.PrivacyReport
+	err      error
+}
+
+func NewPrivacyInfo(
+	msg lib.MessageView, index []int, uiConfig *config.UIConfig,
+) *PrivacyInfo {
+	return &PrivacyInfo{msg: msg, index: index, uiConfig: uiConfig}
+}
+
+func (p *PrivacyInfo) Draw(ctx *ui.Context) {
+	p.mu.Lock()
+	fetch := !p.fetched
+	p.fetched = true
+	report, err := p.report, p.err
+	p.mu.Unlock()
+	if fetch {
+		// the callback may be invoked synchronously
+		p.msg.FetchBodyPart(p.index, p.analyze)
+		p.mu.Lock()
+		report, err = p.report, p.err
+		p.mu.Unlock()
+	}
+
+	defaultStyle := p.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', defaultStyle)
+	x := ctx.Printf(0, 0, p.uiConfig.GetStyle(config.STYLE_HEADER), "Privacy:")
+	switch {
+	case err != nil:
+		ctx.Printf(x+1, 0, p.uiConfig.GetStyle(config.STYLE_ERROR),
+			"%v", err)
+	case report == nil:
+		ctx.Printf(x+1, 0, defaultStyle, "Analyzing...")
+	case report.Clean():
+		ctx.Printf(x+1, 0, p.uiConfig.GetStyle(config.STYLE_SUCCESS),
+			"%s", report.String())
+	default:
+		ctx.Printf(x+1, 0, p.uiConfig.GetStyle(config.STYLE_WARNING),
+			"%s", report.String())
+	}
+}
+
+func (p *PrivacyInfo) analyze(r io.Reader) {
+	report, err := parse.AnalyzeHTML(r)
+	if err != nil {
+		log.Warnf("failed to analyze html: %v", err)
+	}
+	p.mu.Lock()
+	p.report, p.err = report, err
+	p.mu.Unlock()
+	p.Invalidate()
+}
+
+func (p *PrivacyInfo) Invalidate() {
+	ui.Invalidate()
+}
+
+// findHTMLPart returns the index of the first text/html part of a message.
+func findHTMLPart(bs *models.BodyStructure, index []int) ([]int, bool) {
+	if bs == nil {
+		return nil, false
+	}
+	if len(bs.Parts) == 0 {
+		if strings.EqualFold(bs.FullMIMEType(), "text/html") {
+			return index, true
+		}
+		return nil, false
+	}
+	for i, part := range bs.Parts {
+		curindex := append(index, i+1) //nolint:gocritic // intentional append to different slice
+		if found, ok := findHTMLPart(part, curindex); ok {
+			return found, true
+		}
+	}
+	return nil, false
+}
@@ -11,6 +11,11 @@ type PartInfo struct {
 	Msg   *models.MessageInfo
 	Part  *models.BodyStructure
 	Links []string
+	// LinkTexts are the visible texts of links, when known
+	LinkTexts map[string][]string
+	// VisibleLinks are the links displayed on screen by the built-in
+	// pager, in order of appearance
+	VisibleLinks []string
 }
 
 type ProvidesMessage interface {
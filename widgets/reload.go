@@ -0,0 +1,142 @@
+package widgets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+)
+
+// configPollInterval is how often the configuration files are checked for
+// modifications when watch-config is enabled.
+const configPollInterval = 2 * time.Second
+
+// Reload parses the configuration files again. The widgets use the new
+// settings and the workers of the accounts that were added, removed or
+// changed are started or stopped. The composers and message viewers keep
+// their settings until they are closed.
+func (aerc *Aerc) Reload() error {
+	if err := config.Reload(); err != nil {
+		return err
+	}
+
+	var errs []string
+	names := make(map[string]bool)
+	for i, conf := range config.Accounts {
+		names[conf.Name] = true
+		acct, ok := aerc.accounts[conf.Name]
+		if ok && acct.acct.Equal(conf) {
+			// the running worker keeps its configuration
+			config.Accounts[i] = acct.acct
+			acct.reloadUi()
+			continue
+		}
+		view, err := NewAccountView(aerc, conf, aerc, nil)
+		if ok {
+			log.Infof("[%s] account changed, restarting", conf.Name)
+			acct.close()
+			delete(aerc.accounts, conf.Name)
+			if err != nil {
+				acct.tab.Content = errorScreen(err.Error())
+			} else {
+				acct.tab.Content = view
+				acct.tab.SetUiConfig(view.UiConfig())
+				view.tab = acct.tab
+			}
+		} else {
+			log.Infof("[%s] account added, starting", conf.Name)
+			if err != nil {
+				aerc.tabs.Add(errorScreen(err.Error()), conf.Name, nil)
+			} else {
+				view.tab = aerc.tabs.Add(view, conf.Name, view.UiConfig())
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", conf.Name, err))
+		} else {
+			aerc.accounts[conf.Name] = view
+		}
+	}
+	for name, acct := range aerc.accounts {
+		if names[name] {
+			continue
+		}
+		log.Infof("[%s] account removed, stopping", name)
+		acct.close()
+		delete(aerc.accounts, name)
+		aerc.RemoveTab(acct)
+	}
+	if tab := aerc.tabs.Selected(); tab != nil {
+		if interactive, ok := tab.Content.(ui.Interactive); ok {
+			interactive.Focus(true)
+		}
+	}
+
+	aerc.showConfigWarnings()
+	aerc.WatchConfig()
+	aerc.UpdateStatus()
+	ui.Invalidate()
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to start accounts: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// WatchConfig periodically checks if the configuration files were modified
+// and reloads them, until watch-config is disabled.
+func (aerc *Aerc) WatchConfig() {
+	if aerc.watching || !config.General.WatchConfig {
+		return
+	}
+	aerc.watching = true
+	aerc.watchConfig(configModTimes())
+}
+
+func (aerc *Aerc) watchConfig(mtimes map[string]time.Time) {
+	time.AfterFunc(configPollInterval, func() {
+		ui.QueueFunc(func() {
+			if !config.General.WatchConfig {
+				aerc.watching = false
+				return
+			}
+			current := configModTimes()
+			if modified(mtimes, current) {
+				log.Infof("configuration modified, reloading")
+				if err := aerc.Reload(); err != nil {
+					aerc.PushError(err.Error())
+				} else {
+					aerc.PushSuccess("Configuration reloaded")
+				}
+				// the stylesets in use may have changed
+				current = configModTimes()
+			}
+			aerc.watchConfig(current)
+		})
+	})
+}
+
+func configModTimes() map[string]time.Time {
+	mtimes := make(map[string]time.Time)
+	for _, file := range config.Files() {
+		if info, err := os.Stat(file); err == nil {
+			mtimes[file] = info.ModTime()
+		}
+	}
+	return mtimes
+}
+
+func modified(before, after map[string]time.Time) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for file, mtime := range after {
+		if !mtime.Equal(before[file]) {
+			return true
+		}
+	}
+	return false
+}
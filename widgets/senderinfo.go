@@ -0,0 +1,34 @@
+package widgets
+
+import (
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+)
+
+// SenderInfo displays the reasons why the sender of a message may be
+// spoofed, one per line.
+type SenderInfo struct {
+	warnings []string
+	uiConfig *config.UIConfig
+}
+
+func NewSenderInfo(warnings []string, uiConfig *config.UIConfig) *SenderInfo {
+	return &SenderInfo{warnings: warnings, uiConfig: uiConfig}
+}
+
+func (s *SenderInfo) Draw(ctx *ui.Context) {
+	defaultStyle := s.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	errorStyle := s.uiConfig.GetStyle(config.STYLE_ERROR)
+	ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', defaultStyle)
+	x := ctx.Printf(0, 0, s.uiConfig.GetStyle(config.STYLE_HEADER), "Sender:")
+	for y, warning := range s.warnings {
+		if icon := s.uiConfig.IconSenderWarning; icon != "" {
+			warning = icon + " " + warning
+		}
+		ctx.Printf(x+1, y, errorStyle, "%s", warning)
+	}
+}
+
+func (s *SenderInfo) Invalidate() {
+	ui.Invalidate()
+}
@@ -0,0 +1,383 @@
+package widgets
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/format"
+	"git.sr.ht/~rjarry/aerc/lib/sieve"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+)
+
+const sieveListWidth = 30
+
+// RunSieve connects to the ManageSieve server of the account and runs fn in
+// the background. Errors are pushed to the status line. On success, done is
+// called from the main loop if not nil.
+func RunSieve(acct *AccountView, fn func(*sieve.Client) error, done func()) {
+	go func() {
+		defer log.PanicHandler()
+
+		err := runSieve(acct, fn)
+		if err != nil {
+			acct.aerc.PushError(fmt.Sprintf("sieve: %v", err))
+			return
+		}
+		if done != nil {
+			ui.QueueFunc(done)
+		}
+	}()
+}
+
+func runSieve(acct *AccountView, fn func(*sieve.Client) error) error {
+	u, err := acct.AccountConfig().SieveURL()
+	if err != nil {
+		return err
+	}
+	c, err := sieve.Dial(u)
+	if err != nil {
+		return err
+	}
+	if err := fn(c); err != nil {
+		c.Close()
+		return err
+	}
+	return c.Logout()
+}
+
+// EditSieveScript downloads a script, or starts an empty one if it does not
+// exist, and opens it in the editor. When the editor exits, the script is
+// validated and uploaded. done is called after a successful upload.
+func EditSieveScript(aerc *Aerc, acct *AccountView, name string, done func()) {
+	var content string
+	RunSieve(acct, func(c *sieve.Client) error {
+		scripts, err := c.ListScripts()
+		if err != nil {
+			return err
+		}
+		for _, s := range scripts {
+			if s.Name == name {
+				content, err = c.GetScript(name)
+				return err
+			}
+		}
+		return nil
+	}, func() {
+		f, err := os.CreateTemp("", "aerc-sieve-*.sieve")
+		if err != nil {
+			aerc.PushError(err.Error())
+			return
+		}
+		defer f.Close()
+		if _, err := f.WriteString(content); err != nil {
+			aerc.PushError(err.Error())
+			os.Remove(f.Name())
+			return
+		}
+		editSieveFile(aerc, acct, name, f.Name(), done)
+	})
+}
+
+func editSieveFile(aerc *Aerc, acct *AccountView, name, path string, done func()) {
+	editorName, err := aerc.CmdFallbackSearch([]string{
+		config.Compose.Editor,
+		os.Getenv("EDITOR"),
+		"vi",
+		"nano",
+	})
+	if err != nil {
+		aerc.PushError(fmt.Sprintf("could not start editor: %v", err))
+		os.Remove(path)
+		return
+	}
+	cmd := exec.Command("/bin/sh", "-c",
+		editorName+" "+format.ShellQuote([]string{path}))
+	term, err := NewTerminal(cmd)
+	if err != nil {
+		aerc.PushError(err.Error())
+		os.Remove(path)
+		return
+	}
+	term.OnClose = func(err error) {
+		aerc.RemoveTab(term)
+		if err != nil {
+			aerc.PushError(err.Error())
+			os.Remove(path)
+			return
+		}
+		uploadSieveFile(aerc, acct, name, path, done)
+	}
+	aerc.NewTab(term, "sieve: "+name)
+}
+
+// uploadSieveFile validates and uploads an edited script. If the server
+// rejects it, the user can edit it again or discard the changes.
+func uploadSieveFile(aerc *Aerc, acct *AccountView, name, path string, done func()) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		aerc.PushError(err.Error())
+		return
+	}
+	content := string(data)
+	var warnings string
+	go func() {
+		defer log.PanicHandler()
+
+		err := runSieve(acct, func(c *sieve.Client) error {
+			var err error
+			warnings, err = c.CheckScript(content)
+			if err != nil {
+				return err
+			}
+			return c.PutScript(name, content)
+		})
+		ui.QueueFunc(func() {
+			if err == nil {
+				os.Remove(path)
+				if warnings != "" {
+					aerc.PushWarning(fmt.Sprintf(
+						"sieve: %s uploaded: %s", name, warnings))
+				} else {
+					aerc.PushSuccess(fmt.Sprintf(
+						"sieve: %s uploaded", name))
+				}
+				if done != nil {
+					done()
+				}
+				return
+			}
+			dialog := NewSelectorDialog(
+				"Invalid Sieve script",
+				fmt.Sprintf("%s: %v", name, err),
+				[]string{"Edit", "Discard"}, 0, acct.UiConfig(),
+				func(option string, _ error) {
+					aerc.CloseDialog()
+					if option == "Edit" {
+						editSieveFile(aerc, acct, name, path, done)
+					} else {
+						os.Remove(path)
+					}
+				},
+			)
+			aerc.AddDialog(dialog)
+		})
+	}()
+}
+
+// SieveView lists the Sieve scripts stored on the ManageSieve server of an
+// account and shows the selected one.
+type SieveView struct {
+	aerc     *Aerc
+	acct     *AccountView
+	uiConfig *config.UIConfig
+
+	mu       sync.Mutex
+	scripts  []sieve.Script
+	contents map[string]string
+	selected int
+	scroll   int
+	status   string
+}
+
+func NewSieveView(aerc *Aerc, acct *AccountView) *SieveView {
+	sv := &SieveView{
+		aerc:     aerc,
+		acct:     acct,
+		uiConfig: acct.UiConfig(),
+		contents: make(map[string]string),
+	}
+	sv.Refresh()
+	return sv
+}
+
+// Refresh downloads the list of scripts and their contents.
+func (sv *SieveView) Refresh() {
+	sv.mu.Lock()
+	sv.status = "loading..."
+	sv.mu.Unlock()
+	sv.Invalidate()
+	go func() {
+		defer log.PanicHandler()
+
+		var scripts []sieve.Script
+		contents := make(map[string]string)
+		err := runSieve(sv.acct, func(c *sieve.Client) error {
+			var err error
+			scripts, err = c.ListScripts()
+			if err != nil {
+				return err
+			}
+			for _, s := range scripts {
+				contents[s.Name], err = c.GetScript(s.Name)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		sv.mu.Lock()
+		if err != nil {
+			sv.status = err.Error()
+		} else {
+			current := ""
+			if sv.selected < len(sv.scripts) {
+				current = sv.scripts[sv.selected].Name
+			}
+			sv.scripts = scripts
+			sv.contents = contents
+			sv.selected = 0
+			for i, s := range scripts {
+				if s.Name == current {
+					sv.selected = i
+				}
+			}
+			sv.status = ""
+		}
+		sv.mu.Unlock()
+		sv.Invalidate()
+	}()
+}
+
+// Run runs fn on the server of the account and refreshes the list.
+func (sv *SieveView) Run(fn func(*sieve.Client) error, status string) {
+	RunSieve(sv.acct, fn, func() {
+		sv.aerc.PushStatus(status, 10*time.Second)
+		sv.Refresh()
+	})
+}
+
+func (sv *SieveView) SelectedAccount() *AccountView {
+	return sv.acct
+}
+
+// Selected returns the name of the selected script, or an empty string.
+func (sv *SieveView) Selected() string {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	if sv.selected < 0 || sv.selected >= len(sv.scripts) {
+		return ""
+	}
+	return sv.scripts[sv.selected].Name
+}
+
+// Scripts returns the names of the scripts on the server.
+func (sv *SieveView) Scripts() []string {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	names := make([]string, 0, len(sv.scripts))
+	for _, s := range sv.scripts {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// Next moves the selection by n scripts, n may be negative.
+func (sv *SieveView) Next(n int) {
+	sv.mu.Lock()
+	sv.selected += n
+	if sv.selected >= len(sv.scripts) {
+		sv.selected = len(sv.scripts) - 1
+	}
+	if sv.selected < 0 {
+		sv.selected = 0
+	}
+	sv.mu.Unlock()
+	sv.Invalidate()
+}
+
+func (sv *SieveView) Invalidate() {
+	ui.Invalidate()
+}
+
+func (sv *SieveView) Focus(focus bool) {
+}
+
+func (sv *SieveView) Event(event tcell.Event) bool {
+	return false
+}
+
+func (sv *SieveView) Draw(ctx *ui.Context) {
+	defaultStyle := sv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', defaultStyle)
+
+	listWidth := sieveListWidth
+	if listWidth > ctx.Width()/2 {
+		listWidth = ctx.Width() / 2
+	}
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	sv.drawList(ctx.Subcontext(0, 0, listWidth, ctx.Height()))
+	ctx.Fill(listWidth, 0, 1, ctx.Height(), sv.uiConfig.BorderCharVertical,
+		sv.uiConfig.GetStyle(config.STYLE_BORDER))
+	sv.drawScript(ctx.Subcontext(listWidth+1, 0,
+		ctx.Width()-listWidth-1, ctx.Height()))
+}
+
+func (sv *SieveView) drawList(ctx *ui.Context) {
+	style := sv.uiConfig.GetStyle(config.STYLE_DIRLIST_DEFAULT)
+	if len(sv.scripts) == 0 {
+		if sv.status == "" {
+			ctx.Printf(0, 0, style, "(no scripts)")
+		}
+		return
+	}
+	if sv.selected < sv.scroll {
+		sv.scroll = sv.selected
+	}
+	if sv.selected >= sv.scroll+ctx.Height() {
+		sv.scroll = sv.selected - ctx.Height() + 1
+	}
+	for y, i := 0, sv.scroll; i < len(sv.scripts) && y < ctx.Height(); i++ {
+		s := sv.scripts[i]
+		style := sv.uiConfig.GetStyle(config.STYLE_DIRLIST_DEFAULT)
+		if i == sv.selected {
+			style = sv.uiConfig.GetStyleSelected(config.STYLE_DIRLIST_DEFAULT)
+		}
+		ctx.Fill(0, y, ctx.Width(), 1, ' ', style)
+		name := s.Name
+		if s.Active {
+			name += " (active)"
+		}
+		ctx.Printf(1, y, style, "%s",
+			runewidth.Truncate(name, ctx.Width()-1, "…"))
+		y++
+	}
+}
+
+func (sv *SieveView) drawScript(ctx *ui.Context) {
+	y := 0
+	if sv.status != "" {
+		style := sv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+		if sv.status != "loading..." {
+			style = sv.uiConfig.GetStyle(config.STYLE_ERROR)
+		}
+		ctx.Printf(1, y, style, "%s", sv.status)
+		y += 2
+	}
+	if sv.selected >= len(sv.scripts) {
+		return
+	}
+	name := sv.scripts[sv.selected].Name
+	ctx.Printf(1, y, sv.uiConfig.GetStyle(config.STYLE_TITLE), "%s", name)
+	y += 2
+	content := strings.ReplaceAll(sv.contents[name], "\r\n", "\n")
+	style := sv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	for _, line := range strings.Split(content, "\n") {
+		if y >= ctx.Height() {
+			break
+		}
+		line = strings.ReplaceAll(line, "\t", "    ")
+		ctx.Printf(1, y, style, "%s",
+			runewidth.Truncate(line, ctx.Width()-1, "…"))
+		y++
+	}
+}
@@ -0,0 +1,480 @@
+package widgets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib"
+	"git.sr.ht/~rjarry/aerc/lib/format"
+	"git.sr.ht/~rjarry/aerc/lib/pager"
+	"git.sr.ht/~rjarry/aerc/lib/parse"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/models"
+)
+
+// A threadMessage is a message of a conversation with its text, once
+// fetched.
+type threadMessage struct {
+	info   *models.MessageInfo
+	view   lib.MessageView
+	part   []int
+	blocks []parse.Block
+	err    error
+	folded bool
+	quotes bool
+}
+
+// A threadRow is a screen row of a conversation: a wrapped part of a line
+// of a message.
+type threadRow struct {
+	msg   int
+	line  pager.Line
+	start int
+	end   int
+	style tcell.Style
+}
+
+// ThreadViewer displays all the messages of a thread in chronological order
+// in a single scrollable view. Quoted text is collapsed and already read
+// messages are folded to their header line. Message commands act on the
+// focused message.
+type ThreadViewer struct {
+	acct     *AccountView
+	store    *lib.MessageStore
+	uiConfig *config.UIConfig
+
+	mu       sync.Mutex
+	messages []*threadMessage
+	focused  int
+	quotes   bool
+
+	rows   []threadRow
+	starts []int
+	scroll int
+	height int
+}
+
+// NewThreadViewer creates a viewer for the given messages and starts
+// fetching their text. If markRead is set, the messages are marked as read.
+func NewThreadViewer(
+	acct *AccountView, store *lib.MessageStore, infos []*models.MessageInfo,
+	markRead bool,
+) *ThreadViewer {
+	sort.SliceStable(infos, func(i, j int) bool {
+		return messageDate(infos[i]).Before(messageDate(infos[j]))
+	})
+	tv := &ThreadViewer{
+		acct:     acct,
+		store:    store,
+		uiConfig: acct.UiConfig(),
+		focused:  -1,
+	}
+	for i, info := range infos {
+		unread := !info.Flags.Has(models.SeenFlag)
+		last := i == len(infos)-1
+		tv.messages = append(tv.messages, &threadMessage{
+			info:   info,
+			folded: !unread && !last,
+		})
+		if tv.focused < 0 && (unread || last) {
+			tv.focused = i
+		}
+	}
+	for _, msg := range tv.messages {
+		tv.load(msg, markRead)
+	}
+	return tv
+}
+
+func messageDate(info *models.MessageInfo) (date time.Time) {
+	if info.Envelope != nil {
+		date = info.Envelope.Date
+	}
+	if date.IsZero() {
+		date = info.InternalDate
+	}
+	return date
+}
+
+// load decrypts a message if needed and fetches its plain text part.
+func (tv *ThreadViewer) load(msg *threadMessage, markRead bool) {
+	lib.NewMessageStoreView(msg.info, markRead, tv.store,
+		tv.acct.aerc.Crypto, tv.acct.aerc.DecryptKeys,
+		func(view lib.MessageView, err error) {
+			if err != nil {
+				tv.setText(msg, nil, nil, err)
+				return
+			}
+			part := lib.FindPlaintext(view.BodyStructure(), nil)
+			if part == nil {
+				tv.setText(msg, view, nil,
+					errors.New("no text/plain part, open it with :view"))
+				return
+			}
+			view.FetchBodyPart(part, func(r io.Reader) {
+				text, err := io.ReadAll(r)
+				if err != nil {
+					log.Warnf("failed to fetch message text: %v", err)
+				}
+				msg.part = part
+				tv.setText(msg, view, parse.SplitQuotes(string(text)), err)
+			})
+		})
+}
+
+func (tv *ThreadViewer) setText(
+	msg *threadMessage, view lib.MessageView, blocks []parse.Block, err error,
+) {
+	tv.mu.Lock()
+	msg.view = view
+	msg.blocks = blocks
+	msg.err = err
+	tv.mu.Unlock()
+	tv.Invalidate()
+}
+
+func (tv *ThreadViewer) Store() *lib.MessageStore {
+	return tv.store
+}
+
+func (tv *ThreadViewer) SelectedAccount() *AccountView {
+	return tv.acct
+}
+
+func (tv *ThreadViewer) SelectedMessage() (*models.MessageInfo, error) {
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+	if tv.focused < 0 || tv.focused >= len(tv.messages) {
+		return nil, errors.New("no message selected")
+	}
+	return tv.messages[tv.focused].info, nil
+}
+
+func (tv *ThreadViewer) SelectedMessagePart() *PartInfo {
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+	if tv.focused < 0 || tv.focused >= len(tv.messages) {
+		return nil
+	}
+	msg := tv.messages[tv.focused]
+	if msg.view == nil || msg.part == nil {
+		return nil
+	}
+	part, err := msg.view.BodyStructure().PartAtIndex(msg.part)
+	if err != nil {
+		return nil
+	}
+	return &PartInfo{Index: msg.part, Msg: msg.info, Part: part}
+}
+
+func (tv *ThreadViewer) MarkedMessages() ([]uint32, error) {
+	return tv.acct.MarkedMessages()
+}
+
+// Next focuses the message delta positions after the focused one and
+// scrolls to it.
+func (tv *ThreadViewer) Next(delta int) {
+	tv.mu.Lock()
+	tv.focused += delta
+	if tv.focused >= len(tv.messages) {
+		tv.focused = len(tv.messages) - 1
+	}
+	if tv.focused < 0 {
+		tv.focused = 0
+	}
+	if tv.focused < len(tv.starts) {
+		tv.scroll = tv.starts[tv.focused]
+	}
+	tv.mu.Unlock()
+	tv.Invalidate()
+}
+
+// Remove drops the given messages from the thread once they were deleted or
+// moved. It returns the number of messages left.
+func (tv *ThreadViewer) Remove(uids []uint32) int {
+	removed := make(map[uint32]bool, len(uids))
+	for _, uid := range uids {
+		removed[uid] = true
+	}
+	tv.mu.Lock()
+	var messages []*threadMessage
+	focused := tv.focused
+	for i, msg := range tv.messages {
+		if !removed[msg.info.Uid] {
+			messages = append(messages, msg)
+		} else if i < tv.focused {
+			focused--
+		}
+	}
+	tv.messages = messages
+	if focused >= len(messages) {
+		focused = len(messages) - 1
+	}
+	if focused < 0 {
+		focused = 0
+	}
+	tv.focused = focused
+	tv.mu.Unlock()
+	tv.Invalidate()
+	return len(messages)
+}
+
+// ToggleFold folds or unfolds the focused message, or all messages.
+func (tv *ThreadViewer) ToggleFold(all bool) {
+	tv.mu.Lock()
+	if tv.focused >= 0 && tv.focused < len(tv.messages) {
+		folded := !tv.messages[tv.focused].folded
+		for i, msg := range tv.messages {
+			if all || i == tv.focused {
+				msg.folded = folded
+			}
+		}
+	}
+	tv.mu.Unlock()
+	tv.Invalidate()
+}
+
+// ToggleQuotes shows or collapses the quoted text of the focused message, or
+// of all messages.
+func (tv *ThreadViewer) ToggleQuotes(all bool) {
+	tv.mu.Lock()
+	if all {
+		tv.quotes = !tv.quotes
+		for _, msg := range tv.messages {
+			msg.quotes = tv.quotes
+		}
+	} else if tv.focused >= 0 && tv.focused < len(tv.messages) {
+		msg := tv.messages[tv.focused]
+		msg.quotes = !msg.quotes
+	}
+	tv.mu.Unlock()
+	tv.Invalidate()
+}
+
+func (tv *ThreadViewer) Invalidate() {
+	ui.Invalidate()
+}
+
+func (tv *ThreadViewer) Focus(focus bool) {
+}
+
+// layout wraps the messages in rows for the given width. The caller must
+// hold the lock.
+func (tv *ThreadViewer) layout(width int) {
+	defaultStyle := tv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	headerStyle := tv.uiConfig.GetStyle(config.STYLE_HEADER)
+	focusedStyle := tv.uiConfig.GetStyle(config.STYLE_TITLE)
+	errorStyle := tv.uiConfig.GetStyle(config.STYLE_ERROR)
+	quoteStyle := defaultStyle.Dim(true)
+
+	tv.rows = tv.rows[:0]
+	tv.starts = tv.starts[:0]
+	add := func(msg int, text string, style tcell.Style) {
+		line := pager.NewLine(text)
+		for _, row := range pager.Wrap([]pager.Line{line}, width) {
+			tv.rows = append(tv.rows, threadRow{
+				msg: msg, line: line, start: row.Start, end: row.End,
+				style: style,
+			})
+		}
+	}
+	for i, msg := range tv.messages {
+		tv.starts = append(tv.starts, len(tv.rows))
+		style := headerStyle
+		if i == tv.focused {
+			style = focusedStyle
+		}
+		if !msg.info.Flags.Has(models.SeenFlag) {
+			style = style.Bold(true)
+		}
+		add(i, tv.header(msg), style)
+		if msg.folded {
+			continue
+		}
+		switch {
+		case msg.err != nil:
+			add(i, msg.err.Error(), errorStyle)
+		case msg.view == nil:
+			add(i, "Fetching message...", defaultStyle)
+		}
+		for _, block := range msg.blocks {
+			if block.Quoted && !msg.quotes {
+				add(i, fmt.Sprintf("[%d quoted lines]", len(block.Lines)),
+					quoteStyle)
+				continue
+			}
+			style := defaultStyle
+			if block.Quoted {
+				style = quoteStyle
+			}
+			for _, line := range block.Lines {
+				add(i, line, style)
+			}
+		}
+		add(i, "", defaultStyle)
+	}
+}
+
+func (tv *ThreadViewer) header(msg *threadMessage) string {
+	marker := "▾"
+	if msg.folded {
+		marker = "▸"
+	}
+	from, subject := "", ""
+	if msg.info.Envelope != nil {
+		from = format.FormatAddresses(msg.info.Envelope.From)
+		subject = msg.info.Envelope.Subject
+	}
+	date := format.DummyIfZeroDate(messageDate(msg.info).Local(),
+		tv.uiConfig.MessageViewTimestampFormat,
+		tv.uiConfig.MessageViewThisDayTimeFormat,
+		tv.uiConfig.MessageViewThisWeekTimeFormat,
+		tv.uiConfig.MessageViewThisYearTimeFormat)
+	if msg.folded {
+		return fmt.Sprintf("%s %s  %s  %s", marker, date, from, subject)
+	}
+	return fmt.Sprintf("%s %s  %s", marker, date, from)
+}
+
+func (tv *ThreadViewer) Draw(ctx *ui.Context) {
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+
+	defaultStyle := tv.uiConfig.GetStyle(config.STYLE_DEFAULT)
+	ctx.Fill(0, 0, ctx.Width(), ctx.Height(), ' ', defaultStyle)
+	tv.height = ctx.Height()
+	tv.layout(ctx.Width())
+	tv.clampScroll()
+
+	for y := 0; y < ctx.Height() && tv.scroll+y < len(tv.rows); y++ {
+		row := tv.rows[tv.scroll+y]
+		x := 0
+		for _, r := range row.line.Runes[row.start:row.end] {
+			ctx.SetCell(x, y, r.Value, row.style)
+			x += r.Width
+		}
+	}
+}
+
+// clampScroll keeps the last row at the bottom of the screen. The caller
+// must hold the lock.
+func (tv *ThreadViewer) clampScroll() {
+	if tv.scroll > len(tv.rows)-tv.height {
+		tv.scroll = len(tv.rows) - tv.height
+	}
+	if tv.scroll < 0 {
+		tv.scroll = 0
+	}
+}
+
+func (tv *ThreadViewer) top() {
+	tv.mu.Lock()
+	tv.scroll = 0
+	tv.mu.Unlock()
+	tv.Invalidate()
+}
+
+func (tv *ThreadViewer) bottom() {
+	tv.mu.Lock()
+	tv.scroll = len(tv.rows)
+	tv.clampScroll()
+	tv.mu.Unlock()
+	tv.Invalidate()
+}
+
+func (tv *ThreadViewer) down(n int) {
+	tv.mu.Lock()
+	tv.scroll += n
+	tv.clampScroll()
+	tv.mu.Unlock()
+	tv.Invalidate()
+}
+
+func (tv *ThreadViewer) Event(event tcell.Event) bool {
+	key, ok := event.(*tcell.EventKey)
+	if !ok {
+		return false
+	}
+	page := tv.height
+	switch key.Key() {
+	case tcell.KeyDown, tcell.KeyCtrlN, tcell.KeyCtrlE:
+		tv.down(1)
+	case tcell.KeyUp, tcell.KeyCtrlP, tcell.KeyCtrlY:
+		tv.down(-1)
+	case tcell.KeyPgDn, tcell.KeyCtrlF:
+		tv.down(page)
+	case tcell.KeyPgUp, tcell.KeyCtrlB:
+		tv.down(-page)
+	case tcell.KeyCtrlD:
+		tv.down(page / 2)
+	case tcell.KeyCtrlU:
+		tv.down(-page / 2)
+	case tcell.KeyHome:
+		tv.top()
+	case tcell.KeyEnd:
+		tv.bottom()
+	case tcell.KeyTab:
+		tv.Next(1)
+	case tcell.KeyBacktab:
+		tv.Next(-1)
+	case tcell.KeyEnter:
+		tv.ToggleFold(false)
+	case tcell.KeyRune:
+		switch key.Rune() {
+		case 'j':
+			tv.down(1)
+		case 'k':
+			tv.down(-1)
+		case ' ', 'f':
+			tv.down(page)
+		case 'b':
+			tv.down(-page)
+		case 'd':
+			tv.down(page / 2)
+		case 'u':
+			tv.down(-page / 2)
+		case 'g':
+			tv.top()
+		case 'G':
+			tv.bottom()
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (tv *ThreadViewer) MouseEvent(localX int, localY int, event tcell.Event) {
+	mouse, ok := event.(*tcell.EventMouse)
+	if !ok {
+		return
+	}
+	switch mouse.Buttons() {
+	case tcell.WheelDown:
+		tv.down(3)
+	case tcell.WheelUp:
+		tv.down(-3)
+	case tcell.Button1:
+		tv.mu.Lock()
+		row := tv.scroll + localY
+		if row < len(tv.rows) {
+			msg := tv.rows[row].msg
+			if tv.starts[msg] == row && msg == tv.focused {
+				tv.messages[msg].folded = !tv.messages[msg].folded
+			}
+			tv.focused = msg
+		}
+		tv.mu.Unlock()
+		tv.Invalidate()
+	}
+}
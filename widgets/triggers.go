@@ -0,0 +1,180 @@
+package widgets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"git.sr.ht/~rjarry/aerc/config"
+	"git.sr.ht/~rjarry/aerc/lib/ipc"
+	"git.sr.ht/~rjarry/aerc/lib/state"
+	"git.sr.ht/~rjarry/aerc/lib/templates"
+	"git.sr.ht/~rjarry/aerc/lib/ui"
+	"git.sr.ht/~rjarry/aerc/log"
+	"git.sr.ht/~rjarry/aerc/models"
+	"github.com/emersion/go-message/mail"
+)
+
+var (
+	// running and queued shell triggers, waited for on exit
+	shellTriggers sync.WaitGroup
+	// shell triggers run one at a time, the first one is running
+	shellQueue []func()
+	shellLock  sync.Mutex
+)
+
+// RunTrigger executes a trigger with the given template data. aerc commands
+// are executed immediately and must be run from the main loop. Shell
+// commands are run in the background, one at a time and in order. Errors are
+// pushed to the status line.
+func (aerc *Aerc) RunTrigger(t config.Trigger, data models.TemplateData) {
+	switch {
+	case t.Shell != "":
+		var buf bytes.Buffer
+		tmpl, err := templates.ParseTemplate(t.Name, t.Shell)
+		if err == nil {
+			err = templates.Render(tmpl, &buf, data)
+		}
+		if err != nil {
+			aerc.PushError(fmt.Sprintf("%s: %v", t.Name, err))
+			return
+		}
+		log.Debugf("%s: running %q", t.Name, buf.String())
+		cmd := exec.Command("sh", "-c", buf.String())
+		cmd.Env = append(os.Environ(),
+			"AERC_TRIGGER="+t.Name,
+			"AERC_ACCOUNT="+data.Account(),
+			"AERC_FOLDER="+data.Folder())
+		queueShellTrigger(func() {
+			if out, err := cmd.CombinedOutput(); err != nil {
+				log.Errorf("%s: %v: %s", t.Name, err, out)
+				aerc.PushError(fmt.Sprintf("%s: %v", t.Name, err))
+			}
+		})
+	case len(t.Command) > 0:
+		log.Debugf("%s: executing %v", t.Name, t.Command)
+		if err := aerc.cmd(t.Command, data); err != nil {
+			aerc.PushError(fmt.Sprintf("%s: %v", t.Name, err))
+		}
+	}
+}
+
+// queueShellTrigger runs the shell trigger after the queued ones so that
+// events on many messages do not start as many processes at once.
+func queueShellTrigger(run func()) {
+	shellTriggers.Add(1)
+	shellLock.Lock()
+	defer shellLock.Unlock()
+	shellQueue = append(shellQueue, run)
+	if len(shellQueue) > 1 {
+		return
+	}
+	go func() {
+		defer log.PanicHandler()
+		for {
+			shellLock.Lock()
+			run := shellQueue[0]
+			shellLock.Unlock()
+
+			run()
+			shellTriggers.Done()
+
+			shellLock.Lock()
+			shellQueue = shellQueue[1:]
+			empty := len(shellQueue) == 0
+			shellLock.Unlock()
+			if empty {
+				return
+			}
+		}
+	}()
+}
+
+// WaitTriggers waits at most timeout for the shell triggers to complete.
+func WaitTriggers(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		defer log.PanicHandler()
+		shellTriggers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Warnf("shell triggers still running after %s", timeout)
+	}
+}
+
+// triggerData returns the template data of a trigger for the account.
+func (acct *AccountView) triggerData(
+	folder string, msg *models.MessageInfo,
+) *state.TemplateData {
+	var data state.TemplateData
+	data.SetAccount(acct.acct)
+	data.SetFolder(folder)
+	data.SetInfo(msg, 0, false)
+	data.SetState(&acct.state)
+	return &data
+}
+
+// runTrigger executes a trigger once for each message, or once without
+// message info when msgs is empty.
+func (acct *AccountView) runTrigger(
+	t config.Trigger, folder string, msgs ...*models.MessageInfo,
+) {
+	if !t.IsSet() {
+		return
+	}
+	if len(msgs) == 0 {
+		acct.aerc.RunTrigger(t, acct.triggerData(folder, nil))
+	}
+	for _, msg := range msgs {
+		acct.aerc.RunTrigger(t, acct.triggerData(folder, msg))
+	}
+}
+
+// triggerOpened runs the compose-opened trigger once the composer is shown in
+// its tab.
+func (c *Composer) triggerOpened() {
+	if !config.Triggers.ComposeOpened.IsSet() {
+		return
+	}
+	var data state.TemplateData
+	data.SetAccount(c.acctConfig)
+	data.SetFolder(c.acct.Directories().Selected())
+	data.SetHeaders(c.header, c.parent)
+	c.aerc.RunTrigger(config.Triggers.ComposeOpened, &data)
+}
+
+// TriggerSent runs the mail-sent trigger after the message of the composer
+// was sent with the given header. It may be called from any goroutine.
+func (c *Composer) TriggerSent(header *mail.Header) {
+	c.acct.publish(&ipc.Event{
+		Event:   ipc.EventMailSent,
+		Folder:  c.acctConfig.CopyTo,
+		Message: headerMessage(header),
+	})
+	if !config.Triggers.MailSent.IsSet() {
+		return
+	}
+	var data state.TemplateData
+	data.SetAccount(c.acctConfig)
+	data.SetFolder(c.acctConfig.CopyTo)
+	data.SetHeaders(header, c.parent)
+	ui.QueueFunc(func() {
+		c.aerc.RunTrigger(config.Triggers.MailSent, &data)
+	})
+}
+
+// SendFailed reports the failure to send the message of the composer to the
+// IPC clients. It may be called from any goroutine.
+func (c *Composer) SendFailed(header *mail.Header, err error) {
+	c.acct.publish(&ipc.Event{
+		Event:   ipc.EventSendFailed,
+		Message: headerMessage(header),
+		Error:   err.Error(),
+	})
+}
@@ -23,6 +23,7 @@ type CachedHeader struct {
 	BodyStructure models.BodyStructure
 	Envelope      models.Envelope
 	InternalDate  time.Time
+	Size          uint32
 	Uid           uint32
 	Header        []byte
 	Created       time.Time
@@ -65,6 +66,7 @@ func (w *IMAPWorker) cacheHeader(mi *models.MessageInfo) {
 		BodyStructure: *mi.BodyStructure,
 		Envelope:      *mi.Envelope,
 		InternalDate:  mi.InternalDate,
+		Size:          mi.Size,
 		Uid:           mi.Uid,
 		Header:        hdr.Bytes(),
 		Created:       time.Now(),
@@ -115,6 +117,7 @@ func (w *IMAPWorker) getCachedHeaders(msg *types.FetchMessageHeaders) []uint32 {
 			BodyStructure: &ch.BodyStructure,
 			Envelope:      &ch.Envelope,
 			Flags:         models.SeenFlag, // Always return a SEEN flag
+			Size:          ch.Size,
 			Uid:           ch.Uid,
 			RFC822Headers: hdr,
 		}
@@ -41,6 +41,7 @@ func (imapw *IMAPWorker) handleFetchMessageHeaders(
 		imap.FetchInternalDate,
 		imap.FetchFlags,
 		imap.FetchUid,
+		imap.FetchRFC822Size,
 		section.FetchItem(),
 	}
 	imapw.handleFetchMessages(msg, toFetch, items,
@@ -64,6 +65,7 @@ func (imapw *IMAPWorker) handleFetchMessageHeaders(
 				Flags:         translateImapFlags(_msg.Flags),
 				InternalDate:  _msg.InternalDate,
 				RFC822Headers: header,
+				Size:          _msg.Size,
 				Uid:           _msg.Uid,
 			}
 			refs, err := header.MsgIDList("references")
@@ -2,6 +2,7 @@ package imap
 
 import (
 	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
 
 	"git.sr.ht/~rjarry/aerc/log"
 	"git.sr.ht/~rjarry/aerc/models"
@@ -121,3 +122,120 @@ func (imapw *IMAPWorker) handleSearchDirectory(msg *types.SearchDirectory) {
 		Uids:    uids,
 	}, nil)
 }
+
+func (imapw *IMAPWorker) handleSearchDirectories(msg *types.SearchDirectories) {
+	emitError := func(err error) {
+		imapw.worker.PostMessage(&types.Error{
+			Message: types.RespondTo(msg),
+			Error:   err,
+		}, nil)
+	}
+
+	var criteria *imap.SearchCriteria
+	for _, argv := range msg.Searches {
+		alt, err := parseSearch(argv)
+		if err != nil {
+			emitError(err)
+			return
+		}
+		if criteria == nil {
+			criteria = alt
+		} else {
+			criteria = &imap.SearchCriteria{
+				Or: [][2]*imap.SearchCriteria{{criteria, alt}},
+			}
+		}
+	}
+	if criteria == nil {
+		criteria = imap.NewSearchCriteria()
+	}
+
+	// search on a separate connection to keep the selected directory and to
+	// not block the worker while each directory is examined in turn. The
+	// connection is kept for the next searches, which run one at a time.
+	go func() {
+		defer log.PanicHandler()
+		imapw.searchLock.Lock()
+		defer imapw.searchLock.Unlock()
+		if imapw.search == nil || imapw.search.State() == imap.LogoutState {
+			c, err := imapw.connect()
+			if err != nil {
+				emitError(err)
+				return
+			}
+			c.Timeout = imapw.config.connection_timeout
+			imapw.search = c
+		}
+		imapw.searchDirectories(imapw.search, msg, criteria)
+		imapw.worker.PostMessage(
+			&types.Done{Message: types.RespondTo(msg)}, nil)
+	}()
+}
+
+// closeSearch logs out of the search connection once the running search, if
+// any, is over.
+func (imapw *IMAPWorker) closeSearch() {
+	go func() {
+		defer log.PanicHandler()
+		imapw.searchLock.Lock()
+		defer imapw.searchLock.Unlock()
+		if imapw.search == nil {
+			return
+		}
+		if err := imapw.search.Logout(); err != nil {
+			log.Debugf("search connection logout: %v", err)
+		}
+		imapw.search = nil
+	}()
+}
+
+func (imapw *IMAPWorker) searchDirectories(c *client.Client,
+	msg *types.SearchDirectories, criteria *imap.SearchCriteria,
+) {
+	items := []imap.FetchItem{
+		imap.FetchEnvelope,
+		imap.FetchInternalDate,
+		imap.FetchFlags,
+		imap.FetchUid,
+	}
+	for _, dir := range msg.Directories {
+		if _, err := c.Select(dir, true); err != nil {
+			log.Errorf("could not examine %s: %v", dir, err)
+			continue
+		}
+		uids, err := c.UidSearch(criteria)
+		if err != nil {
+			log.Errorf("could not search %s: %v", dir, err)
+			continue
+		}
+		if len(uids) == 0 {
+			continue
+		}
+		messages := make(chan *imap.Message)
+		done := make(chan []*models.MessageInfo)
+		go func() {
+			defer log.PanicHandler()
+			var infos []*models.MessageInfo
+			for _msg := range messages {
+				infos = append(infos, &models.MessageInfo{
+					Envelope:     translateEnvelope(_msg.Envelope),
+					Flags:        translateImapFlags(_msg.Flags),
+					InternalDate: _msg.InternalDate,
+					Uid:          _msg.Uid,
+				})
+			}
+			done <- infos
+		}()
+		err = c.UidFetch(toSeqSet(uids), items, messages)
+		infos := <-done
+		if err != nil {
+			log.Errorf("could not fetch results from %s: %v", dir, err)
+			continue
+		}
+		imapw.worker.PostMessage(&types.DirectorySearchResults{
+			Message:   types.RespondTo(msg),
+			Directory: dir,
+			Infos:     infos,
+		}, nil)
+	}
+}
@@ -3,6 +3,7 @@ package imap
 import (
 	"fmt"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/emersion/go-imap"
@@ -75,6 +76,10 @@ type IMAPWorker struct {
 
 	threadAlgorithm sortthread.ThreadAlgorithm
 	liststatus      bool
+
+	// search is the connection of SearchDirectories
+	search     *client.Client
+	searchLock sync.Mutex
 }
 
 func NewIMAPWorker(worker *types.Worker) (types.Backend, error) {
@@ -187,6 +192,7 @@ func (w *IMAPWorker) handleMessage(msg types.WorkerMessage) error {
 	case *types.Disconnect:
 		w.observer.SetAutoReconnect(false)
 		w.observer.Stop()
+		w.closeSearch()
 		if w.client == nil || w.client.State() != imap.SelectedState {
 			reterr = errNotConnected
 			break
@@ -231,6 +237,8 @@ func (w *IMAPWorker) handleMessage(msg types.WorkerMessage) error {
 		w.handleAppendMessage(msg)
 	case *types.SearchDirectory:
 		w.handleSearchDirectory(msg)
+	case *types.SearchDirectories:
+		w.handleSearchDirectories(msg)
 	case *types.CheckMail:
 		w.handleCheckMailMessage(msg)
 	default:
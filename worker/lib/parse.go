@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strings"
 	"time"
@@ -268,6 +269,12 @@ func MessageInfo(raw RawMessage) (*models.MessageInfo, error) {
 		return nil, err
 	}
 	defer r.Close()
+	var size uint32
+	if f, ok := r.(*os.File); ok {
+		if st, err := f.Stat(); err == nil {
+			size = uint32(st.Size())
+		}
+	}
 	msg, err := ReadMessage(r)
 	if err != nil {
 		return nil, fmt.Errorf("could not read message: %w", err)
@@ -308,7 +315,7 @@ func MessageInfo(raw RawMessage) (*models.MessageInfo, error) {
 		Labels:        labels,
 		InternalDate:  recDate,
 		RFC822Headers: &mail.Header{Header: msg.Header},
-		Size:          0,
+		Size:          size,
 		Uid:           raw.UID(),
 		Error:         parseErr,
 	}, nil
@@ -109,6 +109,22 @@ func Search(messages []RawMessage, criteria *searchCriteria) ([]uint32, error) {
 	return matchedUids, nil
 }
 
+// UnionUids returns the uids of all lists without duplicates, in the order
+// they first appear.
+func UnionUids(lists [][]uint32) []uint32 {
+	seen := make(map[uint32]bool)
+	var uids []uint32
+	for _, list := range lists {
+		for _, uid := range list {
+			if !seen[uid] {
+				seen[uid] = true
+				uids = append(uids, uid)
+			}
+		}
+	}
+	return uids
+}
+
 // searchMessage executes the search criteria for the given RawMessage,
 // returns true if search succeeded
 func searchMessage(message RawMessage, criteria *searchCriteria,
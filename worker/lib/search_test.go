@@ -0,0 +1,17 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnionUids(t *testing.T) {
+	uids := UnionUids([][]uint32{{3, 1}, nil, {1, 2, 3, 4}})
+	expected := []uint32{3, 1, 2, 4}
+	if !reflect.DeepEqual(uids, expected) {
+		t.Errorf("expected %v, got %v", expected, uids)
+	}
+	if uids := UnionUids(nil); len(uids) != 0 {
+		t.Errorf("expected no uids, got %v", uids)
+	}
+}
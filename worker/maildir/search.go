@@ -102,10 +102,16 @@ func getParsedFlag(name string) maildir.Flag {
 }
 
 func (w *Worker) search(criteria *searchCriteria) ([]uint32, error) {
+	return w.searchDir(*w.selected, criteria)
+}
+
+// searchDir runs the search criteria against all messages of the given
+// maildir, which does not need to be the selected one.
+func (w *Worker) searchDir(dir maildir.Dir, criteria *searchCriteria) ([]uint32, error) {
 	requiredParts := getRequiredParts(criteria)
 	log.Debugf("Required parts bitmask for search: %b", requiredParts)
 
-	keys, err := w.c.UIDs(*w.selected)
+	keys, err := w.c.UIDs(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +128,7 @@ func (w *Worker) search(criteria *searchCriteria) ([]uint32, error) {
 		go func(key uint32) {
 			defer log.PanicHandler()
 			defer wg.Done()
-			success, err := w.searchKey(key, criteria, requiredParts)
+			success, err := w.searchKey(dir, key, criteria, requiredParts)
 			if err != nil {
 				// don't return early so that we can still get some results
 				log.Errorf("Failed to search key %d: %v", key, err)
@@ -139,10 +145,10 @@ func (w *Worker) search(criteria *searchCriteria) ([]uint32, error) {
 }
 
 // Execute the search criteria for the given key, returns true if search succeeded
-func (w *Worker) searchKey(key uint32, criteria *searchCriteria,
-	parts MsgParts,
+func (w *Worker) searchKey(dir maildir.Dir, key uint32,
+	criteria *searchCriteria, parts MsgParts,
 ) (bool, error) {
-	message, err := w.c.Message(*w.selected, key)
+	message, err := w.c.Message(dir, key)
 	if err != nil {
 		return false, err
 	}
@@ -286,6 +286,8 @@ func (w *Worker) handleMessage(msg types.WorkerMessage) error {
 		return w.handleAppendMessage(msg)
 	case *types.SearchDirectory:
 		return w.handleSearchDirectory(msg)
+	case *types.SearchDirectories:
+		return w.handleSearchDirectories(msg)
 	}
 	return errUnsupported
 }
@@ -805,6 +807,58 @@ func (w *Worker) handleSearchDirectory(msg *types.SearchDirectory) error {
 	return nil
 }
 
+func (w *Worker) handleSearchDirectories(msg *types.SearchDirectories) error {
+	var searches []*searchCriteria
+	for _, argv := range msg.Searches {
+		criteria, err := parseSearch(argv)
+		if err != nil {
+			return err
+		}
+		searches = append(searches, criteria)
+	}
+	for _, name := range msg.Directories {
+		dir := w.c.Store.Dir(name)
+		var results [][]uint32
+		var err error
+		for _, criteria := range searches {
+			var uids []uint32
+			uids, err = w.searchDir(dir, criteria)
+			if err != nil {
+				break
+			}
+			results = append(results, uids)
+		}
+		if err != nil {
+			log.Errorf("could not search %s: %v", name, err)
+			continue
+		}
+		uids := lib.UnionUids(results)
+		if len(uids) == 0 {
+			continue
+		}
+		infos := make([]*models.MessageInfo, 0, len(uids))
+		for _, uid := range uids {
+			m, err := w.c.Message(dir, uid)
+			if err != nil {
+				log.Errorf("could not get message %d: %v", uid, err)
+				continue
+			}
+			info, err := m.MessageInfo()
+			if err != nil {
+				log.Errorf("could not get message info %d: %v", uid, err)
+				continue
+			}
+			infos = append(infos, info)
+		}
+		w.worker.PostMessage(&types.DirectorySearchResults{
+			Message:   types.RespondTo(msg),
+			Directory: name,
+			Infos:     infos,
+		}, nil)
+	}
+	return nil
+}
+
 func (w *Worker) msgInfoFromUid(uid uint32) (*models.MessageInfo, error) {
 	m, err := w.c.Message(*w.selected, uid)
 	if err != nil {
@@ -325,6 +325,53 @@ func (w *mboxWorker) handleMessage(msg types.WorkerMessage) error {
 			Uids:    uids,
 		}, nil)
 
+	case *types.SearchDirectories:
+		for _, name := range msg.Directories {
+			folder, ok := w.data.Mailbox(name)
+			if !ok {
+				continue
+			}
+			var results [][]uint32
+			for _, argv := range msg.Searches {
+				var uids []uint32
+				uids, reterr = filterUids(folder, folder.Uids(), argv)
+				if reterr != nil {
+					break
+				}
+				results = append(results, uids)
+			}
+			if reterr != nil {
+				break
+			}
+			uids := lib.UnionUids(results)
+			if len(uids) == 0 {
+				continue
+			}
+			infos := make([]*models.MessageInfo, 0, len(uids))
+			for _, uid := range uids {
+				m, err := folder.Message(uid)
+				if err != nil {
+					log.Errorf("could not get message %d: %v", uid, err)
+					continue
+				}
+				info, err := lib.MessageInfo(m)
+				if err != nil {
+					log.Errorf("could not get message info %d: %v", uid, err)
+					continue
+				}
+				infos = append(infos, info)
+			}
+			w.worker.PostMessage(&types.DirectorySearchResults{
+				Message:   types.RespondTo(msg),
+				Directory: name,
+				Infos:     infos,
+			}, nil)
+		}
+		if reterr == nil {
+			w.worker.PostMessage(
+				&types.Done{Message: types.RespondTo(msg)}, nil)
+		}
+
 	case *types.AppendMessage:
 		if msg.Destination == "" {
 			reterr = fmt.Errorf("AppendMessage with empty destination directory")
@@ -14,12 +14,15 @@ type WorkerMessage interface {
 	setId(id int64)
 	Account() string
 	setAccount(string)
+	Worker() *Worker
+	setWorker(*Worker)
 }
 
 type Message struct {
 	inResponseTo WorkerMessage
 	id           int64
 	acct         string
+	worker       *Worker
 }
 
 func RespondTo(msg WorkerMessage) Message {
@@ -48,6 +51,15 @@ func (m *Message) setAccount(name string) {
 	m.acct = name
 }
 
+// Worker returns the worker that posted the message.
+func (m *Message) Worker() *Worker {
+	return m.worker
+}
+
+func (m *Message) setWorker(worker *Worker) {
+	m.worker = worker
+}
+
 // Meta-messages
 
 type Done struct {
@@ -113,6 +125,16 @@ type SearchDirectory struct {
 	Argv []string
 }
 
+// SearchDirectories runs searches over several directories at once without
+// changing the selected one. A message matches when it matches any of the
+// searches. Each directory with matches yields a DirectorySearchResults
+// message before the final Done.
+type SearchDirectories struct {
+	Message
+	Directories []string
+	Searches    [][]string
+}
+
 type DirectoryThreaded struct {
 	Message
 	Threads []*Thread
@@ -221,6 +243,12 @@ type SearchResults struct {
 	Uids []uint32
 }
 
+type DirectorySearchResults struct {
+	Message
+	Directory string
+	Infos     []*models.MessageInfo
+}
+
 type MessageInfo struct {
 	Message
 	Info       *models.MessageInfo
@@ -17,6 +17,7 @@ type Thread struct {
 
 	Hidden  bool // if this flag is set the message isn't rendered in the UI
 	Deleted bool // if this flag is set the message was deleted
+	Folded  bool // if this flag is set the children aren't rendered in the UI
 }
 
 // AddChild appends the child node at the end of the existing children of t.
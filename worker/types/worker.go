@@ -25,6 +25,7 @@ type Worker struct {
 	messageCallbacks map[int64]func(msg WorkerMessage)
 	actionQueue      *list.List
 	status           int32
+	pending          map[int64]struct{}
 
 	sync.Mutex
 }
@@ -36,6 +37,7 @@ func NewWorker(name string) *Worker {
 		actionCallbacks:  make(map[int64]func(msg WorkerMessage)),
 		messageCallbacks: make(map[int64]func(msg WorkerMessage)),
 		actionQueue:      list.New(),
+		pending:          make(map[int64]struct{}),
 	}
 }
 
@@ -95,10 +97,19 @@ func (worker *Worker) PostAction(msg WorkerMessage, cb func(msg WorkerMessage))
 	if cb != nil {
 		worker.Lock()
 		worker.actionCallbacks[msg.getId()] = cb
+		worker.pending[msg.getId()] = struct{}{}
 		worker.Unlock()
 	}
 }
 
+// Pending returns the number of actions posted with a callback that did not
+// receive a final response yet.
+func (worker *Worker) Pending() int {
+	worker.Lock()
+	defer worker.Unlock()
+	return len(worker.pending)
+}
+
 // PostMessage posts an message to the UI. This method should not be called
 // from the same goroutine that the UI runs in or deadlocks may occur
 func (worker *Worker) PostMessage(msg WorkerMessage,
@@ -106,6 +117,7 @@ func (worker *Worker) PostMessage(msg WorkerMessage,
 ) {
 	worker.setId(msg)
 	msg.setAccount(worker.Name)
+	msg.setWorker(worker)
 
 	if resp := msg.InResponseTo(); resp != nil {
 		log.Tracef("(%s) PostMessage %T:%T", worker.Name, msg, resp)
@@ -130,6 +142,11 @@ func (worker *Worker) ProcessMessage(msg WorkerMessage) WorkerMessage {
 	if inResponseTo := msg.InResponseTo(); inResponseTo != nil {
 		worker.Lock()
 		f, ok := worker.actionCallbacks[inResponseTo.getId()]
+		switch msg.(type) {
+		case *Done, *Error, *Unsupported:
+			// errors are not always final but the action is answered
+			delete(worker.pending, inResponseTo.getId())
+		}
 		worker.Unlock()
 		if ok {
 			f(msg)
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWorkerPending(t *testing.T) {
+	worker := NewWorker("test")
+	go func() {
+		for range worker.Actions {
+		}
+	}()
+
+	var responses int
+	cb := func(msg WorkerMessage) { responses++ }
+	open := &OpenDirectory{Directory: "INBOX"}
+	flag := &FlagMessages{Uids: []uint32{1, 2}}
+	worker.PostAction(open, cb)
+	worker.PostAction(flag, cb)
+	worker.PostAction(&Configure{}, nil)
+	if n := worker.Pending(); n != 2 {
+		t.Fatalf("expected 2 pending actions, got %d", n)
+	}
+
+	worker.ProcessMessage(&DirectoryInfo{Message: RespondTo(open)})
+	if n := worker.Pending(); n != 2 {
+		t.Errorf("expected 2 pending actions, got %d", n)
+	}
+	worker.ProcessMessage(&Done{Message: RespondTo(open)})
+	if n := worker.Pending(); n != 1 {
+		t.Errorf("expected 1 pending action, got %d", n)
+	}
+	worker.ProcessMessage(&Error{Message: RespondTo(flag), Error: errors.New("fail")})
+	worker.ProcessMessage(&Done{Message: RespondTo(flag)})
+	if n := worker.Pending(); n != 0 {
+		t.Errorf("expected no pending actions, got %d", n)
+	}
+	if responses != 4 {
+		t.Errorf("expected 4 responses, got %d", responses)
+	}
+}